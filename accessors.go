@@ -0,0 +1,90 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+)
+
+// Nil-safe accessors for the optional URL fields, e.g. for use in templates.
+// The String accessors return "" if the URL is not present,
+// the Has accessors return true only if the URL is present and has a host.
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func hasURL(u *url.URL) bool {
+	return u != nil && u.Host != ""
+}
+
+// IssuesURLString returns IssuesURL as string, or "" if not present.
+func (c *CurseForge) IssuesURLString() string { return urlString(c.IssuesURL) }
+
+// HasIssuesURL returns true if IssuesURL is present.
+func (c *CurseForge) HasIssuesURL() bool { return hasURL(c.IssuesURL) }
+
+// WikiURLString returns WikiURL as string, or "" if not present.
+func (c *CurseForge) WikiURLString() string { return urlString(c.WikiURL) }
+
+// HasWikiURL returns true if WikiURL is present.
+func (c *CurseForge) HasWikiURL() bool { return hasURL(c.WikiURL) }
+
+// SourceURLString returns SourceURL as string, or "" if not present.
+func (c *CurseForge) SourceURLString() string { return urlString(c.SourceURL) }
+
+// HasSourceURL returns true if SourceURL is present.
+func (c *CurseForge) HasSourceURL() bool { return hasURL(c.SourceURL) }
+
+// DonationURLString returns DontationURL as string, or "" if not present.
+func (c *CurseForge) DonationURLString() string { return urlString(c.DontationURL) }
+
+// HasDonationURL returns true if DontationURL is present.
+func (c *CurseForge) HasDonationURL() bool { return hasURL(c.DontationURL) }
+
+// CurseURLString returns CurseURL as string, or "" if not present.
+func (c *CurseForge) CurseURLString() string { return urlString(c.CurseURL) }
+
+// HasCurseURL returns true if CurseURL is present.
+func (c *CurseForge) HasCurseURL() bool { return hasURL(c.CurseURL) }
+
+// DonationURLString returns DontationURL as string, or "" if not present.
+func (c *Curse) DonationURLString() string { return urlString(c.DontationURL) }
+
+// HasDonationURL returns true if DontationURL is present.
+func (c *Curse) HasDonationURL() bool { return hasURL(c.DontationURL) }
+
+// CurseforgeURLString returns CurseforgeURL as string, or "" if not present.
+func (c *Curse) CurseforgeURLString() string { return urlString(c.CurseforgeURL) }
+
+// HasCurseforgeURL returns true if CurseforgeURL is present.
+func (c *Curse) HasCurseforgeURL() bool { return hasURL(c.CurseforgeURL) }
+
+// DirectURLString returns DirectURL as string, or "" if not present.
+func (f *File) DirectURLString() string { return urlString(f.DirectURL) }
+
+// HasDirectURL returns true if DirectURL is present.
+func (f *File) HasDirectURL() bool { return hasURL(f.DirectURL) }
+
+// ImageURLString returns ImageURL as string, or "" if not present.
+func (a *Author) ImageURLString() string { return urlString(a.ImageURL) }
+
+// HasImageURL returns true if ImageURL is present.
+func (a *Author) HasImageURL() bool { return hasURL(a.ImageURL) }
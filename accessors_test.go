@@ -0,0 +1,41 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestURLAccessors(t *testing.T) {
+	wikiURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := &CurseForge{WikiURL: wikiURL, SourceURL: &url.URL{}}
+
+	if !results.HasWikiURL() || results.WikiURLString() != "https://minecraft.curseforge.com/projects/taam/pages" {
+		t.Errorf("Expected WikiURL '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/pages", results.WikiURLString())
+	}
+	if results.HasIssuesURL() || results.IssuesURLString() != "" {
+		t.Errorf("Expected no IssuesURL, got '%s'", results.IssuesURLString())
+	}
+	if results.HasSourceURL() {
+		t.Errorf("Expected SourceURL without host not to be present")
+	}
+}
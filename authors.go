@@ -0,0 +1,90 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"strings"
+)
+
+// AuthorRole is the role of a member in a project, parsed from Author.Role.
+type AuthorRole uint8
+
+const (
+	// AuthorRoleUnknown is used for roles that could not be recognized.
+	AuthorRoleUnknown AuthorRole = iota
+	AuthorRoleOwner
+	AuthorRoleAuthor
+	AuthorRoleFormerAuthor
+	AuthorRoleContributor
+	AuthorRoleArtist
+	AuthorRoleMaintainer
+	AuthorRoleDocumenter
+	AuthorRoleTester
+	AuthorRoleTranslator
+	AuthorRoleTicketManager
+)
+
+// authorRoleNames are the English titles of the roles, as printed on the page.
+var authorRoleNames = map[AuthorRole]string{
+	AuthorRoleOwner:         "Owner",
+	AuthorRoleAuthor:        "Author",
+	AuthorRoleFormerAuthor:  "Former Author",
+	AuthorRoleContributor:   "Contributor",
+	AuthorRoleArtist:        "Artist",
+	AuthorRoleMaintainer:    "Maintainer",
+	AuthorRoleDocumenter:    "Documenter",
+	AuthorRoleTester:        "Tester",
+	AuthorRoleTranslator:    "Translator",
+	AuthorRoleTicketManager: "Ticket Manager",
+}
+
+// ParseAuthorRole maps a role as printed on the page (e.g. "Owner", "Former Author") to an AuthorRole.
+// Case, whitespace and a trailing colon are ignored. Anything unrecognized is mapped to AuthorRoleUnknown.
+func ParseAuthorRole(role string) AuthorRole {
+	role = strings.Join(strings.Fields(trimAuthorRole(role)), " ")
+	for authorRole, name := range authorRoleNames {
+		if strings.EqualFold(name, role) {
+			return authorRole
+		}
+	}
+	return AuthorRoleUnknown
+}
+
+// String returns the English title of the role, as printed on the page.
+func (r AuthorRole) String() string {
+	if name, ok := authorRoleNames[r]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler, so the role is written to JSON by its title, see String.
+func (r AuthorRole) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseAuthorRole.
+func (r *AuthorRole) UnmarshalText(text []byte) error {
+	*r = ParseAuthorRole(string(text))
+	return nil
+}
+
+// trimAuthorRole removes the whitespace and the colon around a role,
+// e.g. "Owner: " -> "Owner". Used by all parsers, so Author.Role is comparable across sites.
+func trimAuthorRole(role string) string {
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(role), ":"))
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"testing"
+)
+
+func TestParseAuthorRole(t *testing.T) {
+	testValues := map[string]AuthorRole{
+		"Owner":             AuthorRoleOwner,
+		"Owner:":            AuthorRoleOwner,
+		" owner: ":          AuthorRoleOwner,
+		"Former  Author":    AuthorRoleFormerAuthor,
+		"Contributor":       AuthorRoleContributor,
+		"Ticket Manager:":   AuthorRoleTicketManager,
+		"Chief Coffee Tech": AuthorRoleUnknown,
+		"":                  AuthorRoleUnknown,
+	}
+	for role, expected := range testValues {
+		if parsed := ParseAuthorRole(role); parsed != expected {
+			t.Errorf("Expected %s for '%s', got %s", expected, role, parsed)
+		}
+	}
+}
+
+func TestTrimAuthorRole(t *testing.T) {
+	testValues := map[string]string{
+		"Owner":          "Owner",
+		"Owner:":         "Owner",
+		"Owner: ":        "Owner",
+		" Former Author": "Former Author",
+	}
+	for role, expected := range testValues {
+		if trimmed := trimAuthorRole(role); trimmed != expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", expected, role, trimmed)
+		}
+	}
+}
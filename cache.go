@@ -0,0 +1,149 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// HeaderFromCache is set on responses served from the ResponseCache of a Fetcher,
+// after the server answered with 304 (Not Modified). See IsNotModified.
+const HeaderFromCache = "X-From-Cache"
+
+// ResponseCache stores pages for conditional requests, see Fetcher.Cache.
+// Implementations must be safe for concurrent use. Back it with a shared store (e.g. Redis)
+// to share the cache between processes; NewMemoryCache returns a simple in-memory implementation.
+type ResponseCache interface {
+	// Get returns the cached response for the URL, false if there is none.
+	Get(url string) (*CachedResponse, bool)
+	// Set stores the response for the URL, replacing any previous one.
+	Set(url string, response *CachedResponse)
+}
+
+// CachedResponse is a page stored in a ResponseCache.
+type CachedResponse struct {
+	// The validators sent by the server, at least one of them is set
+	ETag         string
+	LastModified string
+	// The header of the original response
+	Header http.Header
+	// The (decoded) body of the original response
+	Body []byte
+}
+
+// IsNotModified returns true if resp was served from the ResponseCache of the Fetcher,
+// i.e. the page did not change since it was cached.
+func IsNotModified(resp *http.Response) bool {
+	return resp != nil && resp.Header.Get(HeaderFromCache) != ""
+}
+
+// MemoryCache is a ResponseCache keeping all pages in memory. It never evicts pages.
+type MemoryCache struct {
+	mutex     sync.RWMutex
+	responses map[string]*CachedResponse
+}
+
+// NewMemoryCache creates a new, empty MemoryCache instance.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		responses: make(map[string]*CachedResponse),
+	}
+}
+
+// Get implements ResponseCache.
+func (c *MemoryCache) Get(url string) (*CachedResponse, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	response, ok := c.responses[url]
+	return response, ok
+}
+
+// Set implements ResponseCache.
+func (c *MemoryCache) Set(url string, response *CachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.responses[url] = response
+}
+
+// setConditionalHeaders adds If-None-Match & If-Modified-Since to req if the page is cached.
+// Returns the cached response, nil if there is none.
+func (f *Fetcher) setConditionalHeaders(req *http.Request, url string) *CachedResponse {
+	if f.Cache == nil {
+		return nil
+	}
+	cached, ok := f.Cache.Get(url)
+	if !ok || cached == nil {
+		return nil
+	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
+	return cached
+}
+
+// applyCache replaces the body of a 304 response with the cached page,
+// and stores successful responses with validators in the cache.
+func (f *Fetcher) applyCache(url string, resp *http.Response, cached *CachedResponse) error {
+	if f.Cache == nil {
+		return nil
+	}
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Header = copyHeader(cached.Header)
+		resp.Header.Set(HeaderFromCache, "1")
+		resp.ContentLength = int64(len(cached.Body))
+		resp.Body = ioutil.NopCloser(bytes.NewReader(cached.Body))
+		return nil
+	}
+
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return wrapError(err, "Error reading response body")
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	f.Cache.Set(url, &CachedResponse{
+		ETag:         etag,
+		LastModified: lastModified,
+		Header:       copyHeader(resp.Header),
+		Body:         body,
+	})
+	return nil
+}
+
+// copyHeader returns a deep copy of header.
+func copyHeader(header http.Header) http.Header {
+	clone := make(http.Header, len(header))
+	for key, values := range header {
+		clone[key] = append([]string(nil), values...)
+	}
+	return clone
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetcherCache(t *testing.T) {
+	const page = `<html><body><div id="custom">Cached Value</div></body></html>`
+	const lastModified = "Tue, 22 Aug 2017 00:00:00 GMT"
+
+	var served, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/last-modified":
+			if r.Header.Get("If-Modified-Since") == lastModified {
+				notModified++
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		served++
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(nil)
+	fetcher.Cache = NewMemoryCache()
+
+	for _, path := range []string{"/etag", "/last-modified", "/uncached"} {
+		for attempt := 0; attempt < 2; attempt++ {
+			resp, err := fetcher.FetchPage(server.URL + path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != http.StatusOK || string(body) != page {
+				t.Errorf("%s: Unexpected response %d '%s'", path, resp.StatusCode, body)
+			}
+			expectCached := attempt == 1 && path != "/uncached"
+			if IsNotModified(resp) != expectCached {
+				t.Errorf("%s: Expected IsNotModified() %t on attempt %d", path, expectCached, attempt)
+			}
+		}
+	}
+
+	// The pages with validators are only sent once
+	if served != 4 || notModified != 2 {
+		t.Errorf("Expected 4 pages & 2 not modified responses, got %d & %d", served, notModified)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+)
+
+// Clone returns a deep copy of c. All slices and URLs are copied,
+// so modifying the copy does not affect c and vice versa.
+// Returns nil if c is nil.
+func (c *Curse) Clone() *Curse {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+
+	clone.DontationURL = cloneURL(c.DontationURL)
+	clone.CurseforgeURL = cloneURL(c.CurseforgeURL)
+	clone.GameURL = cloneURL(c.GameURL)
+
+	clone.Authors = cloneAuthors(c.Authors)
+	clone.Categories = cloneCategories(c.Categories)
+	clone.Screenshots = cloneImages(c.Screenshots)
+	clone.Downloads = cloneFiles(c.Downloads)
+
+	clone.CurseForge = c.CurseForge.Clone()
+	clone.Fetch = cloneFetchMeta(c.Fetch)
+
+	return &clone
+}
+
+// Clone returns a deep copy of c. All slices and URLs are copied,
+// so modifying the copy does not affect c and vice versa.
+// Returns nil if c is nil.
+func (c *CurseForge) Clone() *CurseForge {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+
+	clone.OverviewURL = cloneURL(c.OverviewURL)
+	clone.FilesURL = cloneURL(c.FilesURL)
+	clone.ImagesURL = cloneURL(c.ImagesURL)
+	clone.DependenciesURL = cloneURL(c.DependenciesURL)
+	clone.DependentsURL = cloneURL(c.DependentsURL)
+
+	clone.CurseURL = cloneURL(c.CurseURL)
+	clone.ReportProjectURL = cloneURL(c.ReportProjectURL)
+	clone.IssuesURL = cloneURL(c.IssuesURL)
+	clone.WikiURL = cloneURL(c.WikiURL)
+	clone.SourceURL = cloneURL(c.SourceURL)
+
+	clone.CanonicalURL = cloneURL(c.CanonicalURL)
+	clone.ProjectURL = cloneURL(c.ProjectURL)
+	clone.DontationURL = cloneURL(c.DontationURL)
+	clone.ImageURL = cloneURL(c.ImageURL)
+	clone.ImageThumbnailURL = cloneURL(c.ImageThumbnailURL)
+	clone.ImageURLVariants = cloneURLMap(c.ImageURLVariants)
+	clone.RootGameCategoryURL = cloneURL(c.RootGameCategoryURL)
+	clone.LicenseURL = cloneURL(c.LicenseURL)
+	clone.GameURL = cloneURL(c.GameURL)
+
+	clone.Authors = cloneAuthors(c.Authors)
+	clone.Categories = cloneCategories(c.Categories)
+	clone.DescriptionMedia = DescriptionMedia{
+		Videos: cloneURLs(c.DescriptionMedia.Videos),
+		Images: cloneURLs(c.DescriptionMedia.Images),
+	}
+	clone.Screenshots = cloneImages(c.Screenshots)
+	clone.Downloads = cloneFiles(c.Downloads)
+	clone.RecentRelease = cloneFile(c.RecentRelease)
+	clone.RecentBeta = cloneFile(c.RecentBeta)
+	clone.RecentAlpha = cloneFile(c.RecentAlpha)
+	clone.LatestDownloadURL = cloneURL(c.LatestDownloadURL)
+	clone.AvailableGameVersions = cloneStrings(c.AvailableGameVersions)
+	if c.FileErrors != nil {
+		// The errors themselves are not modified after parsing
+		clone.FileErrors = append([]error(nil), c.FileErrors...)
+	}
+	clone.FileCountByVersion = cloneUIntMap(c.FileCountByVersion)
+	clone.DownloadsByVersion = cloneUIntMap(c.DownloadsByVersion)
+	clone.Dependencies = cloneDependencies(c.Dependencies)
+	clone.Dependents = cloneDependencies(c.Dependents)
+	clone.IncludedProjects = cloneProjectSummaries(c.IncludedProjects)
+	clone.Comments = cloneComments(c.Comments)
+	clone.Fetch = cloneFetchMeta(c.Fetch)
+
+	return &clone
+}
+
+// cloneFetchMeta returns a copy of m, nil if m is nil.
+func cloneFetchMeta(m *FetchMeta) *FetchMeta {
+	if m == nil {
+		return nil
+	}
+	clone := *m
+	clone.FinalURL = cloneURL(m.FinalURL)
+	return &clone
+}
+
+// cloneURL returns a copy of u, nil if u is nil.
+// (The user info is immutable and therefore shared.)
+func cloneURL(u *url.URL) *url.URL {
+	if u == nil {
+		return nil
+	}
+	clone := *u
+	return &clone
+}
+
+func cloneURLs(urls []*url.URL) []*url.URL {
+	if urls == nil {
+		return nil
+	}
+	clone := make([]*url.URL, len(urls))
+	for idx, u := range urls {
+		clone[idx] = cloneURL(u)
+	}
+	return clone
+}
+
+func cloneURLMap(urls map[int]*url.URL) map[int]*url.URL {
+	if urls == nil {
+		return nil
+	}
+	clone := make(map[int]*url.URL, len(urls))
+	for size, u := range urls {
+		clone[size] = cloneURL(u)
+	}
+	return clone
+}
+
+func cloneStrings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	clone := make([]string, len(list))
+	copy(clone, list)
+	return clone
+}
+
+func cloneUIntMap(m map[string]uint64) map[string]uint64 {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]uint64, len(m))
+	for key, value := range m {
+		clone[key] = value
+	}
+	return clone
+}
+
+func cloneAuthor(a Author) Author {
+	a.URL = cloneURL(a.URL)
+	a.ImageURL = cloneURL(a.ImageURL)
+	return a
+}
+
+func cloneAuthors(authors []Author) []Author {
+	if authors == nil {
+		return nil
+	}
+	clone := make([]Author, len(authors))
+	for idx, a := range authors {
+		clone[idx] = cloneAuthor(a)
+	}
+	return clone
+}
+
+func cloneCategories(categories []Category) []Category {
+	if categories == nil {
+		return nil
+	}
+	clone := make([]Category, len(categories))
+	for idx, c := range categories {
+		c.URL = cloneURL(c.URL)
+		c.ImageURL = cloneURL(c.ImageURL)
+		clone[idx] = c
+	}
+	return clone
+}
+
+func cloneImages(images []Image) []Image {
+	if images == nil {
+		return nil
+	}
+	clone := make([]Image, len(images))
+	for idx, i := range images {
+		i.URL = cloneURL(i.URL)
+		i.ThumbnailURL = cloneURL(i.ThumbnailURL)
+		clone[idx] = i
+	}
+	return clone
+}
+
+func cloneFiles(files []File) []File {
+	if files == nil {
+		return nil
+	}
+	clone := make([]File, len(files))
+	for idx := range files {
+		clone[idx] = *cloneFile(&files[idx])
+	}
+	return clone
+}
+
+// cloneFile returns a copy of f, nil if f is nil.
+func cloneFile(f *File) *File {
+	if f == nil {
+		return nil
+	}
+	clone := *f
+	clone.URL = cloneURL(f.URL)
+	clone.DirectURL = cloneURL(f.DirectURL)
+	clone.ModLoaders = cloneStrings(f.ModLoaders)
+	clone.IncompatibleVersions = cloneStrings(f.IncompatibleVersions)
+	clone.AdditionalFiles = cloneFiles(f.AdditionalFiles)
+	return &clone
+}
+
+func cloneDependencies(dependencies []Dependency) []Dependency {
+	if dependencies == nil {
+		return nil
+	}
+	clone := make([]Dependency, len(dependencies))
+	for idx, d := range dependencies {
+		d.URL = cloneURL(d.URL)
+		d.ImageURL = cloneURL(d.ImageURL)
+		clone[idx] = d
+	}
+	return clone
+}
+
+func cloneComments(comments []Comment) []Comment {
+	if comments == nil {
+		return nil
+	}
+	clone := make([]Comment, len(comments))
+	for idx, c := range comments {
+		c.Author = cloneAuthor(c.Author)
+		c.URL = cloneURL(c.URL)
+		clone[idx] = c
+	}
+	return clone
+}
+
+func cloneProjectSummaries(projects []ProjectSummary) []ProjectSummary {
+	if projects == nil {
+		return nil
+	}
+	clone := make([]ProjectSummary, len(projects))
+	for idx, p := range projects {
+		p.URL = cloneURL(p.URL)
+		p.Author = cloneAuthor(p.Author)
+		clone[idx] = p
+	}
+	return clone
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// checkNotShared reports pointers and slices of a that share memory with b.
+// (Except for the url.Userinfo, which is immutable.)
+func checkNotShared(t *testing.T, a, b reflect.Value, path string) {
+	switch a.Kind() {
+	case reflect.Ptr:
+		if a.IsNil() || b.IsNil() {
+			return
+		}
+		if a.Type() == reflect.TypeOf(&url.Userinfo{}) {
+			return
+		}
+		if a.Pointer() == b.Pointer() {
+			t.Errorf("%s is shared", path)
+		}
+		checkNotShared(t, a.Elem(), b.Elem(), path)
+	case reflect.Slice:
+		if a.Len() == 0 || b.Len() == 0 {
+			return
+		}
+		if a.Pointer() == b.Pointer() {
+			t.Errorf("%s is shared", path)
+		}
+		for idx := 0; idx < a.Len() && idx < b.Len(); idx++ {
+			checkNotShared(t, a.Index(idx), b.Index(idx), path+"[]")
+		}
+	case reflect.Struct:
+		for idx := 0; idx < a.NumField(); idx++ {
+			checkNotShared(t, a.Field(idx), b.Field(idx), path+"."+a.Type().Field(idx).Name)
+		}
+	}
+}
+
+func TestClone(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results, err := ParseCurseReader("https://wow.curseforge.com/projects/pawn", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Fill the values not present on the overview page
+	file := File{
+		Name:                 "Pawn-2.2.0.zip",
+		URL:                  results.CurseforgeURL,
+		DirectURL:            results.CurseforgeURL,
+		ModLoaders:           []string{"Forge"},
+		IncompatibleVersions: []string{"7.3.0"},
+	}
+	results.Downloads = []File{file}
+	results.Screenshots = []Image{{URL: results.GameURL, ThumbnailURL: results.GameURL}}
+	results.CurseForge.Downloads = []File{file}
+	results.CurseForge.Screenshots = results.Screenshots
+	results.CurseForge.AvailableGameVersions = []string{"7.3.0"}
+	results.CurseForge.Dependencies = []Dependency{{Name: "LibStub", URL: results.GameURL, ImageURL: results.GameURL}}
+	results.CurseForge.Comments = []Comment{{Author: results.Authors[0], Body: "Thanks!", URL: results.GameURL}}
+
+	clone := results.Clone()
+	if !reflect.DeepEqual(results, clone) {
+		t.Errorf("Clone differs from the original")
+	}
+	checkNotShared(t, reflect.ValueOf(results), reflect.ValueOf(clone), "Curse")
+
+	// Modifying the clone leaves the original untouched
+	clone.CurseForge.ProjectURL.Host = "example.com"
+	clone.CurseForge.Authors[0].URL.Path = "/members/someone-else"
+	clone.Downloads[0].ModLoaders[0] = "Fabric"
+	if results.CurseForge.ProjectURL.Host != "wow.curseforge.com" {
+		t.Errorf("Original project URL was modified: %s", results.CurseForge.ProjectURL)
+	}
+	if results.CurseForge.Authors[0].URL.Path == "/members/someone-else" {
+		t.Errorf("Original author URL was modified: %s", results.CurseForge.Authors[0].URL)
+	}
+	if results.Downloads[0].ModLoaders[0] != "Forge" {
+		t.Errorf("Original mod loaders were modified: %v", results.Downloads[0].ModLoaders)
+	}
+
+	var nilCurse *Curse
+	if nilCurse.Clone() != nil {
+		t.Error("Expected nil for the clone of a nil *Curse")
+	}
+}
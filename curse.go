@@ -19,10 +19,11 @@ package curse
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/xmlpath.v2"
 )
@@ -33,20 +34,46 @@ import (
 // * https://mods.curse.com/texture-packs/minecraft/equanimity-32x
 // * https://mods.curse.com/worlds/minecraft/246026-skyblock-3
 // * https://mods.curse.com/addons/wow/pawn
+//
+// mods.curse.com redirects to curseforge.com nowadays. If the response was redirected
+// to a curseforge.com page, it is parsed using the CurseForge parser instead.
+// The result is then available in Curse.CurseForge, and the common values are copied over.
 func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 	defer resp.Body.Close()
 
+	if resp.Request != nil && isCurseForgeHost(resp.Request.URL) {
+		documentURL = resp.Request.URL.String()
+	}
+
+	results, err := ParseCurseReader(documentURL, resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	results.Fetch = newFetchMeta(resp, time.Now())
+	return results, nil
+}
+
+// ParseCurseReader parses a mod page from mods.curse.com read from r, e.g. a local file.
+// documentURL is still required for resolving relative links.
+// If documentURL points to curseforge.com, the page is parsed using the CurseForge parser,
+// like a redirected page in ParseCurse().
+func ParseCurseReader(documentURL string, r io.Reader) (*Curse, error) {
 	documentURLParsed, err := url.Parse(strings.TrimSpace(documentURL))
 	if err != nil {
 		return nil, err
 	}
 
-	root, err := xmlpath.ParseHTML(resp.Body)
+	if isCurseForgeHost(documentURLParsed) {
+		return parseCurseRedirected(documentURLParsed, r)
+	}
+
+	root, err := xmlpath.ParseHTML(r)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing xml/http: %s", err.Error())
 	}
 
 	results := new(Curse)
+	lookup := newPageLookup(DefaultFetcher, documentURLParsed)
 
 	var ok bool
 	// Temp-Variable for values to be parsed
@@ -55,19 +82,19 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 	/*
 		Get the project-overview node for faster processing
 	*/
-	projectOverview, ok := pathCache.Node(root, "//*[@id='project-overview']")
+	projectOverview, ok := lookup.Node(root, "project-overview", "//*[@id='project-overview']")
 	if !ok {
 		return nil, errors.New("could not find 'project-overview' in response body")
 	}
 
 	// Title
-	results.Title, ok = pathCache.String(projectOverview, "header/h2")
+	results.Title, ok = lookup.String(projectOverview, "Title", "header/h2")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Title'")
 	}
 
 	// Donation Link
-	results.DontationURL, err = pathCache.URL(projectOverview, "div[@class='meta-info']/div/a/@href")
+	results.DontationURL, err = lookup.URL(projectOverview, "DonationURL", "div[@class='meta-info']/div/a/@href")
 	if err != nil {
 		// Some projects do not have a donation URL -> don't fail!
 		results.DontationURL = nil
@@ -81,20 +108,21 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 		author := Author{}
 
 		// Author Name
-		author.Name, ok = pathCache.String(authorNode, "a")
+		author.Name, ok = lookup.String(authorNode, "Author/Name", "a")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Author/Name'")
 		}
 
 		// Author Role
-		author.Role, ok = pathCache.String(authorNode, "text()")
+		author.Role, ok = lookup.String(authorNode, "Author/Role", "text()")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Author/Role'")
 		}
-		author.Role = strings.TrimSuffix(author.Role, ":")
+		author.Role = trimAuthorRole(author.Role)
+		author.NormalizedRole = ParseAuthorRole(author.Role)
 
 		// Link to author's page
-		author.URL, err = pathCache.URLWithBaseURL(authorNode, "a/@href", documentURLParsed)
+		author.URL, err = lookup.URL(authorNode, "Author/URL", "a/@href")
 		if err != nil {
 			return nil, fmt.Errorf("error resolving value 'Author/URL': %s", err.Error())
 		}
@@ -110,19 +138,19 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 		category := Category{}
 
 		// Author Name
-		category.Name, ok = pathCache.String(categoryNode, "@title")
+		category.Name, ok = lookup.String(categoryNode, "Category/Name", "@title")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Category/Name'")
 		}
 
 		// Link to category page
-		category.URL, err = pathCache.URLWithBaseURL(categoryNode, "@href", documentURLParsed)
+		category.URL, err = lookup.URL(categoryNode, "Category/URL", "@href")
 		if err != nil {
 			return nil, fmt.Errorf("error resolving value 'Category/URL': %s", err.Error())
 		}
 
 		// Link to category image
-		category.ImageURL, err = pathCache.URL(categoryNode, "img/@src")
+		category.ImageURL, err = lookup.URL(categoryNode, "Category/ImageURL", "img/@src")
 		if err != nil {
 			return nil, fmt.Errorf("error resolving value 'Category/ImageURL': %s", err.Error())
 		}
@@ -131,13 +159,16 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 	}
 
 	// Likes
-	parseString, ok = pathCache.String(projectOverview, "div[@class='main-details']/div[@class='main-info']/div[@class='appreciate']/ul/li[@class='grats']/span")
+	parseString, ok = lookup.String(projectOverview, "Likes", "div[@class='main-details']/div[@class='main-info']/div[@class='appreciate']/ul/li[@class='grats']/span")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Likes'")
 	}
 	// Format of this value: "nnn Likes" -> get the first 'field'
-	parseString = strings.Fields(parseString)[0]
-	results.Likes, err = strconv.ParseUint(parseString, 10, 64)
+	parseString, ok = firstField(parseString)
+	if !ok {
+		return nil, fmt.Errorf("error parsing number for 'Likes': empty value")
+	}
+	results.Likes, err = ParseUIntLocale(parseString, lookup.sep)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Likes': %s", err.Error())
 	}
@@ -145,80 +176,89 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 	/*
 		Get the details-list node for faster processing
 	*/
-	detailsList, ok := pathCache.Node(projectOverview, "div/div/ul[@class='details-list']")
+	detailsList, ok := lookup.Node(projectOverview, "details-list", "div/div/ul[@class='details-list']")
 	if !ok {
 		return nil, errors.New("could not find 'details-list' in response body")
 	}
 
 	// Game
-	results.Game, ok = pathCache.String(detailsList, "li[@class='game']")
+	results.Game, ok = lookup.String(detailsList, "Game", "li[@class='game']")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Game'")
 	}
 
 	// URL
-	results.GameURL, err = pathCache.URLWithBaseURL(detailsList, "li[@class='game']/a/@href", documentURLParsed)
+	results.GameURL, err = lookup.URL(detailsList, "GameURL", "li[@class='game']/a/@href")
 	if err != nil {
 		return nil, fmt.Errorf("error resolving value 'GameURL': %s", err.Error())
 	}
 
 	// Average Downloads
-	parseString, ok = pathCache.String(detailsList, "li[@class='average-downloads']")
+	parseString, ok = lookup.String(detailsList, "Average Downloads", "li[@class='average-downloads']")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Average Downloads'")
 	}
 	// Format of this value: "nnn Monthly Downloads" -> get the first & second 'field'
 	split := strings.Fields(parseString)
-	results.AvgDownloads, err = strconv.ParseUint(strings.Replace(split[0], ",", "", -1), 10, 64)
+	if len(split) < 2 {
+		return nil, fmt.Errorf("error parsing number for 'Average Downloads': unexpected value '%s'", parseString)
+	}
+	results.AvgDownloads, err = ParseUIntLocale(split[0], lookup.sep)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Average Downloads': %s", err.Error())
 	}
 	results.AvgDownloadsTimeframe = split[1]
 
 	// Total Downloads
-	parseString, ok = pathCache.String(detailsList, "li[@class='downloads']")
+	parseString, ok = lookup.String(detailsList, "Total Downloads", "li[@class='downloads']")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Total Downloads'")
 	}
 	// Format of this value: "nnn Total Downloads" -> get the first 'field'
-	parseString = strings.Fields(parseString)[0]
-	results.TotalDownloads, err = strconv.ParseUint(strings.Replace(parseString, ",", "", -1), 10, 64)
+	parseString, ok = firstField(parseString)
+	if !ok {
+		return nil, fmt.Errorf("error parsing number for 'Total Downloads': empty value")
+	}
+	results.TotalDownloads, err = ParseUIntLocale(parseString, lookup.sep)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Total Downloads': %s", err.Error())
 	}
 
 	// Updated
-	results.Updated, err = pathCache.UnixTimestamp(detailsList, "li[@class='updated' and text()='Updated ']/abbr/@data-epoch")
+	results.Updated, err = lookup.UnixTimestamp(detailsList, "Updated", "li[@class='updated' and text()='Updated ']/abbr/@data-epoch")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Updated': %s", err.Error())
 	}
 
 	// Created
-	results.Created, err = pathCache.UnixTimestamp(detailsList, "li[@class='updated' and text()='Created ']/abbr/@data-epoch")
+	results.Created, err = lookup.UnixTimestamp(detailsList, "Created", "li[@class='updated' and text()='Created ']/abbr/@data-epoch")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Created': %s", err.Error())
 	}
 
 	// Favorites
-	parseString, ok = pathCache.String(detailsList, "li[@class='favorited']")
+	parseString, ok = lookup.String(detailsList, "Favorites", "li[@class='favorited']")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'Favorites'")
 	}
 	// Format of this value: "nnn Favorites" -> get the first 'field'
-	parseString = strings.Fields(parseString)[0]
-	results.Favorites, err = strconv.ParseUint(strings.Replace(parseString, ",", "", -1), 10, 64)
+	parseString, ok = firstField(parseString)
+	if !ok {
+		return nil, fmt.Errorf("error parsing number for 'Favorites': empty value")
+	}
+	results.Favorites, err = ParseUIntLocale(parseString, lookup.sep)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing number for 'Favorites': %s", err.Error())
 	}
 
 	// Project Site // Curseforge URL
-	results.CurseforgeURL, err = pathCache.URL(detailsList, "li[@class='curseforge']/a/@href")
+	results.CurseforgeURL, err = lookup.URL(detailsList, "Curseforge URL", "li[@class='curseforge']/a/@href")
 	if err != nil {
 		return nil, fmt.Errorf("error parsing URL for 'Curseforge URL': %s", err.Error())
 	}
 
 	// License
-	parseString, ok = pathCache.String(detailsList, "li[@class='license']")
+	parseString, ok = lookup.String(detailsList, "License", "li[@class='license']")
 	if !ok {
 		return nil, fmt.Errorf("error resolving value 'License'")
 	}
@@ -231,7 +271,7 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 		screenshot := Image{}
 
 		// URL
-		screenshot.URL, err = pathCache.URL(screenshotNode, "@href")
+		screenshot.URL, err = lookup.URL(screenshotNode, "Screenshot/URL", "@href")
 		if err != nil {
 			return nil, fmt.Errorf("error parsing URL for 'Screenshot/URL': %s", err.Error())
 		}
@@ -246,37 +286,39 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 		download := File{}
 
 		// Name
-		download.Name, ok = pathCache.String(downloadNode, "td[1]/a")
+		download.Name, ok = lookup.String(downloadNode, "Download/Name", "td[1]/a")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Download/Name'")
 		}
 
 		// URL
-		download.URL, err = pathCache.URLWithBaseURL(downloadNode, "td[1]/a/@href", documentURLParsed)
+		download.URL, err = lookup.URL(downloadNode, "Download/URL", "td[1]/a/@href")
 		if err != nil {
 			return nil, fmt.Errorf("error resolving value 'Download/URL': %s", err.Error())
 		}
 
 		// ReleaseType
-		download.ReleaseType, ok = pathCache.String(downloadNode, "td[2]")
+		download.ReleaseType, ok = lookup.String(downloadNode, "Download/ReleaseType", "td[2]")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Download/ReleaseType'")
 		}
+		download.Release = ParseReleaseType(download.ReleaseType)
 
 		// GameVersion
-		download.GameVersion, ok = pathCache.String(downloadNode, "td[3]")
+		download.GameVersion, ok = lookup.String(downloadNode, "Download/GameVersion", "td[3]")
 		if !ok {
 			return nil, fmt.Errorf("error resolving value 'Download/GameVersion'")
 		}
+		download.GameVersion = mapGameVersion(DetectGameType(documentURLParsed), download.GameVersion)
 
 		// Downloads
-		download.Downloads, err = pathCache.UInt(downloadNode, "td[4]")
+		download.Downloads, err = lookup.UIntLocale(downloadNode, "Download/Downloads", "td[4]")
 		if err != nil {
 			return nil, fmt.Errorf("error parsing value for 'Download/Downloads': %s", err.Error())
 		}
 
 		// Date
-		download.Date, err = pathCache.UnixTimestamp(downloadNode, "td[5]/abbr/@data-epoch")
+		download.Date, err = lookup.UnixTimestamp(downloadNode, "Download/Date", "td[5]/abbr/@data-epoch")
 		if err != nil {
 			return nil, fmt.Errorf("error parsing number for 'Download/Date': %s", err.Error())
 		}
@@ -284,5 +326,52 @@ func ParseCurse(documentURL string, resp *http.Response) (*Curse, error) {
 		results.Downloads = append(results.Downloads, download)
 	}
 
+	if lookup.normalize {
+		results.normalizeText()
+	}
+
+	return results, nil
+}
+
+// isCurseForgeHost returns true if the URL points to curseforge.com or one of its subdomains.
+func isCurseForgeHost(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	host := strings.ToLower(u.Host)
+	return host == "curseforge.com" || strings.HasSuffix(host, ".curseforge.com")
+}
+
+// parseCurseRedirected parses a mods.curse.com page that was redirected to curseforge.com.
+func parseCurseRedirected(documentURL *url.URL, r io.Reader) (*Curse, error) {
+	cf := new(CurseForge)
+	err := cf.ParseCurseForgeReader(documentURL, r, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		return nil, wrapError(err, fmt.Sprintf("error parsing redirected page '%s'", documentURL.String()))
+	}
+
+	results := &Curse{
+		CurseForge: cf,
+
+		Title:        cf.Title,
+		DontationURL: cf.DontationURL,
+
+		Authors:    cf.Authors,
+		Categories: cf.Categories,
+		License:    cf.License,
+
+		CurseforgeURL: cf.ProjectURL,
+
+		Game:    cf.Game,
+		GameURL: cf.GameURL,
+
+		TotalDownloads: cf.TotalDownloads,
+
+		Updated: cf.Updated,
+		Created: cf.Created,
+
+		Screenshots: cf.Screenshots,
+		Downloads:   cf.Downloads,
+	}
 	return results, nil
 }
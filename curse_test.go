@@ -17,12 +17,97 @@ Copyright 2017 Oliver Kahrmann
 package curse
 
 import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
 )
 
+// networkTestsEnv enables the tests against the live sites, e.g. CURSE_PARSER_NETWORK_TESTS=1
+const networkTestsEnv = "CURSE_PARSER_NETWORK_TESTS"
+
+// requireNetwork skips the calling test unless the network tests are enabled.
+func requireNetwork(t *testing.T) {
+	if os.Getenv(networkTestsEnv) == "" {
+		t.Skipf("Network test, set %s=1 to run", networkTestsEnv)
+	}
+}
+
+func TestParseCurseFixture(t *testing.T) {
+	f, err := os.Open("testdata/curse_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	documentURL := "https://mods.curse.com/mc-mods/minecraft/238424-taam"
+	results, err := ParseCurseReader(documentURL, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateResults(t, documentURL, results, true)
+
+	if results.Title != "TAAM" {
+		t.Errorf("Expected title '%s', got '%s'", "TAAM", results.Title)
+	}
+	if results.DontationURL.String() != "https://www.paypal.me/founderio" {
+		t.Errorf("Expected donation URL '%s', got '%s'", "https://www.paypal.me/founderio", results.DontationURL)
+	}
+	if len(results.Authors) != 2 || results.Authors[0].Name != "founderio" || results.Authors[0].Role != "Owner" {
+		t.Errorf("Unexpected authors: %v", results.Authors)
+	} else if results.Authors[0].NormalizedRole != AuthorRoleOwner || results.Authors[1].NormalizedRole != AuthorRoleContributor {
+		t.Errorf("Unexpected author roles %s, %s", results.Authors[0].NormalizedRole, results.Authors[1].NormalizedRole)
+	}
+	if len(results.Categories) != 2 || results.Categories[1].Name != "Item Transport" {
+		t.Errorf("Unexpected categories: %v", results.Categories)
+	}
+	if results.Likes != 42 || results.Favorites != 12 {
+		t.Errorf("Expected %d likes and %d favorites, got %d and %d", 42, 12, results.Likes, results.Favorites)
+	}
+	if results.Game != "Minecraft" || results.GameURL.String() != "https://mods.curse.com/mc-mods/minecraft" {
+		t.Errorf("Unexpected game '%s' (%s)", results.Game, results.GameURL)
+	}
+	if results.AvgDownloads != 1234 || results.AvgDownloadsTimeframe != "Monthly" {
+		t.Errorf("Expected %d %s downloads, got %d %s", 1234, "Monthly", results.AvgDownloads, results.AvgDownloadsTimeframe)
+	}
+	if results.TotalDownloads != 56789 {
+		t.Errorf("Expected %d total downloads, got %d", 56789, results.TotalDownloads)
+	}
+	if !results.Updated.Equal(time.Unix(1503439200, 0)) || !results.Created.Equal(time.Unix(1441065600, 0)) {
+		t.Errorf("Unexpected dates: updated %v, created %v", results.Updated, results.Created)
+	}
+	if results.CurseforgeURL.String() != "https://minecraft.curseforge.com/projects/taam" {
+		t.Errorf("Expected curseforge URL '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam", results.CurseforgeURL)
+	}
+	if results.License != "GNU Lesser General Public License version 3 (LGPLv3)" {
+		t.Errorf("Unexpected license '%s'", results.License)
+	}
+	if len(results.Screenshots) != 2 {
+		t.Errorf("Expected %d screenshots, got %d", 2, len(results.Screenshots))
+	}
+
+	expectedFiles := []string{"TAAM-1.12.1-0.7.0.jar", "TAAM-1.11.2-0.6.2.jar"}
+	if len(results.Downloads) != len(expectedFiles) {
+		t.Fatalf("Expected %d files, got %d", len(expectedFiles), len(results.Downloads))
+	}
+	for idx, name := range expectedFiles {
+		if results.Downloads[idx].Name != name {
+			t.Errorf("Expected file name '%s', got '%s'", name, results.Downloads[idx].Name)
+		}
+	}
+	if results.Downloads[1].Release != ReleaseTypeBeta || results.Downloads[1].Downloads != 567 {
+		t.Errorf("Unexpected second file: %v", results.Downloads[1])
+	}
+}
+
 func TestParseCurse(t *testing.T) {
+	requireNetwork(t)
+
 	testUrls := []string{
 		"https://mods.curse.com/mc-mods/minecraft/238424-taam",
 		"https://mods.curse.com/texture-packs/minecraft/equanimity-32x",
@@ -55,7 +140,7 @@ func validateResults(t *testing.T, url string, results *Curse, expectDonationURL
 	}
 	for _, dl := range results.Downloads {
 
-		if dl.Date == time.Unix(0, 0).UTC() {
+		if dl.Date.IsZero() {
 			t.Errorf("Empty value 'Download/Date' when testing URL %s", url)
 		}
 		if time.Since(dl.Date).Hours() > 96 && dl.Downloads == 0 {
@@ -172,10 +257,169 @@ func validateResults(t *testing.T, url string, results *Curse, expectDonationURL
 		t.Errorf("Empty value 'AvgDownloadsTimeframe' when testing URL %s", url)
 	}
 
-	if results.Created == time.Unix(0, 0).UTC() {
+	if results.Created.IsZero() {
 		t.Errorf("Empty value 'Created' when testing URL %s", url)
 	}
-	if results.Updated == time.Unix(0, 0).UTC() {
+	if results.Updated.IsZero() {
 		t.Errorf("Empty value 'Updated' when testing URL %s", url)
 	}
 }
+
+func TestParseCurseRedirected(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate mods.curse.com redirecting to the project on curseforge.com
+	redirected, _ := url.Parse("https://wow.curseforge.com/projects/pawn")
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       f,
+		Request:    &http.Request{URL: redirected},
+	}
+
+	results, err := ParseCurse("https://mods.curse.com/addons/wow/pawn", resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.CurseForge == nil {
+		t.Fatal("Expected CurseForge results for a redirected page")
+	}
+	if results.Title != "Pawn" {
+		t.Errorf("Unexpected 'Title': %q", results.Title)
+	}
+	if results.License != "All Rights Reserved" {
+		t.Errorf("Unexpected 'License': %q", results.License)
+	}
+	if results.TotalDownloads != 12345678 {
+		t.Errorf("Unexpected 'TotalDownloads': %d", results.TotalDownloads)
+	}
+	if len(results.Authors) != 1 || results.Authors[0].Name != "VgerAN" {
+		t.Errorf("Unexpected 'Authors': %v", results.Authors)
+	}
+	if results.CurseforgeURL == nil || results.CurseforgeURL.String() != "https://wow.curseforge.com/projects/pawn" {
+		t.Errorf("Unexpected 'CurseforgeURL': %v", results.CurseforgeURL)
+	}
+}
+
+func TestIsCurseForgeHost(t *testing.T) {
+	tests := map[string]bool{
+		"https://wow.curseforge.com/projects/pawn": true,
+		"https://curseforge.com/":                  true,
+		"https://mods.curse.com/addons/wow/pawn":   false,
+		"https://notcurseforge.com/":               false,
+	}
+	for raw, expected := range tests {
+		u, _ := url.Parse(raw)
+		if isCurseForgeHost(u) != expected {
+			t.Errorf("isCurseForgeHost(%s) should be %v", raw, expected)
+		}
+	}
+}
+
+func TestParseCurseEmptyCounts(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curse_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cells without the expected text return an error instead of panicking
+	testValues := map[string][]string{
+		"Likes":             {`<li class="grats"><span>42 Likes</span></li>`, `<li class="grats"><span> </span></li>`},
+		"Average Downloads": {`<li class="average-downloads">1,234 Monthly Downloads</li>`, `<li class="average-downloads">1,234</li>`},
+		"Total Downloads":   {`<li class="downloads">56,789 Total Downloads</li>`, `<li class="downloads"> </li>`},
+		"Favorites":         {`<li class="favorited">12 Favorites</li>`, `<li class="favorited"> </li>`},
+	}
+	for field, replacement := range testValues {
+		page := strings.Replace(string(raw), replacement[0], replacement[1], 1)
+		_, err := ParseCurseReader("https://mods.curse.com/mc-mods/minecraft/238424-taam", strings.NewReader(page))
+		if err == nil || !strings.Contains(err.Error(), "'"+field+"'") {
+			t.Errorf("Expected an error for '%s', got %v", field, err)
+		}
+	}
+}
+
+func TestParseCurseReader(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// A curseforge.com URL selects the CurseForge parser
+	results, err := ParseCurseReader("https://wow.curseforge.com/projects/pawn", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.CurseForge == nil || results.Title != "Pawn" {
+		t.Errorf("Expected CurseForge results with title '%s', got '%s'", "Pawn", results.Title)
+	}
+}
+
+func TestParseCurseConcurrent(t *testing.T) {
+	// Run with -race: all parsers share the package-level XpathCache
+	done := make(chan error)
+	for i := 0; i < 8; i++ {
+		go func(i int) {
+			f, err := os.Open("testdata/curse_taam.html")
+			if err != nil {
+				done <- err
+				return
+			}
+			defer f.Close()
+			if i%4 == 0 {
+				pathCache.Clear()
+			}
+			_, err = ParseCurseReader("https://mods.curse.com/mc-mods/minecraft/238424-taam", f)
+			done <- err
+		}(i)
+	}
+	for i := 0; i < 8; i++ {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestParsersResolveProtocolRelativeURLs(t *testing.T) {
+	const imageURL = "//media.forgecdn.net/avatars/thumbnails/14/479/16/16/635596760578719019.png"
+
+	// Replace the first category image of both fixtures with the same protocol-relative URL
+	curseRaw, err := ioutil.ReadFile("testdata/curse_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	curseRaw = bytes.Replace(curseRaw, []byte("https://media.forgecdn.net/avatars/thumbnails/14/479/16/16/635596760578719019.png"), []byte(imageURL), 1)
+	cfRaw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfRaw = bytes.Replace(cfRaw, []byte("https://media.forgecdn.net/avatars/thumbnails/14/472/32/32/635596758684497577.png"), []byte(imageURL), 1)
+
+	for _, scheme := range []string{"http", "https"} {
+		curseResults, err := ParseCurseReader(scheme+"://mods.curse.com/mc-mods/minecraft/238424-taam", bytes.NewReader(curseRaw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfURL, err := url.Parse(scheme + "://wow.curseforge.com/projects/pawn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfResults := new(CurseForge)
+		err = cfResults.ParseCurseForgeReader(cfURL, bytes.NewReader(cfRaw), true, CFSectionOverview, CFOptionNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Both inherit the scheme of the page
+		expected := scheme + ":" + imageURL
+		if len(curseResults.Categories) == 0 || curseResults.Categories[0].ImageURL.String() != expected {
+			t.Errorf("Expected Curse category image '%s', got %v", expected, curseResults.Categories)
+		}
+		if len(cfResults.Categories) == 0 || cfResults.Categories[0].ImageURL.String() != expected {
+			t.Errorf("Expected CurseForge category image '%s', got %v", expected, cfResults.Categories)
+		}
+	}
+}
@@ -17,10 +17,16 @@ Copyright 2017 Oliver Kahrmann
 package curse
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"path"
 
@@ -38,19 +44,32 @@ const (
 	// Pass only this to load the overview page but only parse the header info.
 	CFSectionHeader CurseForgeSections = 0
 	// CFSectionOverview enables fetching of the overview page.
-	// Parses the "About this Project" section & other values from the sidebar.
-	// Does not include description or comments.
-	// Also does not include recent files. Use the option CFOptionOverviewRecentFiles to include them.
+	// Parses the "About this Project" section, the description & other values from the sidebar.
+	// Does not include comments, use CFSectionComments for them.
+	// The recent files are only added to Downloads with the option CFOptionOverviewRecentFiles,
+	// the newest file of each release channel is always set (RecentRelease, RecentBeta, RecentAlpha).
 	CFSectionOverview = 1
 	// CFSectionFiles enables fetching of the files page.
 	// Parses all files of the file page. Multiple pages will be requested sequentially.
-	// (always, even when using parallel=true)
+	// (always, even when using CFOptionParallel)
 	// Use the option CFOptionFilesNoPagination to load only the first page.
 	CFSectionFiles = 2
-	// CFSectionImages enables fetching of the images page. Currently not implemented!
+	// CFSectionImages enables fetching of the images page.
+	// Parses the image gallery into Screenshots.
 	CFSectionImages = 4
 	// Reserved should we ever want to parse issues on the internal issue tracker.
 	_ = 8
+	// CFSectionDependencies enables fetching of the dependencies page.
+	// Parses the listed dependencies into Dependencies, including the dependency type.
+	CFSectionDependencies = 16
+	// CFSectionComments enables fetching of the comments page.
+	// Parses all comments into Comments. Multiple pages will be requested sequentially.
+	// Use the option CFOptionCommentsNoPagination to load only the first page.
+	CFSectionComments = 32
+	// CFSectionDependents enables fetching of the dependents page.
+	// Parses the projects depending on this one into Dependents, including the dependency type.
+	// Only the first page of dependents is parsed.
+	CFSectionDependents = 64
 )
 
 // Has is a convenience function for binary operations.
@@ -62,6 +81,38 @@ func (s CurseForgeSections) Has(sec CurseForgeSections) bool {
 	return (s & sec) != 0
 }
 
+// cfSectionNames are the names of the sections used by String, in order of their bits.
+var cfSectionNames = []struct {
+	section CurseForgeSections
+	name    string
+}{
+	{CFSectionOverview, "Overview"},
+	{CFSectionFiles, "Files"},
+	{CFSectionImages, "Images"},
+	{CFSectionDependencies, "Dependencies"},
+	{CFSectionComments, "Comments"},
+	{CFSectionDependents, "Dependents"},
+}
+
+// String returns the names of the selected sections joined with "|", e.g. "Overview|Files".
+// CFSectionHeader is returned as "Header", unknown bits as hex number.
+func (s CurseForgeSections) String() string {
+	if s == CFSectionHeader {
+		return "Header"
+	}
+	var names []string
+	for _, n := range cfSectionNames {
+		if s&n.section != 0 {
+			names = append(names, n.name)
+			s &^= n.section
+		}
+	}
+	if s != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint8(s)))
+	}
+	return strings.Join(names, "|")
+}
+
 // CurseForgeOptions allow tweaks to the parsers of some sub-pages.
 // See documentation on the single flags for details.
 type CurseForgeOptions uint8
@@ -75,10 +126,44 @@ const (
 	// so files will contain duplicate if you also load the files page when
 	// using this. They will also be missing the game version tag, as that
 	// is not easily accessible (or even at all for some curseforge sites).
+	// Use CurseForge.Merge to combine separately fetched results without duplicates.
 	CFOptionOverviewRecentFiles = 1
 	// CFOptionFilesNoPagination instructs the files parser to ignore
 	// subsequent files pages. Only the first page of files will be parsed.
+	// To parse the first few pages only, see Fetcher.MaxFilesPages.
+	// To parse the pages with recent files only, see FetchCurseForgeSince.
 	CFOptionFilesNoPagination = 2
+	// CFOptionFilesFetchDetails instructs the files parser to also fetch
+	// the detail page of every file, to parse values not present in the listing.
+	// This causes one additional request per file!
+	// Not supported for the API-backed files listing of the redesigned site.
+	CFOptionFilesFetchDetails = 4
+	// CFOptionParallel instructs FetchCurseForge to fetch the pages of
+	// the selected sections concurrently instead of one after another.
+	// Subsequent files pages are still requested sequentially.
+	CFOptionParallel = 8
+	// CFOptionCommentsNoPagination instructs the comments parser to ignore
+	// subsequent comments pages. Only the first page of comments will be parsed.
+	CFOptionCommentsNoPagination = 16
+	// CFOptionContinueOnError instructs FetchCurseForge to continue with the
+	// remaining sections if fetching or parsing a section fails.
+	// The partially filled results are returned together with a *MultiError,
+	// listing the errors keyed by the URL of the failed section.
+	// Errors of the header are still fatal, as all sections depend on it.
+	CFOptionContinueOnError = 32
+	// CFOptionFilesFetchAdditional instructs the files parser to also fetch
+	// the additional files of every file that has some (see File.HasAdditionalFiles),
+	// by following the more-files tag to the page of the file.
+	// This causes one additional request per file with additional files,
+	// unless the page is loaded for CFOptionFilesFetchDetails anyway.
+	// Not supported for the API-backed files listing of the redesigned site.
+	CFOptionFilesFetchAdditional = 64
+	// CFOptionSkipHeader instructs FetchCurseForge to not parse the header on the first page,
+	// e.g. if the header data is known already or its layout is unusual for some game sites.
+	// The values of the header (URLs of the sections, title, authors etc.) are left empty,
+	// GameType is detected from the project URL only.
+	// At least one section has to be selected, CFSectionHeader alone is an error.
+	CFOptionSkipHeader = 128
 )
 
 // Has is a convenience function for binary operations.
@@ -90,6 +175,40 @@ func (o CurseForgeOptions) Has(opt CurseForgeOptions) bool {
 	return (o & opt) != 0
 }
 
+// cfOptionNames are the names of the options used by String, in order of their bits.
+var cfOptionNames = []struct {
+	option CurseForgeOptions
+	name   string
+}{
+	{CFOptionOverviewRecentFiles, "OverviewRecentFiles"},
+	{CFOptionFilesNoPagination, "FilesNoPagination"},
+	{CFOptionFilesFetchDetails, "FilesFetchDetails"},
+	{CFOptionParallel, "Parallel"},
+	{CFOptionCommentsNoPagination, "CommentsNoPagination"},
+	{CFOptionContinueOnError, "ContinueOnError"},
+	{CFOptionFilesFetchAdditional, "FilesFetchAdditional"},
+	{CFOptionSkipHeader, "SkipHeader"},
+}
+
+// String returns the names of the set options joined with "|", e.g. "OverviewRecentFiles|FilesNoPagination".
+// CFOptionNone is returned as "None", unknown bits as hex number.
+func (o CurseForgeOptions) String() string {
+	if o == CFOptionNone {
+		return "None"
+	}
+	var names []string
+	for _, n := range cfOptionNames {
+		if o&n.option != 0 {
+			names = append(names, n.name)
+			o &^= n.option
+		}
+	}
+	if o != 0 {
+		names = append(names, fmt.Sprintf("0x%x", uint8(o)))
+	}
+	return strings.Join(names, "|")
+}
+
 // DeriveCurseForgeURLs derives sub-urls like files or images from the
 // base projectURL on CurseForge. No HTTP calls are made.
 // Example:
@@ -98,11 +217,27 @@ func (o CurseForgeOptions) Has(opt CurseForgeOptions) bool {
 // CFSectionOverview -> https://minecraft.curseforge.com/projects/taam
 // CFSectionFiles -> https://minecraft.curseforge.com/projects/taam/files
 // CFSectionImages -> https://minecraft.curseforge.com/projects/taam/images
+// CFSectionDependencies -> https://minecraft.curseforge.com/projects/taam/relations/dependencies
+// CFSectionComments -> https://minecraft.curseforge.com/projects/taam/comments
+// CFSectionDependents -> https://minecraft.curseforge.com/projects/taam/relations/dependents
+//
+// Project URLs of the current site (https://www.curseforge.com/minecraft/mc-mods/taam)
+// are derived using its sub-page paths, e.g. the images are found at .../screenshots.
+// See IsLegacyCurseForgeURL.
+//
+// FetchCurseForge only uses the derived URLs until the header is parsed. The subsequent sections
+// are fetched from the links in the navigation of the header, if present (not with CFOptionParallel).
 func DeriveCurseForgeURLs(projectURL *url.URL) (map[CurseForgeSections]*url.URL, error) {
-	urls := make(map[CurseForgeSections]*url.URL, 5)
-	relatives := make(map[CurseForgeSections]string, 5)
+	urls := make(map[CurseForgeSections]*url.URL, 7)
+	relatives := make(map[CurseForgeSections]string, 7)
 	relatives[CFSectionFiles] = "files"
 	relatives[CFSectionImages] = "images"
+	relatives[CFSectionDependencies] = "relations/dependencies"
+	relatives[CFSectionComments] = "comments"
+	relatives[CFSectionDependents] = "relations/dependents"
+	if !IsLegacyCurseForgeURL(projectURL) {
+		relatives[CFSectionImages] = "screenshots"
+	}
 
 	// Overview does not have a "subfolder"
 	urls[CFSectionOverview] = projectURL
@@ -124,6 +259,41 @@ func DeriveCurseForgeURLs(projectURL *url.URL) (map[CurseForgeSections]*url.URL,
 	return urls, nil
 }
 
+// cfHeaderSectionURL returns the URL of a section as linked in the navigation of the parsed header,
+// nil if the header was not parsed (yet) or does not link the section.
+// The links are more reliable than the URLs derived by DeriveCurseForgeURLs,
+// as some games use different sub-page paths.
+func cfHeaderSectionURL(results *CurseForge, section CurseForgeSections) *url.URL {
+	var sectionURL *url.URL
+	switch section {
+	case CFSectionOverview:
+		sectionURL = results.OverviewURL
+	case CFSectionFiles:
+		sectionURL = results.FilesURL
+	case CFSectionImages:
+		sectionURL = results.ImagesURL
+	case CFSectionDependencies:
+		sectionURL = results.DependenciesURL
+	case CFSectionDependents:
+		sectionURL = results.DependentsURL
+	}
+	if !hasURL(sectionURL) {
+		return nil
+	}
+	return sectionURL
+}
+
+// IsLegacyCurseForgeURL returns true if the URL uses the layout of the legacy CurseForge sites,
+// i.e. a game subdomain with projects/<slug> (https://minecraft.curseforge.com/projects/taam).
+// Returns false for the current site, which has the game in the path (https://www.curseforge.com/minecraft/mc-mods/taam).
+func IsLegacyCurseForgeURL(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host != "www.curseforge.com" && host != "curseforge.com"
+}
+
 // FetchCurseForge fetches and parses mod pages from curseforge.com. The sections to be fetched & parsed can be selected.
 // (minecraft.curseforge.com, or feed-the-beast.com, or or or - For a complete list, see curseforge.com).
 //
@@ -138,25 +308,58 @@ func DeriveCurseForgeURLs(projectURL *url.URL) (map[CurseForgeSections]*url.URL,
 // is loaded and only the header values are parsed & returned.
 // Multiple values can be added to select multiple sections,
 // e.g. CFSectionFiles | CFSectionImages
-// This causes multiple sequential requests to CurseForge.
+// This causes multiple sequential requests to CurseForge,
+// or concurrent requests when using CFOptionParallel.
 //
 // options: Pass CFOptionNone, or one or more of the other options to tweak
 // some behaviour of the content parsers.
 //
 // Multiple values can be added to define multiple options,
 // e.g. CFOptionOverviewRecentFiles | CFOptionFilesNoPagination
+//
+// If a section fails, no results are returned. Pass CFOptionContinueOnError to get
+// the results of all other sections, together with a *MultiError.
+// Pages that could not be fetched are reported as *FetchError, values that could not be
+// resolved from a page as *ParseError, both possibly wrapped with more context.
+//
+// All requests are sent using DefaultFetcher. Use Fetcher.FetchCurseForge to use a custom http.Client.
 func FetchCurseForge(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions) (*CurseForge, error) {
+	return DefaultFetcher.FetchCurseForge(projectURL, sections, options)
+}
+
+// FetchCurseForge fetches and parses mod pages from curseforge.com, see FetchCurseForge() for details.
+// All requests, including subsequent files pages, are sent using this Fetcher.
+func (f *Fetcher) FetchCurseForge(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions) (*CurseForge, error) {
 	results := new(CurseForge)
+	// Section errors, only filled with CFOptionContinueOnError
+	errs := NewMultiError()
+
+	if options.Has(CFOptionSkipHeader) {
+		if sections == CFSectionHeader {
+			return nil, errors.New("no section selected to parse with CFOptionSkipHeader")
+		}
+		results.GameType = DetectGameType(projectURL)
+	}
 
 	// if the requested section is 0 (CFSectionHeader) we load the overview page, and only parse the header
 	if sections == CFSectionHeader {
 		var resp *http.Response
 
-		resp, err := FetchPage(projectURL.String())
+		resp, err := f.FetchPage(projectURL.String())
 		if err != nil {
 			return nil, err
 		}
-		err = results.ParseCurseForge(projectURL, resp, true, CFSectionHeader, options)
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			return nil, wrapError(ErrProjectNotFound, fmt.Sprintf("Error parsing URL '%s'", projectURL.String()))
+		}
+		err = checkPageStatus(projectURL.String(), resp)
+		if err != nil {
+			return nil, err
+		}
+		results.Fetch = newFetchMeta(resp, time.Now())
+		err = results.parseCurseForge(f, responseURL(resp, projectURL), resp.Body, true, CFSectionHeader, options)
+		resp.Body.Close()
 		if err != nil {
 			return nil, err
 		}
@@ -169,27 +372,103 @@ func FetchCurseForge(projectURL *url.URL, sections CurseForgeSections, options C
 			return nil, err
 		}
 
+		if options.Has(CFOptionParallel) {
+			err = f.fetchCurseForgeParallel(results, urls, sections, options, errs)
+			if err != nil {
+				return nil, err
+			}
+			return results, errs.ErrorOrNil()
+		}
+
 		// Fetch & parse the sections subsequently, only parsing the header on the first call
+		// (Same order as with CFOptionParallel, so the header is parsed from the overview page, if selected)
 		doHeader := true
-		for section, url := range urls {
+		for _, section := range cfSectionOrder {
+			url := urls[section]
+			// Once the header is parsed, prefer the links of its navigation over the derived URLs
+			if headerURL := cfHeaderSectionURL(results, section); !doHeader && url != nil && headerURL != nil {
+				url = headerURL
+			}
 			// Only load specified sections
-			if sections.Has(section) {
-				// Fetch
-				resp, err := FetchPage(url.String())
-				if err != nil {
-					return nil, fmt.Errorf("Error fetching URL '%s': %s", url.String(), err.Error())
+			if sections.Has(section) && url != nil {
+				headerErr, sectionErr := f.fetchCurseForgeSection(results, url, section, doHeader, options, nil)
+				if headerErr != nil {
+					return nil, headerErr
 				}
-				// Parse
-				err = results.ParseCurseForge(url, resp, doHeader, section, options)
+				err = addSectionError(errs, url, sectionErr, options)
 				if err != nil {
-					return nil, fmt.Errorf("Error parsing URL '%s': %s", url.String(), err.Error())
+					return nil, err
 				}
 				// Skip header on all subsequent calls
 				doHeader = false
 			}
 		}
 	}
-	return results, nil
+	return results, errs.ErrorOrNil()
+}
+
+// FetchCurseForgeContext is FetchCurseForge, aborting all requests when ctx is done.
+// All requests are sent using DefaultFetcher, see Fetcher.FetchCurseForgeContext.
+func FetchCurseForgeContext(ctx context.Context, projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions) (*CurseForge, error) {
+	return DefaultFetcher.FetchCurseForgeContext(ctx, projectURL, sections, options)
+}
+
+// FetchCurseForgeContext is FetchCurseForge, aborting all requests when ctx is done.
+// This includes subsequent files & comments pages and the requests of CFOptionParallel.
+// If ctx is done before all pages were fetched, ctx.Err() is returned, e.g. context.DeadlineExceeded,
+// even with CFOptionContinueOnError.
+func (f *Fetcher) FetchCurseForgeContext(ctx context.Context, projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions) (*CurseForge, error) {
+	results, err := f.withContext(ctx).FetchCurseForge(projectURL, sections, options)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return results, err
+}
+
+// FetchCurseForgeTimeout is FetchCurseForge, aborting all requests once timeout has passed.
+// All requests are sent using DefaultFetcher, see Fetcher.FetchCurseForgeTimeout.
+func FetchCurseForgeTimeout(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions, timeout time.Duration) (*CurseForge, error) {
+	return DefaultFetcher.FetchCurseForgeTimeout(projectURL, sections, options, timeout)
+}
+
+// FetchCurseForgeTimeout is FetchCurseForge, aborting all requests once timeout has passed.
+// The timeout covers the whole call including all subsequent pages, not each single request.
+// If it passes, context.DeadlineExceeded is returned. A timeout of 0 or less disables it.
+func (f *Fetcher) FetchCurseForgeTimeout(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions, timeout time.Duration) (*CurseForge, error) {
+	if timeout <= 0 {
+		return f.FetchCurseForge(projectURL, sections, options)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return f.FetchCurseForgeContext(ctx, projectURL, sections, options)
+}
+
+// FetchCurseForgeSince is FetchCurseForge, but stops loading further files pages once a page lists a file
+// uploaded before since, e.g. the time of the last sync for an incremental update.
+// All requests are sent using DefaultFetcher, see Fetcher.FetchCurseForgeSince.
+func FetchCurseForgeSince(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions, since time.Time) (*CurseForge, error) {
+	return DefaultFetcher.FetchCurseForgeSince(projectURL, sections, options, since)
+}
+
+// FetchCurseForgeSince is FetchCurseForge, but stops loading further files pages once a page lists a file
+// uploaded before since. The listing is sorted newest first, so the remaining pages would only list older files.
+// All files of the loaded pages are kept, see CurseForge.FilesSince to drop the older ones.
+// The zero time loads all pages.
+func (f *Fetcher) FetchCurseForgeSince(projectURL *url.URL, sections CurseForgeSections, options CurseForgeOptions, since time.Time) (*CurseForge, error) {
+	return f.withFilesSince(since).FetchCurseForge(projectURL, sections, options)
+}
+
+// addSectionError records the error of a section in errs when using CFOptionContinueOnError.
+// Otherwise, the error is returned to abort fetching.
+func addSectionError(errs *MultiError, sectionURL *url.URL, err error, options CurseForgeOptions) error {
+	if err == nil {
+		return nil
+	}
+	if !options.Has(CFOptionContinueOnError) {
+		return err
+	}
+	errs.Add(sectionURL.String(), err)
+	return nil
 }
 
 // ParseCurseForge parses single from curseforge.com
@@ -198,197 +477,558 @@ func FetchCurseForge(projectURL *url.URL, sections CurseForgeSections, options C
 // Supported & tested examples: see FetchCurseForge()
 //
 // results: The struct passed in results is filled with the parsed data.
+// The values are added to those already present, so a single struct can be filled from several pages.
+// To re-parse into the same struct (e.g. when polling), call Reset first instead of allocating a new one.
 // parseHeader: true, if the header values shall be parsed.
 // section: A SINGLE section to tell which parser to use.
+//
+// If the page is the not-found page of CurseForge, an error wrapping ErrProjectNotFound is returned.
+//
+// If resp was redirected, links are resolved against the final URL of resp instead of documentURL.
+//
+// Subsequent requests (e.g. further files pages) are sent using DefaultFetcher.
 func (results *CurseForge) ParseCurseForge(documentURL *url.URL, resp *http.Response, parseHeader bool, section CurseForgeSections, options CurseForgeOptions) error {
 	defer resp.Body.Close()
 
-	root, err := xmlpath.ParseHTML(resp.Body)
+	return results.ParseCurseForgeReader(responseURL(resp, documentURL), resp.Body, parseHeader, section, options)
+}
+
+// ParseCurseForgeReader parses a single page from curseforge.com read from r, e.g. a local file.
+// See ParseCurseForge() for details on the parameters.
+// documentURL is still required for resolving relative links.
+//
+// Subsequent requests (e.g. further files pages) are sent using DefaultFetcher.
+// Pass CFOptionFilesNoPagination to avoid any network access.
+func (results *CurseForge) ParseCurseForgeReader(documentURL *url.URL, r io.Reader, parseHeader bool, section CurseForgeSections, options CurseForgeOptions) error {
+	return results.parseCurseForge(DefaultFetcher, documentURL, r, parseHeader, section, options)
+}
+
+// ParseCurseForgeBytes parses a single page from curseforge.com that is already held in memory,
+// e.g. received from a message queue. See ParseCurseForge() for details on the parameters.
+// documentURL is still required for resolving relative links.
+//
+// Subsequent requests (e.g. further files pages) are sent using DefaultFetcher.
+// Pass CFOptionFilesNoPagination to avoid any network access.
+func (results *CurseForge) ParseCurseForgeBytes(documentURL *url.URL, data []byte, parseHeader bool, section CurseForgeSections, options CurseForgeOptions) error {
+	return results.ParseCurseForgeReader(documentURL, bytes.NewReader(data), parseHeader, section, options)
+}
+
+// parseCurseForge implements ParseCurseForgeReader, sending subsequent requests using the given fetcher.
+func (results *CurseForge) parseCurseForge(fetcher *Fetcher, documentURL *url.URL, r io.Reader, parseHeader bool, section CurseForgeSections, options CurseForgeOptions) error {
+	doc, err := newParsedDocument(fetcher, documentURL, r)
 	if err != nil {
-		return fmt.Errorf("error parsing xml/http: %s", err.Error())
+		return err
 	}
 
 	if parseHeader {
-		err = parseCFHeader(results, documentURL, root, options)
+		err = doc.ParseHeader(results, options)
 		if err != nil {
-			return fmt.Errorf("error processing CF header: %s", err.Error())
+			return err
 		}
 	}
+	return doc.ParseSection(results, section, options)
+}
+
+// parseCurseForgeSection runs the parser of a single section on an already parsed document.
+func (results *CurseForge) parseCurseForgeSection(fetcher *Fetcher, documentURL *url.URL, root *xmlpath.Node, raw []byte, section CurseForgeSections, options CurseForgeOptions) error {
+	var err error
+	lookup := newPageLookup(fetcher, documentURL)
 
 	switch section {
 	case CFSectionOverview:
-		err = parseCFOverview(results, documentURL, root, options)
+		err = parseCFOverview(results, lookup, root, options)
 		if err != nil {
-			return fmt.Errorf("error processing CF Overview: %s", err.Error())
+			return wrapError(err, "error processing CF Overview")
 		}
+		parseCFDescription(results, raw)
 	case CFSectionFiles:
-		err = parseCFFiles(results, documentURL, root, options)
+		err = parseCFFiles(fetcher, results, lookup, root, options)
 		if err != nil {
-			return fmt.Errorf("error processing CF Files: %s", err.Error())
+			return wrapError(err, "error processing CF Files")
 		}
 	case CFSectionImages:
-		err = parseCFImages(results, documentURL, root, options)
+		err = parseCFImages(results, lookup, root, options)
+		if err != nil {
+			return wrapError(err, "error processing CF Images")
+		}
+	case CFSectionDependencies:
+		err = parseCFDependencies(results, lookup, root, options)
+		if err != nil {
+			return wrapError(err, "error processing CF Dependencies")
+		}
+	case CFSectionComments:
+		err = parseCFComments(fetcher, results, lookup, root, options)
+		if err != nil {
+			return wrapError(err, "error processing CF Comments")
+		}
+	case CFSectionDependents:
+		err = parseCFDependents(results, lookup, root, options)
 		if err != nil {
-			return fmt.Errorf("error processing CF Files: %s", err.Error())
+			return wrapError(err, "error processing CF Dependents")
 		}
 	}
 
+	updateFirstFileDate(results)
+
+	if lookup.normalize {
+		results.normalizeText()
+	}
+
+	updateIncludedProjects(results)
+
 	return nil
 }
 
-func parseCFHeader(results *CurseForge, documentURLParsed *url.URL, root *xmlpath.Node, options CurseForgeOptions) error {
+// isCFNotFoundPage returns true if the document is the not-found page of CurseForge.
+// It is sometimes sent with status 200 and still has the header navigation,
+// so it is detected by its error container instead.
+// The title is not checked, project titles may start with "404" as well.
+func isCFNotFoundPage(root *xmlpath.Node) bool {
+	_, ok := pathCache.Node(root, "//*[@id='content']/section[contains(@class, 'error-page')]")
+	return ok
+}
+
+// updateFirstFileDate sets FirstFileDate to the date of the oldest file in Downloads.
+// Files with an unparsed date are ignored.
+func updateFirstFileDate(results *CurseForge) {
+	for _, file := range results.Downloads {
+		if !file.hasDate() {
+			continue
+		}
+		if results.FirstFileDate.IsZero() || file.Date.Before(results.FirstFileDate) {
+			results.FirstFileDate = file.Date
+		}
+	}
+}
+
+// cfNavbarPaths & cfATFPaths are the candidate xpaths of the header containers, tried in order.
+// CurseForge serves different variants of the markup during layout rollouts.
+var cfNavbarPaths = []string{
+	"//nav[@class='e-header-nav']",
+	"//nav[contains(@class, 'e-header-nav')]",
+}
+var cfATFPaths = []string{
+	"//*[@id='site-main']/section[@class='atf']",
+	"//section[contains(@class, 'atf')]",
+}
+
+// cfFollowersPaths are the candidate xpaths of the follower count in the sidebar of the overview page.
+var cfFollowersPaths = []string{
+	"//ul[@class='cf-details project-details']/li[div[@class='info-label']='Followers ']/div[@class='info-data']",
+	"//ul[@class='cf-details project-details']/li[div[@class='info-label']='Watchers ']/div[@class='info-data']",
+}
+
+func parseCFHeader(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
 	var ok bool
 	var err error
+	// Temp-Variable for values to be parsed
+	var parseString string
 
 	var navbar *xmlpath.Node
-	navbar, ok = pathCache.Node(root, "//nav[@class='e-header-nav']")
-	if !ok {
-		return fmt.Errorf("did not find navbar")
+	navbar, err = lookup.RequiredNodeAny(root, "navbar", cfNavbarPaths...)
+	if err != nil {
+		return err
 	}
 
-	results.OverviewURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Overview')]/@href", documentURLParsed)
+	results.OverviewURL, err = lookup.RequiredURL(navbar, "Overview URL", "//li/a[contains(text(), 'Overview')]/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Overview URL': %s", err.Error())
+		return err
 	}
 
-	results.FilesURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Files')]/@href", documentURLParsed)
+	results.FilesURL, err = lookup.RequiredURL(navbar, "Files URL", "//li/a[contains(text(), 'Files')]/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Files URL': %s", err.Error())
+		return err
 	}
 
-	results.ImagesURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Images')]/@href", documentURLParsed)
+	results.ImagesURL, err = lookup.RequiredURL(navbar, "Images URL", "//li/a[contains(text(), 'Images')]/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Images URL': %s", err.Error())
+		return err
 	}
 
 	// can be empty / non-present
-	results.IssuesURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Issues')]/@href", documentURLParsed)
+	results.IssuesURL, err = lookup.URL(navbar, "Issues URL", "//li/a[contains(text(), 'Issues')]/@href")
 	/*if err != nil {
-		return fmt.Errorf("error resolving value 'Issues URL': %s", err.Error())
+		return newParseError(lookup.url, "Issues URL", "//li/a[contains(text(), 'Issues')]/@href", err)
 	}*/
 
 	// can be empty / non-present
-	results.WikiURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Wiki')]/@href", documentURLParsed)
+	results.WikiURL, err = lookup.URL(navbar, "Wiki URL", "//li/a[contains(text(), 'Wiki')]/@href")
 	/*if err != nil {
-		return fmt.Errorf("error resolving value 'Wiki URL': %s", err.Error())
+		return newParseError(lookup.url, "Wiki URL", "//li/a[contains(text(), 'Wiki')]/@href", err)
 	}*/
 
 	// can be empty / non-present
-	results.SourceURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Source')]/@href", documentURLParsed)
+	results.SourceURL, err = lookup.URL(navbar, "Source URL", "//li/a[contains(text(), 'Source')]/@href")
 	/*if err != nil {
-		return fmt.Errorf("error resolving value 'Source URL': %s", err.Error())
+		return newParseError(lookup.url, "Source URL", "//li/a[contains(text(), 'Source')]/@href", err)
 	}*/
 
-	results.DependenciesURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Dependencies')]/@href", documentURLParsed)
+	results.DependenciesURL, err = lookup.RequiredURL(navbar, "Dependencies URL", "//li/a[contains(text(), 'Dependencies')]/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Dependencies URL': %s", err.Error())
+		return err
 	}
 
-	results.DependentsURL, err = pathCache.URLWithBaseURL(navbar, "//li/a[contains(text(), 'Dependents')]/@href", documentURLParsed)
+	results.DependentsURL, err = lookup.RequiredURL(navbar, "Dependents URL", "//li/a[contains(text(), 'Dependents')]/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Dependents URL': %s", err.Error())
+		return err
 	}
 
 	// Game (Actually: "Which curseforge is this?")
-	results.Game, ok = pathCache.String(root, "//*[@id='site-main']/header//h1")
-	if !ok {
-		return fmt.Errorf("error resolving value 'Game'")
+	results.Game, err = lookup.RequiredString(root, "Game", "//*[@id='site-main']/header//h1")
+	if err != nil {
+		return err
 	}
 	results.Game = strings.TrimSuffix(results.Game, " CurseForge")
+	results.GameType = DetectGameType(lookup.url)
+	if results.GameType == GameTypeUnknown {
+		results.GameType = gameTypeByName(results.Game)
+	}
 
 	// Game URL
-	results.GameURL, err = pathCache.URLWithBaseURL(root, "//*[@id='site-main']/header//a/@href", documentURLParsed)
+	results.GameURL, err = lookup.RequiredURL(root, "Game URL", "//*[@id='site-main']/header//a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Game URL': %s", err.Error())
+		return err
 	}
 
 	var atf *xmlpath.Node
-	atf, ok = pathCache.Node(root, "//*[@id='site-main']/section[@class='atf']")
-	if !ok {
-		return fmt.Errorf("did not find atf section")
+	atf, err = lookup.RequiredNodeAny(root, "atf section", cfATFPaths...)
+	if err != nil {
+		return err
+	}
+
+	// Canonical project URL; the overview link if the page does not declare one,
+	// as the header may have been parsed from a section page like .../files
+	results.CanonicalURL, err = lookup.URL(root, "Canonical URL", "//head/link[@rel='canonical']/@href")
+	if err != nil {
+		results.CanonicalURL = cloneURL(results.OverviewURL)
 	}
 
 	// Title
-	results.Title, ok = pathCache.String(atf, "//h1/a/span")
-	if !ok {
-		return fmt.Errorf("error resolving value 'Title'")
+	results.Title, err = lookup.RequiredString(atf, "Title", "//h1/a/span")
+	if err != nil {
+		return err
 	}
 
 	// Project URL
-	results.ProjectURL, err = pathCache.URLWithBaseURL(atf, "//h1/a/@href", documentURLParsed)
+	results.ProjectURL, err = lookup.RequiredURL(atf, "Project URL", "//h1/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Project URL': %s", err.Error())
+		return err
 	}
 
-	// RootGameCategory
-	results.RootGameCategory, ok = pathCache.String(atf, "//h2/a")
+	// Summary
+	// can be non-present on older projects, falls back to the meta description
+	results.Summary, ok = lookup.String(atf, "Summary", ".//p[contains(@class, 'project-summary')]")
 	if !ok {
-		return fmt.Errorf("error resolving value 'RootGameCategory'")
+		results.Summary, ok = lookup.String(root, "Summary", "//head/meta[@name='description']/@content")
+	}
+
+	// Project ID
+	// Taken from the data attribute of the atf section if present, otherwise from the project URL (if it is not a slug).
+	// Other elements of the page (e.g. related projects) carry the attribute as well.
+	results.ProjectID, err = lookup.UInt(atf, "ProjectID", "@data-project-id")
+	if err != nil {
+		results.ProjectID, _ = ParseProjectID(results.ProjectURL)
+	}
+
+	// RootGameCategory
+	results.RootGameCategory, err = lookup.RequiredString(atf, "RootGameCategory", "//h2/a")
+	if err != nil {
+		return err
 	}
 
 	// RootGameCategoryURL
-	results.RootGameCategoryURL, err = pathCache.URLWithBaseURL(atf, "//h2/a/@href", documentURLParsed)
+	results.RootGameCategoryURL, err = lookup.RequiredURL(atf, "RootGameCategoryURL", "//h2/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'RootGameCategoryURL': %s", err.Error())
+		return err
 	}
 
+	// Modpack
+	results.IsModpack = isCFModpack(lookup, root, results.RootGameCategory, results.RootGameCategoryURL)
+
 	// Avatar Image URL
-	results.ImageURL, err = pathCache.URLWithBaseURL(atf, "//div[@class='avatar-wrapper']/a/@href", documentURLParsed)
+	results.ImageURL, err = lookup.RequiredURL(atf, "ImageURL", "//div[@class='avatar-wrapper']/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'ImageURL': %s", err.Error())
+		return err
 	}
 	// Avatar Image Thumbnail URL
-	results.ImageThumbnailURL, err = pathCache.URLWithBaseURL(atf, "//div[@class='avatar-wrapper']/a/img/@src", documentURLParsed)
+	results.ImageThumbnailURL, err = lookup.RequiredURL(atf, "ImageThumbnailURL", "//div[@class='avatar-wrapper']/a/img/@src")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'ImageThumbnailURL': %s", err.Error())
+		return err
 	}
+	results.ImageURLVariants = avatarURLVariants(results.ImageThumbnailURL)
 	// Donation URL
 	// can be empty / non-present
-	results.DontationURL, err = pathCache.URL(atf, "//a[@class='button tip icon-donate icon-paypal']/@href")
-	/*if err != nil {
-		return fmt.Errorf("error resolving value 'DontationURL': %s", err.Error())
-	}*/
+	// Any provider (PayPal, Patreon, Ko-fi, ...) is marked with the donate icon
+	var donateButton *xmlpath.Node
+	donateButton, ok = lookup.Node(atf, "Donation button", "//a[contains(@class, 'icon-donate')]")
+	if ok {
+		results.DontationURL, err = lookup.URL(donateButton, "DontationURL", "@href")
+		/*if err != nil {
+			return fmt.Errorf("error resolving value 'DontationURL': %s", err.Error())
+		}*/
+		parseString, _ = pathCache.String(donateButton, "@class")
+		results.DonationProvider = parseDonationProvider(parseString)
+	}
 
 	return nil
 }
 
-func parseCFOverview(results *CurseForge, documentURL *url.URL, root *xmlpath.Node, options CurseForgeOptions) error {
+// parseCFRecentFiles parses the files listed in the "Recent Files" section of the overview sidebar.
+// Rows that cannot be parsed are skipped, their errors are returned separately.
+func parseCFRecentFiles(lookup *pageLookup, sidebar *xmlpath.Node) ([]File, []error) {
+	var files []File
+	var rowErrors []error
+
+	recents := pathCache.Iter(sidebar, "//div[@class='cf-sidebar-wrapper']//li[@class='file-tag']")
+	for recents.Next() {
+		file, err := parseCFRecentFile(recents.Node(), lookup)
+		if err != nil {
+			rowErrors = append(rowErrors, err)
+			continue
+		}
+		files = append(files, file)
+	}
+
+	return files, rowErrors
+}
+
+// parseCFRecentFile parses a single file of the "Recent Files" section.
+func parseCFRecentFile(fileTag *xmlpath.Node, lookup *pageLookup) (File, error) {
+	var err error
+
+	file := File{}
+
+	file.ReleaseType, err = lookup.RequiredString(fileTag, "File/ReleaseType", "div[@class='e-project-file-phase-wrapper']/div/@title")
+	if err != nil {
+		return file, err
+	}
+	file.Release = ParseReleaseType(file.ReleaseType)
+
+	file.DirectURL, err = lookup.RequiredURL(fileTag, "File/DirectURL", ".//div[@class='project-file-download-button']/a/@href")
+	if err != nil {
+		return file, err
+	}
+
+	file.URL, err = lookup.RequiredURL(fileTag, "File/URL", ".//div[@class='project-file-name-container']/a/@href")
+	if err != nil {
+		return file, err
+	}
+
+	file.Name, err = lookup.RequiredString(fileTag, "File/Name", ".//div[@class='project-file-name-container']/a/text()")
+	if err != nil {
+		return file, err
+	}
+
+	// File ID, taken from the file URL
+	file.ID, _ = lastNumericPathSegment(file.URL)
+
+	file.Date, err = lookup.RequiredUnixTimestamp(fileTag, "File/Date", ".//abbr/@data-epoch")
+	if err != nil {
+		return file, err
+	}
+
+	return file, nil
+}
+
+// setCFRecentFilesByRelease sets RecentRelease, RecentBeta and RecentAlpha to the newest of the recent files
+// in the respective release channel. The overview lists the recent files grouped by channel.
+func setCFRecentFilesByRelease(results *CurseForge, recentFiles []File) {
+	for idx := range recentFiles {
+		file := recentFiles[idx]
+
+		var recent **File
+		switch file.Release {
+		case ReleaseTypeRelease:
+			recent = &results.RecentRelease
+		case ReleaseTypeBeta:
+			recent = &results.RecentBeta
+		case ReleaseTypeAlpha:
+			recent = &results.RecentAlpha
+		default:
+			continue
+		}
+		if *recent == nil || file.Date.After((*recent).Date) {
+			*recent = &file
+		}
+	}
+}
+
+// parseCFDescriptionMedia collects the embedded videos & inline images of the description.
+func parseCFDescriptionMedia(media *DescriptionMedia, documentURL *url.URL, description *xmlpath.Node) error {
+	videosPath := ".//iframe/@src"
+	videos := pathCache.Iter(description, videosPath)
+	for videos.Next() {
+		videoURL, err := ParseURLWithBase(videos.Node().String(), documentURL)
+		if err != nil {
+			return newParseError(documentURL, "DescriptionMedia/Videos", videosPath, err)
+		}
+		media.Videos = append(media.Videos, videoURL)
+	}
+
+	imagesPath := ".//img/@src"
+	images := pathCache.Iter(description, imagesPath)
+	for images.Next() {
+		imageURL, err := ParseURLWithBase(images.Node().String(), documentURL)
+		if err != nil {
+			return newParseError(documentURL, "DescriptionMedia/Images", imagesPath, err)
+		}
+		media.Images = append(media.Images, imageURL)
+	}
+
+	return nil
+}
+
+// parseDonationProvider returns the donation provider from the classes of a donate button.
+// e.g. "button tip icon-donate icon-paypal" -> "paypal"
+// Returns an empty string if no provider class is present.
+func parseDonationProvider(class string) string {
+	for _, c := range strings.Fields(class) {
+		if strings.HasPrefix(c, "icon-") && c != "icon-donate" {
+			return strings.TrimPrefix(c, "icon-")
+		}
+	}
+	return ""
+}
+
+// cfAvatarSizes are the sizes (in pixels) of the avatar variants derived by avatarURLVariants.
+var cfAvatarSizes = []int{32, 64, 128, 256}
+
+// avatarURLVariants derives the URLs of the avatar at the sizes in cfAvatarSizes from its thumbnail URL.
+// The thumbnails on forgecdn.net contain the size as path segments, e.g.
+// https://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png
+// The size of the thumbnail itself is included as well.
+// Returns nil if the URL does not match this pattern.
+func avatarURLVariants(thumbnail *url.URL) map[int]*url.URL {
+	if thumbnail == nil || !strings.HasSuffix(strings.ToLower(thumbnail.Hostname()), "forgecdn.net") {
+		return nil
+	}
+	// .../thumbnails/<id>/<id>/<width>/<height>/<file>
+	segments := strings.Split(thumbnail.Path, "/")
+	if len(segments) < 6 || segments[len(segments)-6] != "thumbnails" {
+		return nil
+	}
+	width, err := strconv.Atoi(segments[len(segments)-3])
+	if err != nil {
+		return nil
+	}
+	if _, err = strconv.Atoi(segments[len(segments)-2]); err != nil {
+		return nil
+	}
+
+	variants := make(map[int]*url.URL, len(cfAvatarSizes)+1)
+	variants[width] = cloneURL(thumbnail)
+	for _, size := range cfAvatarSizes {
+		variant := cloneURL(thumbnail)
+		variantSegments := append([]string(nil), segments...)
+		variantSegments[len(segments)-3] = strconv.Itoa(size)
+		variantSegments[len(segments)-2] = strconv.Itoa(size)
+		variant.Path = strings.Join(variantSegments, "/")
+		variant.RawPath = ""
+		variants[size] = variant
+	}
+	return variants
+}
+
+func parseCFOverview(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
 	var ok bool
 	var err error
+	// Temp-Variable for values to be parsed
+	var parseString string
 
 	var sidebar *xmlpath.Node
-	sidebar, ok = pathCache.Node(root, "//*[@id='content']/section/div[@class='e-project-details-secondary']")
-	if !ok {
-		return fmt.Errorf("did not find atf section: %s", err.Error())
+	sidebar, err = lookup.RequiredNode(root, "sidebar", "//*[@id='content']/section/div[@class='e-project-details-secondary']")
+	if err != nil {
+		return err
+	}
+
+	/*
+		Description
+	*/
+
+	// can be empty / non-present
+	var description *xmlpath.Node
+	description, ok = lookup.Node(root, "Description", "//*[@id='content']/section/div[@class='e-project-details-primary']/div[@class='project-description']")
+	if ok {
+		err = parseCFDescriptionMedia(&results.DescriptionMedia, lookup.url, description)
+		if err != nil {
+			return err
+		}
 	}
 
 	/*
 		Sidebar Values
 	*/
 
-	results.Created, err = pathCache.UnixTimestamp(sidebar, "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Created ']/div[@class='info-data']/abbr/@data-epoch")
+	results.Created, err = lookup.RequiredUnixTimestamp(sidebar, "Created", "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Created ']/div[@class='info-data']/abbr/@data-epoch")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Created': %s", err.Error())
+		return err
 	}
 
-	results.Updated, err = pathCache.UnixTimestamp(sidebar, "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Last Released File ']/div[@class='info-data']/abbr/@data-epoch")
+	results.Updated, err = lookup.RequiredUnixTimestamp(sidebar, "Updated // Last Released File", "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Last Released File ']/div[@class='info-data']/abbr/@data-epoch")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'Updated // Last Released File': %s", err.Error())
+		return err
 	}
 
-	results.TotalDownloads, err = pathCache.UInt(sidebar, "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Total Downloads ']/div[@class='info-data']")
+	results.TotalDownloads, err = lookup.RequiredCount(sidebar, "TotalDownloads", "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Total Downloads ']/div[@class='info-data']")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'TotalDownloads': %s", err.Error())
+		return err
 	}
 
-	results.License, ok = pathCache.String(sidebar, "//ul[@class='cf-details project-details']/li[div[@class='info-label']='License ']/div[@class='info-data']/a")
-	if !ok {
-		return fmt.Errorf("error resolving value 'License'")
+	results.License, err = lookup.RequiredString(sidebar, "License", "//ul[@class='cf-details project-details']/li[div[@class='info-label']='License ']/div[@class='info-data']/a")
+	if err != nil {
+		return err
 	}
+	results.LicenseSPDX = ParseLicenseSPDX(results.License)
 
-	results.LicenseURL, err = pathCache.URLWithBaseURL(sidebar, "//ul[@class='cf-details project-details']/li[div[@class='info-label']='License ']/div[@class='info-data']/a/@href", documentURL)
+	results.LicenseURL, err = lookup.RequiredURL(sidebar, "LicenseURL", "//ul[@class='cf-details project-details']/li[div[@class='info-label']='License ']/div[@class='info-data']/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'LicenseURL': %s", err.Error())
+		return err
+	}
+
+	// Rating
+	// Not all games display ratings, so this can be empty / non-present
+	ratingPath := "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Rating ']/div[@class='info-data']/span[@class='rating-average']"
+	parseString, ok = lookup.String(sidebar, "Rating", ratingPath)
+	if ok {
+		results.Rating, err = strconv.ParseFloat(parseString, 64)
+		if err != nil {
+			return newParseError(lookup.url, "Rating", ratingPath, err)
+		}
+	}
+
+	ratingCountPath := "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Rating ']/div[@class='info-data']/span[@class='rating-count']"
+	parseString, ok = lookup.String(sidebar, "RatingCount", ratingCountPath)
+	if ok {
+		// Format of this value: "(nnn ratings)" -> get the first 'field'
+		split := strings.Fields(strings.Trim(parseString, "()"))
+		if len(split) == 0 {
+			return newParseError(lookup.url, "RatingCount", ratingCountPath, nil)
+		}
+		results.RatingCount, err = ParseUIntLocale(split[0], lookup.sep)
+		if err != nil {
+			return newParseError(lookup.url, "RatingCount", ratingCountPath, err)
+		}
+	}
+
+	// Comment count
+	// Not all games display engagement metrics, so this can be non-present
+	commentCountPath := "//ul[@class='cf-details project-details']/li[div[@class='info-label']='Comments ']/div[@class='info-data']"
+	parseString, ok = lookup.String(sidebar, "CommentCount", commentCountPath)
+	if ok {
+		results.CommentCount, err = ParseCountLocale(parseString, lookup.sep)
+		if err != nil {
+			return newParseError(lookup.url, "CommentCount", commentCountPath, err)
+		}
+	}
+
+	// Followers
+	// Labelled "Followers" or "Watchers" depending on the game, can be non-present
+	parseString, ok = lookup.StringAny(sidebar, "Followers", cfFollowersPaths...)
+	if ok {
+		results.Followers, err = ParseCountLocale(parseString, lookup.sep)
+		if err != nil {
+			return newParseError(lookup.url, "Followers", anyPaths(cfFollowersPaths...), err)
+		}
 	}
 
 	/*
@@ -401,19 +1041,19 @@ func parseCFOverview(results *CurseForge, documentURL *url.URL, root *xmlpath.No
 
 		category := Category{}
 
-		category.Name, ok = pathCache.String(categoryNode, "a/@title")
-		if !ok {
-			return fmt.Errorf("error resolving value 'Category/Name'")
+		category.Name, err = lookup.RequiredString(categoryNode, "Category/Name", "a/@title")
+		if err != nil {
+			return err
 		}
 
-		category.URL, err = pathCache.URLWithBaseURL(categoryNode, "a/@href", documentURL)
+		category.URL, err = lookup.RequiredURL(categoryNode, "Category/URL", "a/@href")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'Category/URL': %s", err.Error())
+			return err
 		}
 
-		category.ImageURL, err = pathCache.URLWithBaseURL(categoryNode, "a/img/@src", documentURL)
+		category.ImageURL, err = lookup.RequiredURL(categoryNode, "Category/ImageURL", "a/img/@src")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'Category/ImageURL': %s", err.Error())
+			return err
 		}
 
 		results.Categories = append(results.Categories, category)
@@ -424,15 +1064,19 @@ func parseCFOverview(results *CurseForge, documentURL *url.URL, root *xmlpath.No
 		Links
 	*/
 
-	results.CurseURL, err = pathCache.URLWithBaseURL(sidebar, "//li[@class='view-on-curse']/a/@href", documentURL)
+	results.CurseURL, err = lookup.RequiredURL(sidebar, "CurseURL", "//li[@class='view-on-curse']/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'CurseURL': %s", err.Error())
+		return err
+	}
+	// The curse link ends with the numeric project ID, e.g. https://www.curseforge.com/projects/19373
+	// Only used if the header did not provide it.
+	if results.ProjectID == 0 {
+		results.ProjectID, _ = ParseProjectID(results.CurseURL)
 	}
-	//TODO: extract the curse project ID from the URL
 
-	results.ReportProjectURL, err = pathCache.URLWithBaseURL(sidebar, "//li[@class='report-project']/a/@href", documentURL)
+	results.ReportProjectURL, err = lookup.RequiredURL(sidebar, "ReportProjectURL", "//li[@class='report-project']/a/@href")
 	if err != nil {
-		return fmt.Errorf("error resolving value 'ReportProjectURL': %s", err.Error())
+		return err
 	}
 
 	/*
@@ -445,24 +1089,26 @@ func parseCFOverview(results *CurseForge, documentURL *url.URL, root *xmlpath.No
 
 		author := Author{}
 
-		author.Name, ok = pathCache.String(memberNode, "div[@class='info-wrapper']/p/a[1]/span")
-		if !ok {
-			return fmt.Errorf("error resolving value 'Author/Name'")
+		author.Name, err = lookup.RequiredString(memberNode, "Author/Name", "div[@class='info-wrapper']/p/a[1]/span")
+		if err != nil {
+			return err
 		}
 
-		author.URL, err = pathCache.URLWithBaseURL(memberNode, "div[@class='info-wrapper']/p/a[1]/@href", documentURL)
+		author.URL, err = lookup.RequiredURL(memberNode, "Author/URL", "div[@class='info-wrapper']/p/a[1]/@href")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'Author/URL': %s", err.Error())
+			return err
 		}
 
-		author.Role, ok = pathCache.String(memberNode, "div[@class='info-wrapper']/p/span[@class='title']")
-		if !ok {
-			return fmt.Errorf("error resolving value 'Author/Role'")
+		author.Role, err = lookup.RequiredString(memberNode, "Author/Role", "div[@class='info-wrapper']/p/span[@class='title']")
+		if err != nil {
+			return err
 		}
+		author.Role = trimAuthorRole(author.Role)
+		author.NormalizedRole = ParseAuthorRole(author.Role)
 
-		author.ImageURL, err = pathCache.URLWithBaseURL(memberNode, "div/div/a/img/@src", documentURL)
+		author.ImageURL, err = lookup.RequiredURL(memberNode, "Author/ImageURL", "div/div/a/img/@src")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'Author/ImageURL': %s", err.Error())
+			return err
 		}
 
 		results.Authors = append(results.Authors, author)
@@ -472,51 +1118,81 @@ func parseCFOverview(results *CurseForge, documentURL *url.URL, root *xmlpath.No
 	/*
 		Recent Files
 	*/
+	// Recent Files
+	// can be empty / non-present
+	// Rows that cannot be parsed do not fail the overview, their errors are added to FileErrors
+	recentFiles, rowErrors := parseCFRecentFiles(lookup, sidebar)
+	results.FileErrors = append(results.FileErrors, rowErrors...)
+	setCFRecentFilesByRelease(results, recentFiles)
 	if options.Has(CFOptionOverviewRecentFiles) {
-		recents := pathCache.Iter(sidebar, "//div[@class='cf-sidebar-wrapper']//li[@class='file-tag']")
-		for recents.Next() {
-			fileTag := recents.Node()
+		results.Downloads = append(results.Downloads, recentFiles...)
+	}
 
-			file := File{}
+	// Main download button of the latest file
+	// can be non-present, e.g. for projects without files
+	results.LatestDownloadURL, _ = lookup.URL(root, "LatestDownloadURL", "//div[@class='project-actions']/a[contains(@class, 'icon-download')]/@href")
 
-			file.ReleaseType, ok = pathCache.String(fileTag, "div[@class='e-project-file-phase-wrapper']/div/@title")
-			if !ok {
-				return fmt.Errorf("error resolving value 'File/ReleaseType'")
-			}
+	return nil
+}
 
-			file.DirectURL, err = pathCache.URLWithBaseURL(fileTag, "//div[@class='project-file-download-button']/a/@href", documentURL)
-			if err != nil {
-				return fmt.Errorf("error resolving value 'File/DirectURL': %s", err.Error())
-			}
+func parseCFFiles(fetcher *Fetcher, results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	// The redesigned site does not render file rows, but loads them from an API
+	if projectID, ok := isAPIBackedFilesPage(root); ok {
+		if fetcher.skipFilesAPI {
+			results.ProjectID = projectID
+			return nil
+		}
+		return parseCFFilesAPI(fetcher, results, lookup.url, projectID, options)
+	}
 
-			file.URL, err = pathCache.URLWithBaseURL(fileTag, "//div[@class='project-file-name-container']/a/@href", documentURL)
-			if err != nil {
-				return fmt.Errorf("error resolving value 'File/URL': %s", err.Error())
-			}
+	// Files added by this call, for fetching the details afterwards
+	firstFile := len(results.Downloads)
 
-			file.Name, ok = pathCache.String(fileTag, "//div[@class='project-file-name-container']/a/text()")
-			if !ok {
-				return fmt.Errorf("error resolving value 'File/Name'")
-			}
+	// The version filter lists every game version files were released for
+	parseCFAvailableGameVersions(results, root)
 
-			file.Date, err = pathCache.UnixTimestamp(fileTag, "//abbr/@data-epoch")
-			if err != nil {
-				return fmt.Errorf("error resolving value 'File/Date': %s", err.Error())
-			}
+	err := parseCFFilesPages(fetcher, results, lookup, root, options)
+	if err != nil {
+		return err
+	}
 
-			results.Downloads = append(results.Downloads, file)
+	if options.Has(CFOptionFilesFetchDetails) || options.Has(CFOptionFilesFetchAdditional) {
+		rowErrors, err := fetchCFFileDetails(fetcher, results.Downloads[firstFile:], results.GameType, options)
+		results.FileErrors = append(results.FileErrors, rowErrors...)
+		if err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-func parseCFFiles(results *CurseForge, documentURL *url.URL, root *xmlpath.Node, options CurseForgeOptions) error {
+// parseCFFilesPages parses the first files page and sequentially loads & parses the subsequent pages.
+func parseCFFilesPages(fetcher *Fetcher, results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	// Files added by the current page, for checking the cutoff of FetchCurseForgeSince
+	pageStart := len(results.Downloads)
 
 	// Parse the files on the first page
-	err := parseCFFilesSinglePage(results, documentURL, root, options)
+	err := parseCFFilesSinglePage(results, lookup, root, options)
+	if err != nil {
+		return wrapError(err, "error parsing first files page")
+	}
+
+	results.TotalFiles = parseCFTotalCount(lookup, root)
+
+	// The pagination is only informational if no pagination is requested,
+	// so a broken one leaves TotalFilePages unknown instead of failing the files
+	pageCount, err := parseCFPageCount(root)
+	if err != nil && options.Has(CFOptionFilesNoPagination) {
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("error parsing first files page: %s", err.Error())
+		return err
+	}
+	// Without pagination, there is a single page
+	results.TotalFilePages = pageCount
+	if results.TotalFilePages == 0 {
+		results.TotalFilePages = 1
 	}
 
 	// Stop if no pagination is requested
@@ -524,7 +1200,46 @@ func parseCFFiles(results *CurseForge, documentURL *url.URL, root *xmlpath.Node,
 		return nil
 	}
 
-	// Get the number of pages
+	// Sequentially, load the file pages (up to the limits of the fetcher)
+	lastPage := fetcher.lastFilesPage(pageCount)
+	var page uint64
+	for page = 2; page <= lastPage; page++ {
+		if fetcher.reachedFilesSince(results.Downloads[pageStart:]) {
+			break
+		}
+		pageStart = len(results.Downloads)
+
+		pageURL := lookup.url.ResolveReference(&url.URL{
+			Path:     "files",
+			RawQuery: fmt.Sprintf("page=%d", page),
+		}).String()
+		resp, err := fetcher.FetchPage(pageURL)
+		if err != nil {
+			return err
+		}
+		err = checkPageStatus(pageURL, resp)
+		if err != nil {
+			return err
+		}
+
+		root, err := xmlpath.ParseHTML(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error parsing xml/http for subsequent files page (%d): %s", page, err.Error())
+		}
+
+		err = parseCFFilesSinglePage(results, lookup, root, options)
+		if err != nil {
+			return wrapError(err, fmt.Sprintf("error parsing files page %d", page))
+		}
+	}
+
+	return nil
+}
+
+// parseCFPageCount returns the number of pages listed in the pagination of a listing (files, comments).
+// Returns 0 if there is no pagination.
+func parseCFPageCount(root *xmlpath.Node) (uint64, error) {
 	// (Last page is definitely listed as single element, so we just look for the one with the highest number)
 	// (Could be optimized probably..)
 	pagination := pathCache.Iter(root, "//div[@class='listing-header']//a[@class='b-pagination-item']")
@@ -533,102 +1248,439 @@ func parseCFFiles(results *CurseForge, documentURL *url.URL, root *xmlpath.Node,
 		pNode := pagination.Node()
 		val, err := ParseUInt(pNode.String())
 		if err != nil {
-			return fmt.Errorf("error parsing page number: %s", err.Error())
+			return 0, fmt.Errorf("error parsing page number: %s", err.Error())
 		}
 		// Just to be sure, compare if it is actually larger...
 		if val > pageCount {
 			pageCount = val
 		}
 	}
+	return pageCount, nil
+}
 
-	// Sequentially, load the file pages
-	var page uint64
-	for page = 2; page <= pageCount; page++ {
-		resp, err := FetchPage(documentURL.ResolveReference(&url.URL{
-			Path:     "files",
-			RawQuery: fmt.Sprintf("page=%d", page),
-		}).String())
-		if err != nil {
-			return fmt.Errorf("error fetching subsequent files page (%d): %s", page, err.Error())
-		}
+// parseCFTotalCount returns the total number of items from the pagination info of a listing,
+// e.g. "Showing 1 - 25 of 211" -> 211. Returns 0 if the info is not present.
+func parseCFTotalCount(lookup *pageLookup, root *xmlpath.Node) uint64 {
+	info, ok := lookup.String(root, "TotalFiles", "//div[@class='listing-header']//div[@class='b-pagination-info']")
+	if !ok {
+		return 0
+	}
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return 0
+	}
+	total, err := ParseUIntLocale(fields[len(fields)-1], lookup.sep)
+	if err != nil {
+		return 0
+	}
+	return total
+}
 
-		root, err := xmlpath.ParseHTML(resp.Body)
-		if err != nil {
-			return fmt.Errorf("error parsing xml/http for subsequent files page (%d): %s", page, err.Error())
-		}
+// parseCFFilesSinglePage parses the rows of a single files page.
+// Rows that cannot be parsed are skipped and their errors added to FileErrors,
+// unless no row of the page can be parsed at all, which is returned as error.
+func parseCFFilesSinglePage(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	var parsed int
+	var rowErrors []error
 
-		err = parseCFFilesSinglePage(results, documentURL, root, options)
+	gameType := results.GameType
+	if gameType == GameTypeUnknown {
+		gameType = DetectGameType(lookup.url)
+	}
+
+	// Archived rows carry an additional class, see parseCFFileDeprecated
+	recents := pathCache.Iter(root, "//tr[contains(@class, 'project-file-list-item')]")
+	for recents.Next() {
+		file, err := parseCFFileRow(recents.Node(), lookup, gameType)
 		if err != nil {
-			return fmt.Errorf("error parsing first files page: %s", err.Error())
+			rowErrors = append(rowErrors, err)
+			continue
 		}
+
+		results.Downloads = append(results.Downloads, file)
+		parsed++
 	}
 
+	// Most likely a layout change instead of some odd rows
+	if parsed == 0 && len(rowErrors) > 0 {
+		return rowErrors[0]
+	}
+	results.FileErrors = append(results.FileErrors, rowErrors...)
 	return nil
 }
 
-func parseCFFilesSinglePage(results *CurseForge, documentURL *url.URL, root *xmlpath.Node, options CurseForgeOptions) error {
+// parseCFFileRow parses a single row (tr) of the files listing.
+// gameType selects the version label fallbacks & the mapping of the game version, see mapGameVersion.
+func parseCFFileRow(fileTag *xmlpath.Node, lookup *pageLookup, gameType GameType) (File, error) {
 	var ok bool
 	var err error
+	// Temp-Variable for values to be parsed
+	var parseString string
 
-	recents := pathCache.Iter(root, "//tr[@class='project-file-list-item']")
-	for recents.Next() {
-		fileTag := recents.Node()
+	file := File{}
 
-		file := File{}
+	file.ReleaseType, err = lookup.RequiredString(fileTag, "File/ReleaseType", "td[@class='project-file-release-type']/div/@title")
+	if err != nil {
+		return file, err
+	}
+	file.Release = ParseReleaseType(file.ReleaseType)
+
+	file.Deprecated = parseCFFileDeprecated(lookup, fileTag)
+
+	file.DirectURL, err = lookup.RequiredURL(fileTag, "File/DirectURL", "td//div[@class='project-file-download-button']/a/@href")
+	if err != nil {
+		return file, err
+	}
+
+	file.URL, err = lookup.RequiredURL(fileTag, "File/URL", "td//div[@class='project-file-name-container']/a/@href")
+	if err != nil {
+		return file, err
+	}
+
+	// File ID
+	// Taken from the data attribute if present, otherwise from the file URL
+	file.ID, err = lookup.UInt(fileTag, "File/ID", "@data-file-id")
+	if err != nil {
+		file.ID, err = lookup.UInt(fileTag, "File/ID", "td//*[@data-file-id]/@data-file-id")
+	}
+	if err != nil {
+		file.ID, _ = lastNumericPathSegment(file.URL)
+	}
+
+	file.Name, err = lookup.RequiredString(fileTag, "File/Name", "td//div[@class='project-file-name-container']/a/text()")
+	if err != nil {
+		return file, err
+	}
+
+	parseString, ok = lookup.String(fileTag, "File/AdditionalFiles", "td//div[@class='project-file-name-container']/a[@class='more-files-tag']")
+	file.HasAdditionalFiles = ok
+	if ok {
+		file.AdditionalFileCount = parseAdditionalFileCount(parseString)
+	}
+
+	file.SizeInfo, err = lookup.RequiredString(fileTag, "File/SizeInfo", "td[@class='project-file-size']/text()")
+	if err != nil {
+		return file, err
+	}
+	// An unknown format leaves SizeBytes at 0, SizeInfo is still available
+	file.SizeBytes, _ = ParseFileSize(file.SizeInfo)
+
+	file.Date, err = lookup.RequiredUnixTimestamp(fileTag, "File/Date", "td//abbr/@data-epoch")
+	if err != nil {
+		return file, err
+	}
+
+	gameVersionPath := "td//span[@class='version-label']/text()"
+	file.GameVersion, ok = lookup.String(fileTag, "File/GameVersion", gameVersionPath)
+	if !ok && gameType == GameTypeWoW {
+		// WoW files pages may print the version without label
+		file.GameVersion, ok = lookup.String(fileTag, "File/GameVersion", "td[@class='project-file-game-version']/text()")
+	}
+	if !ok {
+		return file, newParseError(lookup.url, "File/GameVersion", gameVersionPath, nil)
+	}
+	file.GameVersion = mapGameVersion(gameType, file.GameVersion)
 
-		file.ReleaseType, ok = pathCache.String(fileTag, "td[@class='project-file-release-type']/div/@title")
-		if !ok {
-			return fmt.Errorf("error resolving value 'File/ReleaseType'")
+	// can be non-present, e.g. for featured files
+	downloadsPath := "td[@class='project-file-downloads']/text()"
+	parseString, ok = lookup.String(fileTag, "File/Downloads", downloadsPath)
+	if ok {
+		file.Downloads, err = ParseCountLocale(parseString, lookup.sep)
+		if err != nil {
+			return file, newParseError(lookup.url, "File/Downloads", downloadsPath, err)
 		}
+	}
+
+	return file, nil
+}
+
+// parseCFFileDeprecated returns true if the file row is marked as archived or deprecated,
+// either by a class of the row or by a status badge in the row.
+func parseCFFileDeprecated(lookup *pageLookup, fileTag *xmlpath.Node) bool {
+	class, ok := lookup.String(fileTag, "File/Deprecated", "@class")
+	if ok && isDeprecatedMarker(class) {
+		return true
+	}
+	status, ok := lookup.String(fileTag, "File/Deprecated", "td//*[contains(@class, 'file-status')]")
+	return ok && isDeprecatedMarker(status)
+}
+
+// isDeprecatedMarker returns true if a class or badge text marks a file as archived or deprecated.
+func isDeprecatedMarker(s string) bool {
+	s = strings.ToLower(s)
+	return strings.Contains(s, "archived") || strings.Contains(s, "deprecated")
+}
+
+func parseCFImages(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	var err error
+
+	// Every screenshot in the gallery links to the full image, with the thumbnail nested inside
+	images := pathCache.Iter(root, "//*[contains(@class, 'project-image-listing')]//*[contains(@class, 'project-image-item')]")
+	for images.Next() {
+		imageTag := images.Node()
 
-		file.DirectURL, err = pathCache.URLWithBaseURL(fileTag, "td//div[@class='project-file-download-button']/a/@href", documentURL)
+		image := Image{}
+
+		// Image URL
+		image.URL, err = lookup.RequiredURL(imageTag, "Screenshot/URL", ".//a/@href")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'File/DirectURL': %s", err.Error())
+			return err
 		}
 
-		file.URL, err = pathCache.URLWithBaseURL(fileTag, "td//div[@class='project-file-name-container']/a/@href", documentURL)
+		// Thumbnail URL
+		image.ThumbnailURL, err = lookup.RequiredURL(imageTag, "Screenshot/ThumbnailURL", ".//a//img/@src")
 		if err != nil {
-			return fmt.Errorf("error resolving value 'File/URL': %s", err.Error())
+			return err
 		}
 
-		file.Name, ok = pathCache.String(fileTag, "td//div[@class='project-file-name-container']/a/text()")
-		if !ok {
-			return fmt.Errorf("error resolving value 'File/Name'")
+		results.Screenshots = append(results.Screenshots, image)
+	}
+
+	return nil
+}
+
+// parseAdditionalFileCount parses the count from the text of a more-files tag.
+// Format of this value: "+3 files" -> get the first 'field' without the '+'.
+// Returns 0 if no count can be found.
+func parseAdditionalFileCount(tagText string) uint64 {
+	fields := strings.Fields(tagText)
+	if len(fields) == 0 {
+		return 0
+	}
+	count, err := ParseUInt(strings.TrimPrefix(fields[0], "+"))
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+func parseCFDependencies(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	dependencies, err := parseCFRelations(lookup, root, "Dependency")
+	results.Dependencies = append(results.Dependencies, dependencies...)
+	return err
+}
+
+// parseCFDependents parses the projects listed on the dependents page.
+// The page uses the same listing as the dependencies page.
+func parseCFDependents(results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	dependents, err := parseCFRelations(lookup, root, "Dependent")
+	results.Dependents = append(results.Dependents, dependents...)
+	return err
+}
+
+// parseCFRelations parses the project listing of the dependencies or dependents page.
+// field is the name used for errors, e.g. "Dependency" -> "Dependency/Name".
+// The projects parsed before an error are returned with it.
+func parseCFRelations(lookup *pageLookup, root *xmlpath.Node, field string) ([]Dependency, error) {
+	var err error
+	var relations []Dependency
+
+	// The projects are grouped by type, each group under its own heading
+	groups := pathCache.Iter(root, "//div[@class='project-dependencies']/section")
+	for groups.Next() {
+		groupTag := groups.Node()
+
+		heading, _ := pathCache.String(groupTag, "h4")
+		depType := parseDependencyType(heading)
+
+		dependencies := pathCache.Iter(groupTag, ".//li[@class='project-list-item']")
+		for dependencies.Next() {
+			dependencyTag := dependencies.Node()
+
+			dependency := Dependency{
+				Type: depType,
+			}
+
+			// Name
+			dependency.Name, err = lookup.RequiredString(dependencyTag, field+"/Name", ".//div[@class='name-wrapper overflow-tip']/a")
+			if err != nil {
+				return relations, err
+			}
+
+			// URL
+			dependency.URL, err = lookup.RequiredURL(dependencyTag, field+"/URL", ".//div[@class='name-wrapper overflow-tip']/a/@href")
+			if err != nil {
+				return relations, err
+			}
+
+			// Image URL
+			// can be non-present
+			dependency.ImageURL, _ = lookup.URL(dependencyTag, field+"/ImageURL", ".//div[@class='avatar-wrapper']//img/@src")
+
+			relations = append(relations, dependency)
 		}
+	}
 
-		_, ok = pathCache.String(fileTag, "td//div[@class='project-file-name-container']/a[@class='more-files-tag']")
-		file.HasAdditionalFiles = ok
+	return relations, nil
+}
 
-		file.SizeInfo, ok = pathCache.String(fileTag, "td[@class='project-file-size']/text()")
-		if !ok {
-			return fmt.Errorf("error resolving value 'File/SizeInfo'")
+// isCFModpack returns true if the root category of the project is the modpacks category,
+// or if the page is marked as a modpack.
+func isCFModpack(lookup *pageLookup, root *xmlpath.Node, rootCategory string, rootCategoryURL *url.URL) bool {
+	if strings.EqualFold(rootCategory, "Modpacks") {
+		return true
+	}
+	if rootCategoryURL != nil && strings.EqualFold(path.Base(rootCategoryURL.Path), "modpacks") {
+		return true
+	}
+	_, ok := lookup.Node(root, "Modpack marker", "//*[@data-project-type='modpack']")
+	return ok
+}
+
+// updateIncludedProjects fills IncludedProjects from the included dependencies of a modpack.
+// It is rebuilt after each section, as the header & the dependencies can be parsed in any order.
+func updateIncludedProjects(results *CurseForge) {
+	results.IncludedProjects = results.IncludedProjects[:0]
+	if !results.IsModpack {
+		return
+	}
+	for _, dependency := range results.Dependencies {
+		if !strings.HasPrefix(strings.ToLower(dependency.Type), "includ") {
+			continue
 		}
+		results.IncludedProjects = append(results.IncludedProjects, ProjectSummary{
+			Title: dependency.Name,
+			URL:   dependency.URL,
+		})
+	}
+}
 
-		file.Date, err = pathCache.UnixTimestamp(fileTag, "td//abbr/@data-epoch")
+// parseDependencyType returns the dependency type from the heading of a dependency group,
+// e.g. "Required Dependency" -> "Required", "Embedded Library" -> "Embedded".
+func parseDependencyType(heading string) string {
+	fields := strings.Fields(heading)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// parseCFComments parses the first comments page and sequentially loads & parses the subsequent pages.
+func parseCFComments(fetcher *Fetcher, results *CurseForge, lookup *pageLookup, root *xmlpath.Node, options CurseForgeOptions) error {
+	// Parse the comments on the first page
+	err := parseCFCommentsSinglePage(results, lookup, root)
+	if err != nil {
+		return wrapError(err, "error parsing first comments page")
+	}
+
+	// Stop if no pagination is requested
+	if options.Has(CFOptionCommentsNoPagination) {
+		return nil
+	}
+
+	pageCount, err := parseCFPageCount(root)
+	if err != nil {
+		return err
+	}
+
+	// Sequentially, load the comment pages
+	var page uint64
+	for page = 2; page <= pageCount; page++ {
+		pageURL := lookup.url.ResolveReference(&url.URL{
+			Path:     "comments",
+			RawQuery: fmt.Sprintf("page=%d", page),
+		}).String()
+		resp, err := fetcher.FetchPage(pageURL)
+		if err != nil {
+			return err
+		}
+		err = checkPageStatus(pageURL, resp)
 		if err != nil {
-			return fmt.Errorf("error resolving value 'File/Date': %s", err.Error())
+			return err
 		}
 
-		file.GameVersion, ok = pathCache.String(fileTag, "td//span[@class='version-label']/text()")
-		if !ok {
-			return fmt.Errorf("error resolving value 'File/GameVersion'")
+		root, err := xmlpath.ParseHTML(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("error parsing xml/http for subsequent comments page (%d): %s", page, err.Error())
 		}
 
-		file.Downloads, err = pathCache.UInt(fileTag, "td[@class='project-file-downloads']/text()")
+		err = parseCFCommentsSinglePage(results, lookup, root)
 		if err != nil {
-			return fmt.Errorf("error resolving value 'File/Downloads': %s", err.Error())
+			return wrapError(err, fmt.Sprintf("error parsing comments page %d", page))
 		}
+	}
 
-		results.Downloads = append(results.Downloads, file)
+	return nil
+}
+
+func parseCFCommentsSinglePage(results *CurseForge, lookup *pageLookup, root *xmlpath.Node) error {
+	comments := pathCache.Iter(root, "//ul[@class='comment-list']/li[@class='project-comment']")
+	for comments.Next() {
+		comment, err := parseCFComment(comments.Node(), lookup)
+		if err != nil {
+			return err
+		}
+
+		results.Comments = append(results.Comments, comment)
 	}
 	return nil
 }
 
-func parseCFImages(results *CurseForge, documentURL *url.URL, root *xmlpath.Node, options CurseForgeOptions) error {
-	//var ok bool
-	//var err error
+// parseCFComment parses a single comment (li) of the comments listing.
+func parseCFComment(commentTag *xmlpath.Node, lookup *pageLookup) (Comment, error) {
+	var err error
 
-	//TODO: implement
+	comment := Comment{}
 
-	return nil
+	// Author
+	comment.Author.Name, err = lookup.RequiredString(commentTag, "Comment/Author/Name", "div[@class='comment-author']/a[@class='user-name']")
+	if err != nil {
+		return comment, err
+	}
+
+	comment.Author.URL, err = lookup.RequiredURL(commentTag, "Comment/Author/URL", "div[@class='comment-author']/a[@class='user-name']/@href")
+	if err != nil {
+		return comment, err
+	}
+
+	// can be non-present
+	comment.Author.ImageURL, _ = lookup.URL(commentTag, "Comment/Author/ImageURL", "div[@class='comment-author']/div[@class='avatar-wrapper']//img/@src")
+
+	// Permalink
+	comment.URL, err = lookup.RequiredURL(commentTag, "Comment/URL", ".//a[@class='comment-permalink']/@href")
+	if err != nil {
+		return comment, err
+	}
+
+	comment.Date, err = lookup.RequiredUnixTimestamp(commentTag, "Comment/Date", ".//a[@class='comment-permalink']/abbr/@data-epoch")
+	if err != nil {
+		return comment, err
+	}
+
+	// can be empty
+	comment.Body, _ = lookup.String(commentTag, "Comment/Body", "div[@class='comment-body']")
+	comment.Body = strings.TrimSpace(comment.Body)
+
+	return comment, nil
+}
+
+// parseCFAvailableGameVersions parses the options of the game version filter on the files page.
+// The "All Versions" placeholder is skipped. Versions already present are not added twice.
+func parseCFAvailableGameVersions(results *CurseForge, root *xmlpath.Node) {
+	options := pathCache.Iter(root, "//select[@id='filter-game-version']//option")
+	for options.Next() {
+		optionTag := options.Node()
+
+		// The placeholder has an empty value
+		value, _ := pathCache.String(optionTag, "@value")
+		if value == "" {
+			continue
+		}
+		version := NormalizeString(optionTag.String())
+		if version == "" || containsString(results.AvailableGameVersions, version) {
+			continue
+		}
+		results.AvailableGameVersions = append(results.AvailableGameVersions, version)
+	}
+}
+
+// containsString returns true if list contains s.
+func containsString(list []string, s string) bool {
+	for _, entry := range list {
+		if entry == s {
+			return true
+		}
+	}
+	return false
 }
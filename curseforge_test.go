@@ -17,10 +17,17 @@ Copyright 2017 Oliver Kahrmann
 package curse
 
 import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"os"
 	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/xmlpath.v2"
 )
 
 func TestDeriveCurseForgeURLs(t *testing.T) {
@@ -38,9 +45,220 @@ func TestDeriveCurseForgeURLs(t *testing.T) {
 	if "https://minecraft.curseforge.com/projects/taam/images" != urls[CFSectionImages].String() {
 		t.Errorf("Expected '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/images", urls[CFSectionFiles].String())
 	}
+	if "https://minecraft.curseforge.com/projects/taam/relations/dependencies" != urls[CFSectionDependencies].String() {
+		t.Errorf("Expected '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/relations/dependencies", urls[CFSectionDependencies].String())
+	}
+	if "https://minecraft.curseforge.com/projects/taam/comments" != urls[CFSectionComments].String() {
+		t.Errorf("Expected '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/comments", urls[CFSectionComments].String())
+	}
+	if "https://minecraft.curseforge.com/projects/taam/relations/dependents" != urls[CFSectionDependents].String() {
+		t.Errorf("Expected '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/relations/dependents", urls[CFSectionDependents].String())
+	}
+
+	// Current site layout
+	projectURL, err = url.Parse("https://www.curseforge.com/minecraft/mc-mods/taam")
+	if err != nil {
+		t.Fatal("Parsing rest URL", err.Error())
+	}
+	urls, err = DeriveCurseForgeURLs(projectURL)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := map[CurseForgeSections]string{
+		CFSectionOverview:     "https://www.curseforge.com/minecraft/mc-mods/taam",
+		CFSectionFiles:        "https://www.curseforge.com/minecraft/mc-mods/taam/files",
+		CFSectionImages:       "https://www.curseforge.com/minecraft/mc-mods/taam/screenshots",
+		CFSectionDependencies: "https://www.curseforge.com/minecraft/mc-mods/taam/relations/dependencies",
+	}
+	for section, expectedURL := range expected {
+		if urls[section].String() != expectedURL {
+			t.Errorf("Expected '%s', got '%s'", expectedURL, urls[section].String())
+		}
+	}
+}
+
+func TestCurseForgeSectionsString(t *testing.T) {
+	testValues := map[CurseForgeSections]string{
+		CFSectionHeader:                    "Header",
+		CFSectionOverview:                  "Overview",
+		CFSectionOverview | CFSectionFiles: "Overview|Files",
+		CFSectionComments | CFSectionDependents | CFSectionImages: "Images|Comments|Dependents",
+		CFSectionFiles | 8: "Files|0x8",
+	}
+	for sections, expected := range testValues {
+		if sections.String() != expected {
+			t.Errorf("Expected '%s' for %d, got '%s'", expected, uint8(sections), sections.String())
+		}
+	}
+}
+
+func TestCurseForgeOptionsString(t *testing.T) {
+	testValues := map[CurseForgeOptions]string{
+		CFOptionNone: "None",
+		CFOptionOverviewRecentFiles | CFOptionFilesNoPagination: "OverviewRecentFiles|FilesNoPagination",
+		CFOptionSkipHeader | CFOptionParallel:                   "Parallel|SkipHeader",
+	}
+	for options, expected := range testValues {
+		if options.String() != expected {
+			t.Errorf("Expected '%s' for %d, got '%s'", expected, uint8(options), options.String())
+		}
+	}
+}
+
+func TestIsLegacyCurseForgeURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://minecraft.curseforge.com/projects/taam":         true,
+		"https://wow.curseforge.com/projects/pawn":               true,
+		"https://www.feed-the-beast.com/projects/ftb-revelation": true,
+		"https://www.curseforge.com/minecraft/mc-mods/taam":      false,
+		"https://WWW.CurseForge.com:443/wow/addons/pawn":         false,
+		"https://curseforge.com/minecraft/mc-mods/jei":           false,
+	}
+	for rawURL, expected := range tests {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if IsLegacyCurseForgeURL(u) != expected {
+			t.Errorf("Expected IsLegacyCurseForgeURL(%s) to be %t", rawURL, expected)
+		}
+	}
+}
+
+func TestParseCurseForgeFixture(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(documentURL, f, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validateResultsCurseforge(t, documentURL.String(), results, true)
+
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if results.Game != "WoW" || results.RootGameCategory != "Addons" {
+		t.Errorf("Unexpected game '%s' / root category '%s'", results.Game, results.RootGameCategory)
+	}
+	if results.GameType != GameTypeWoW {
+		t.Errorf("Expected game type %s, got %s", GameTypeWoW, results.GameType)
+	}
+	if results.License != "All Rights Reserved" || results.LicenseSPDX != "" {
+		t.Errorf("Unexpected license '%s' (SPDX '%s')", results.License, results.LicenseSPDX)
+	}
+	if len(results.Authors) != 1 || results.Authors[0].Name != "VgerAN" || results.Authors[0].Role != "Owner" {
+		t.Errorf("Unexpected authors: %v", results.Authors)
+	} else if results.Authors[0].NormalizedRole != AuthorRoleOwner {
+		t.Errorf("Expected author role %s, got %s", AuthorRoleOwner, results.Authors[0].NormalizedRole)
+	}
+	expectedCategories := []string{"Bags & Inventory", "Tooltip"}
+	if len(results.Categories) != len(expectedCategories) {
+		t.Fatalf("Expected %d categories, got %d", len(expectedCategories), len(results.Categories))
+	}
+	for idx, name := range expectedCategories {
+		if results.Categories[idx].Name != name {
+			t.Errorf("Expected category '%s', got '%s'", name, results.Categories[idx].Name)
+		}
+	}
+	if !results.Created.Equal(time.Unix(1178053200, 0)) || !results.Updated.Equal(time.Unix(1503439200, 0)) {
+		t.Errorf("Unexpected dates: created %v, updated %v", results.Created, results.Updated)
+	}
+}
+
+func TestParseCurseForgeFilesFixture(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/curseforge_files_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(documentURL, f, false, CFSectionFiles, CFOptionFilesNoPagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		Name        string
+		GameVersion string
+		Downloads   uint64
+	}{
+		{"TAAM-1.12.1-0.7.0.jar", "1.12.1", 1234},
+		{"TAAM-1.11.2-0.6.2.jar", "1.11.2", 567},
+		{"TAAM-1.10.2-0.5.0.jar", "1.10.2", 0},
+	}
+	if len(results.Downloads) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(results.Downloads))
+	}
+	for idx, file := range results.Downloads {
+		if file.Name != expected[idx].Name || file.GameVersion != expected[idx].GameVersion || file.Downloads != expected[idx].Downloads {
+			t.Errorf("Expected file %v, got %s / %s / %d", expected[idx], file.Name, file.GameVersion, file.Downloads)
+		}
+	}
+	if !results.Downloads[1].HasAdditionalFiles || results.Downloads[1].AdditionalFileCount != 2 {
+		t.Errorf("Expected 2 additional files for '%s'", results.Downloads[1].Name)
+	}
+	for idx, file := range results.Downloads {
+		if file.Deprecated != (idx == 2) {
+			t.Errorf("Unexpected deprecated flag %t for '%s'", file.Deprecated, file.Name)
+		}
+	}
+
+	// The totals are parsed even without loading the other pages
+	if results.TotalFilePages != 3 {
+		t.Errorf("Expected %d file pages, got %d", 3, results.TotalFilePages)
+	}
+	if results.TotalFiles != 9 {
+		t.Errorf("Expected %d files in total, got %d", 9, results.TotalFiles)
+	}
+}
+
+func TestParseCFTotalCount(t *testing.T) {
+	tests := map[string]uint64{
+		`<div class="listing-header"><div class="b-pagination-info">Showing 1 - 25 of 1,211</div></div>`: 1211,
+		`<div class="listing-header"><div class="b-pagination-info">  </div></div>`:                      0,
+		`<div class="listing-header"></div>`:                                                             0,
+	}
+	for page, expected := range tests {
+		root, err := xmlpath.ParseHTML(strings.NewReader(page))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if total := parseCFTotalCount(newPageLookup(nil, nil), root); total != expected {
+			t.Errorf("Expected total %d for '%s', got %d", expected, page, total)
+		}
+	}
+
+	// Localized pages are parsed with the thousands separator of the Fetcher
+	root, err := xmlpath.ParseHTML(strings.NewReader(`<div class="listing-header"><div class="b-pagination-info">1 - 25 von 1.211</div></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total := parseCFTotalCount(newPageLookup(&Fetcher{ThousandsSeparator: '.'}, nil), root); total != 1211 {
+		t.Errorf("Expected total %d with separator '.', got %d", 1211, total)
+	}
+	if total := parseCFTotalCount(newPageLookup(&Fetcher{}, nil), root); total != 0 {
+		t.Errorf("Expected total %d with the default separator, got %d", 0, total)
+	}
 }
 
 func TestParseCurseForge(t *testing.T) {
+	requireNetwork(t)
+
 	testUrls := []string{
 		"https://minecraft.curseforge.com/projects/taam",
 		"https://wow.curseforge.com/projects/pawn",
@@ -71,6 +289,8 @@ func TestParseCurseForge(t *testing.T) {
 }
 
 func TestFetchCurseForge(t *testing.T) {
+	requireNetwork(t)
+
 	testUrls := []string{
 		"https://minecraft.curseforge.com/projects/taam",
 		"https://wow.curseforge.com/projects/pawn",
@@ -172,10 +392,10 @@ func validateResultsCurseforge(t *testing.T, url string, results *CurseForge, ex
 		t.Errorf("Empty value 'TotalDownloads' when testing URL %s", url)
 	}
 
-	if results.Created == time.Unix(0, 0).UTC() {
+	if results.Created.IsZero() {
 		t.Errorf("Empty value 'Created' when testing URL %s", url)
 	}
-	if results.Updated == time.Unix(0, 0).UTC() {
+	if results.Updated.IsZero() {
 		t.Errorf("Empty value 'Updated' when testing URL %s", url)
 	}
 
@@ -221,3 +441,1336 @@ func validateResultsCurseforge(t *testing.T, url string, results *CurseForge, ex
 		}
 	}
 }
+
+func TestUpdateFirstFileDate(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "new", Date: time.Unix(1500000000, 0).UTC()},
+			{Name: "unparsed", Date: time.Time{}},
+			{Name: "old", Date: time.Unix(1400000000, 0).UTC()},
+		},
+	}
+	updateFirstFileDate(results)
+	if !results.FirstFileDate.Equal(time.Unix(1400000000, 0)) {
+		t.Errorf("Expected FirstFileDate '%s', got '%s'", time.Unix(1400000000, 0).UTC(), results.FirstFileDate)
+	}
+}
+
+func TestParseAdditionalFileCount(t *testing.T) {
+	testValues := map[string]uint64{
+		"+3 files":  3,
+		"+12 Files": 12,
+		"":          0,
+		"More":      0,
+	}
+	for tagText, expected := range testValues {
+		count := parseAdditionalFileCount(tagText)
+		if count != expected {
+			t.Errorf("Expected %d for '%s', got %d", expected, tagText, count)
+		}
+	}
+}
+
+func TestCrossSiteURL(t *testing.T) {
+	curseURL, err := url.Parse("//WWW.curse.com/projects/238424#files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := &CurseForge{CurseURL: curseURL}
+	if crossURL := results.CrossSiteURL(); crossURL == nil || crossURL.String() != "https://www.curse.com/projects/238424" {
+		t.Errorf("Expected '%s', got '%v'", "https://www.curse.com/projects/238424", crossURL)
+	}
+	if results.CurseURL.Scheme != "" {
+		t.Errorf("CrossSiteURL modified the original URL")
+	}
+
+	results = &CurseForge{}
+	if crossURL := results.CrossSiteURL(); crossURL != nil {
+		t.Errorf("Expected nil, got '%s'", crossURL)
+	}
+}
+
+// parseTestdata parses the given file from the testdata directory.
+func parseTestdata(t *testing.T, name string) *xmlpath.Node {
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	root, err := xmlpath.ParseHTML(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestParseCFOverviewRating(t *testing.T) {
+	root := parseTestdata(t, "curseforge_overview_pawn.html")
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Rating != 4.6 {
+		t.Errorf("Expected rating %f, got %f", 4.6, results.Rating)
+	}
+	if results.RatingCount != 128 {
+		t.Errorf("Expected rating count %d, got %d", 128, results.RatingCount)
+	}
+}
+
+func TestParseCFOverviewEngagement(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_overview_pawn.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.CommentCount != 342 {
+		t.Errorf("Expected comment count %d, got %d", 342, results.CommentCount)
+	}
+	if results.Followers != 1200 {
+		t.Errorf("Expected followers %d, got %d", 1200, results.Followers)
+	}
+
+	// Not all games display the metrics
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(raw), `<li><div class="info-label">Comments </div><div class="info-data">342</div></li>`, "", 1)
+	page = strings.Replace(page, `<li><div class="info-label">Watchers </div><div class="info-data">1.2K</div></li>`, "", 1)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.CommentCount != 0 || results.Followers != 0 {
+		t.Errorf("Expected no comment count & followers, got %d & %d", results.CommentCount, results.Followers)
+	}
+}
+
+func TestParseCFOverviewBrokenRecentFile(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A recent file without release type is skipped, the overview is still parsed
+	page := strings.Replace(string(raw), `<div class="release-phase tip" title="Release"></div>`, "", 1)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionOverviewRecentFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.TotalDownloads != 12345678 {
+		t.Errorf("Expected total downloads %d, got %d", 12345678, results.TotalDownloads)
+	}
+	if len(results.FileErrors) != 1 {
+		t.Errorf("Expected %d file error, got %v", 1, results.FileErrors)
+	}
+	if len(results.Downloads) == 0 || results.RecentBeta == nil {
+		t.Errorf("Expected the remaining recent files, got %d files", len(results.Downloads))
+	}
+}
+
+func TestParseCFOverviewProjectID(t *testing.T) {
+	root := parseTestdata(t, "curseforge_overview_pawn.html")
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without the header, the ID is taken from the curse link
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.ProjectID != 19373 {
+		t.Errorf("Expected project ID %d, got %d", 19373, results.ProjectID)
+	}
+
+	// An ID parsed from the header is kept
+	results = &CurseForge{ProjectID: 42}
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.ProjectID != 42 {
+		t.Errorf("Expected project ID %d, got %d", 42, results.ProjectID)
+	}
+}
+
+func TestParseCFOverviewDescriptionMedia(t *testing.T) {
+	root := parseTestdata(t, "curseforge_overview_pawn.html")
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	media := results.DescriptionMedia
+	if len(media.Videos) != 1 || media.Videos[0].String() != "https://www.youtube.com/embed/a1B2c3D4e5F" {
+		t.Errorf("Expected video '%s', got %v", "https://www.youtube.com/embed/a1B2c3D4e5F", media.Videos)
+	}
+	if len(media.Images) != 2 {
+		t.Fatalf("Expected %d images, got %d", 2, len(media.Images))
+	}
+	if media.Images[1].String() != "https://wow.curseforge.com/attachments/112/399/pawn-compare.png" {
+		t.Errorf("Expected image '%s', got '%s'", "https://wow.curseforge.com/attachments/112/399/pawn-compare.png", media.Images[1])
+	}
+}
+
+func TestParseCFDescription(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	parseCFDescription(results, raw)
+
+	if !strings.HasPrefix(results.DescriptionHTML, "<p>Pawn calculates scores for items") {
+		t.Errorf("Unexpected description HTML: %s", results.DescriptionHTML)
+	}
+	if !strings.Contains(results.DescriptionHTML, `<img src="/attachments/112/399/pawn-compare.png" alt="Compare"/>`) {
+		t.Errorf("Description HTML is missing the image: %s", results.DescriptionHTML)
+	}
+	if !strings.HasSuffix(results.DescriptionHTML, "</p>") {
+		t.Errorf("Unexpected end of description HTML: %s", results.DescriptionHTML)
+	}
+
+	expectedText := "Pawn calculates scores for items that let you easily see which one is better for your character."
+	if results.DescriptionText != expectedText {
+		t.Errorf("Expected description text %q, got %q", expectedText, results.DescriptionText)
+	}
+
+	// Empty and missing descriptions are no error
+	for _, page := range []string{
+		`<html><body><div class="project-description">  </div></body></html>`,
+		`<html><body><p>No description</p></body></html>`,
+	} {
+		results = new(CurseForge)
+		parseCFDescription(results, []byte(page))
+		if results.DescriptionHTML != "" || results.DescriptionText != "" {
+			t.Errorf("Expected empty description, got %q / %q", results.DescriptionHTML, results.DescriptionText)
+		}
+	}
+
+	// Tags are stripped, whitespace is collapsed
+	page := `<div class="project-description"><h2>Features</h2><ul><li>Fast &amp; <b>small</b></li><li>Free</li></ul>
+	<script>var x = 1;</script></div>`
+	results = new(CurseForge)
+	parseCFDescription(results, []byte(page))
+	if results.DescriptionText != "Features Fast & small Free" {
+		t.Errorf("Expected description text %q, got %q", "Features Fast & small Free", results.DescriptionText)
+	}
+
+	// Inline scripts & unclosed paragraphs are read like a browser does
+	page = `<div class="project-description"><script>if (a < b && c) { x(); }</script><p>First<p>Second</div>`
+	results = new(CurseForge)
+	parseCFDescription(results, []byte(page))
+	if results.DescriptionText != "First Second" {
+		t.Errorf("Expected description text %q, got %q", "First Second", results.DescriptionText)
+	}
+	if !strings.HasSuffix(results.DescriptionHTML, "<p>First</p><p>Second</p>") {
+		t.Errorf("Unexpected description HTML: %s", results.DescriptionHTML)
+	}
+
+	// Nested containers end at the matching end tag, raw text & void elements are written as-is
+	page = `<div class="project-description"><div>Inner</div><style>p > a { color: red; }</style>` +
+		`<p>a &lt; b<br>c</p><!-- hidden --></div><div>Outside</div>`
+	results = new(CurseForge)
+	parseCFDescription(results, []byte(page))
+	expectedHTML := `<div>Inner</div><style>p > a { color: red; }</style><p>a &lt; b<br/>c</p>`
+	if results.DescriptionHTML != expectedHTML {
+		t.Errorf("Expected description HTML %q, got %q", expectedHTML, results.DescriptionHTML)
+	}
+	if results.DescriptionText != "Inner a < b c" {
+		t.Errorf("Expected description text %q, got %q", "Inner a < b c", results.DescriptionText)
+	}
+}
+
+func TestParseCFHeaderDonation(t *testing.T) {
+	root := parseTestdata(t, "curseforge_overview_pawn.html")
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.DontationURL == nil || results.DontationURL.Host != "www.paypal.com" {
+		t.Errorf("Expected donation URL on '%s', got '%v'", "www.paypal.com", results.DontationURL)
+	}
+	if results.DonationProvider != "paypal" {
+		t.Errorf("Expected donation provider '%s', got '%s'", "paypal", results.DonationProvider)
+	}
+}
+
+func TestParseCFHeaderProjectID(t *testing.T) {
+	root := parseTestdata(t, "curseforge_overview_pawn.html")
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.ProjectID != 19373 {
+		t.Errorf("Expected project ID %d, got %d", 19373, results.ProjectID)
+	}
+
+	// Only the atf section is considered, not other projects listed before it
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(raw), `<section class="atf" data-project-id="19373">`, `<div data-project-id="1"></div><section class="atf" data-project-id="19373">`, 1)
+	root, err = xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.ProjectID != 19373 {
+		t.Errorf("Expected project ID %d, got %d", 19373, results.ProjectID)
+	}
+}
+
+func TestParseCFHeaderSummary(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_overview_pawn.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Summary != "Pawn helps you find upgrades and compare items." {
+		t.Errorf("Expected summary '%s', got '%s'", "Pawn helps you find upgrades and compare items.", results.Summary)
+	}
+
+	// Older projects have no summary
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(raw), `<p class="project-summary">Pawn helps you find upgrades and compare items.</p>`, "", 1)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	results = new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Summary != "" {
+		t.Errorf("Expected empty summary, got '%s'", results.Summary)
+	}
+}
+
+func TestParseCFHeaderLayoutVariant(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Variant markup: additional classes on the header containers
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(raw), `<nav class="e-header-nav">`, `<nav class="e-header-nav e-header-nav--b">`, 1)
+	page = strings.Replace(page, `<section class="atf" data-project-id="19373">`, `<section class="atf atf-b" data-project-id="19373">`, 1)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if urlString(results.DependenciesURL) != "https://wow.curseforge.com/projects/pawn/relations/dependencies" {
+		t.Errorf("Unexpected dependencies URL %v", results.DependenciesURL)
+	}
+}
+
+func TestXpathCacheAny(t *testing.T) {
+	root, err := xmlpath.ParseHTML(strings.NewReader(`<html><body><div class="b"><a href="/b">B</a></div></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, _ := url.Parse("https://www.curseforge.com/minecraft")
+
+	s, ok := pathCache.StringAny(root, "//div[@class='a']/a", "//div[@class='b']/a")
+	if !ok || s != "B" {
+		t.Errorf("Expected '%s', got '%s' (%v)", "B", s, ok)
+	}
+	_, ok = pathCache.StringAny(root, "//div[@class='a']/a", "//div[@class='c']/a")
+	if ok {
+		t.Error("Expected no match")
+	}
+	_, ok = pathCache.NodeAny(root, "//div[@class='a']", "//div[@class='b']")
+	if !ok {
+		t.Error("Expected a node")
+	}
+	u, err := pathCache.URLAny(root, base, "//div[@class='a']/a/@href", "//div[@class='b']/a/@href")
+	if err != nil || urlString(u) != "https://www.curseforge.com/b" {
+		t.Errorf("Unexpected URL %v (%v)", u, err)
+	}
+	_, err = pathCache.URLAny(root, base, "//div[@class='a']/a/@href")
+	if err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestParseCFHeaderModpack(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFHeader(results, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_overview_pawn.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.IsModpack {
+		t.Error("Expected an addon not to be a modpack")
+	}
+
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := map[string][]string{
+		"category": {`<h2 class="RootGameCategory"><a href="/addons">Addons</a></h2>`, `<h2 class="RootGameCategory"><a href="/modpacks">Modpacks</a></h2>`},
+		"marker":   {`<section class="atf" data-project-id="19373">`, `<section class="atf" data-project-id="19373" data-project-type="modpack">`},
+	}
+	for name, replacement := range variants {
+		root, err := xmlpath.ParseHTML(strings.NewReader(strings.Replace(string(raw), replacement[0], replacement[1], 1)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = new(CurseForge)
+		err = parseCFHeader(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !results.IsModpack {
+			t.Errorf("Expected a modpack detected by %s", name)
+		}
+	}
+}
+
+func TestUpdateIncludedProjects(t *testing.T) {
+	mod, _ := url.Parse("https://minecraft.curseforge.com/projects/jei")
+	lib, _ := url.Parse("https://minecraft.curseforge.com/projects/codechicken-lib")
+	results := &CurseForge{
+		Dependencies: []Dependency{
+			{Name: "Just Enough Items (JEI)", URL: mod, Type: "Include"},
+			{Name: "CodeChicken Lib", URL: lib, Type: "Required"},
+		},
+	}
+
+	updateIncludedProjects(results)
+	if len(results.IncludedProjects) != 0 {
+		t.Errorf("Expected no included projects for a mod, got %d", len(results.IncludedProjects))
+	}
+
+	results.IsModpack = true
+	updateIncludedProjects(results)
+	updateIncludedProjects(results)
+	if len(results.IncludedProjects) != 1 {
+		t.Fatalf("Expected %d included project, got %d", 1, len(results.IncludedProjects))
+	}
+	if results.IncludedProjects[0].Title != "Just Enough Items (JEI)" || results.IncludedProjects[0].URL != mod {
+		t.Errorf("Unexpected included project %+v", results.IncludedProjects[0])
+	}
+}
+
+func TestParseCFFilesSkipsBadRows(t *testing.T) {
+	page := `<html><body><table><tbody>
+<tr class="project-file-list-item">
+<td class="project-file-release-type"><div title="Release"></div></td>
+<td class="project-file-name">
+<div class="project-file-name-container"><a href="/projects/taam/files/2444195">TAAM-1.12.1-0.7.0.jar</a></div>
+<div class="project-file-download-button"><a href="/projects/taam/files/2444195/download"></a></div>
+</td>
+<td class="project-file-size">1.24 MB</td>
+<td class="project-file-date-uploaded"><abbr data-epoch="1503439200">Aug 22, 2017</abbr></td>
+<td class="project-file-game-version"><span class="version-label">1.12.1</span></td>
+</tr>
+<tr class="project-file-list-item">
+<td class="project-file-release-type"><div title="Beta"></div></td>
+<td class="project-file-name"></td>
+</tr>
+</tbody></table></body></html>`
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFilesSinglePage(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The row without downloads cell is kept, the row without name is skipped
+	if len(results.Downloads) != 1 || results.Downloads[0].Name != "TAAM-1.12.1-0.7.0.jar" || results.Downloads[0].Downloads != 0 {
+		t.Errorf("Unexpected files %v", results.Downloads)
+	}
+	if len(results.FileErrors) != 1 {
+		t.Fatalf("Expected 1 file error, got %v", results.FileErrors)
+	}
+	if parseErr, ok := results.FileErrors[0].(*ParseError); !ok || parseErr.Field != "File/DirectURL" {
+		t.Errorf("Unexpected file error %v", results.FileErrors[0])
+	}
+}
+
+func TestParseCFFilesGameType(t *testing.T) {
+	page := `<html><body><table><tbody>
+<tr class="project-file-list-item">
+<td class="project-file-release-type"><div title="Release"></div></td>
+<td class="project-file-name">
+<div class="project-file-name-container"><a href="/projects/pawn/files/2444195">Pawn-2.2.9.zip</a></div>
+<div class="project-file-download-button"><a href="/projects/pawn/files/2444195/download"></a></div>
+</td>
+<td class="project-file-size">94.5 KB</td>
+<td class="project-file-date-uploaded"><abbr data-epoch="1503439200">Aug 22, 2017</abbr></td>
+<td class="project-file-game-version">70300</td>
+</tr>
+</tbody></table></body></html>`
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A host DetectGameType does not know, the game is resolved by the header
+	documentURL, err := url.Parse("https://addons.example.com/projects/pawn/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := &CurseForge{GameType: GameTypeWoW}
+	err = parseCFFilesSinglePage(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Downloads) != 1 || results.Downloads[0].GameVersion != "7.3.0" {
+		t.Errorf("Unexpected files %v, errors %v", results.Downloads, results.FileErrors)
+	}
+}
+
+func TestParseCFFilesBrokenPagination(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_files_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := strings.Replace(string(raw), `<a class="b-pagination-item" href="/projects/taam/files?page=2">2</a>`, `<a class="b-pagination-item" href="/projects/taam/files?page=2">next</a>`, 1)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pagination is not needed for the first page only
+	results := new(CurseForge)
+	err = parseCFFiles(DefaultFetcher, results, newPageLookup(nil, documentURL), root, CFOptionFilesNoPagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Downloads) != 3 || results.TotalFilePages != 0 {
+		t.Errorf("Expected %d files and unknown pages, got %d files and %d pages", 3, len(results.Downloads), results.TotalFilePages)
+	}
+
+	// Still an error if the pages are requested
+	results = new(CurseForge)
+	err = parseCFFiles(DefaultFetcher, results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err == nil {
+		t.Error("Expected an error for the broken pagination")
+	}
+}
+
+func TestParseCFFileDeprecated(t *testing.T) {
+	testValues := map[string]bool{
+		`<tr class="project-file-list-item"><td>Release</td></tr>`:                                         false,
+		`<tr class="project-file-list-item project-file-archived"><td>Release</td></tr>`:                   true,
+		`<tr class="project-file-list-item deprecated"><td>Release</td></tr>`:                              true,
+		`<tr class="project-file-list-item"><td><span class="file-status">Archived</span></td></tr>`:       true,
+		`<tr class="project-file-list-item"><td><span class="file-status tag">Deprecated</span></td></tr>`: true,
+		`<tr class="project-file-list-item"><td><span class="file-status">Early Access</span></td></tr>`:   false,
+	}
+	for row, expected := range testValues {
+		root, err := xmlpath.ParseHTML(strings.NewReader("<html><body><table>" + row + "</table></body></html>"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileTag, ok := pathCache.Node(root, "//tr")
+		if !ok {
+			t.Fatalf("Row not found in '%s'", row)
+		}
+		if parseCFFileDeprecated(newPageLookup(nil, nil), fileTag) != expected {
+			t.Errorf("Expected %t for '%s'", expected, row)
+		}
+	}
+}
+
+func TestParseCFFileRowID(t *testing.T) {
+	root := parseTestdata(t, "curseforge_files_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFilesSinglePage(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First row has the data attribute, the others are extracted from the URL
+	expectedIDs := []uint64{2444195, 2398011, 2301234}
+	if len(results.Downloads) != len(expectedIDs) {
+		t.Fatalf("Expected %d files, got %d", len(expectedIDs), len(results.Downloads))
+	}
+	for idx, expected := range expectedIDs {
+		if results.Downloads[idx].ID != expected {
+			t.Errorf("Expected file ID %d, got %d", expected, results.Downloads[idx].ID)
+		}
+	}
+}
+
+func TestParseCFFileRowRelease(t *testing.T) {
+	root := parseTestdata(t, "curseforge_files_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFilesSinglePage(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ReleaseType{ReleaseTypeRelease, ReleaseTypeBeta, ReleaseTypeAlpha}
+	if len(results.Downloads) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(results.Downloads))
+	}
+	for idx, release := range expected {
+		if results.Downloads[idx].Release != release {
+			t.Errorf("Expected release type %s, got %s", release, results.Downloads[idx].Release)
+		}
+	}
+}
+
+func TestParseDonationProvider(t *testing.T) {
+	testValues := map[string]string{
+		"button tip icon-donate icon-paypal":  "paypal",
+		"button tip icon-donate icon-patreon": "patreon",
+		"button icon-ko-fi icon-donate":       "ko-fi",
+		"button tip icon-donate":              "",
+	}
+	for class, expected := range testValues {
+		provider := parseDonationProvider(class)
+		if provider != expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", expected, class, provider)
+		}
+	}
+}
+
+func TestAvatarURLVariants(t *testing.T) {
+	thumbnail, _ := url.Parse("https://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png")
+	variants := avatarURLVariants(thumbnail)
+	expected := map[int]string{
+		32:  "https://media.forgecdn.net/avatars/thumbnails/29/441/32/32/636053226359479498.png",
+		64:  "https://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png",
+		128: "https://media.forgecdn.net/avatars/thumbnails/29/441/128/128/636053226359479498.png",
+		256: "https://media.forgecdn.net/avatars/thumbnails/29/441/256/256/636053226359479498.png",
+	}
+	if len(variants) != len(expected) {
+		t.Fatalf("Expected %d variants, got %v", len(expected), variants)
+	}
+	for size, u := range expected {
+		if variants[size] == nil || variants[size].String() != u {
+			t.Errorf("Expected '%s' for size %d, got %v", u, size, variants[size])
+		}
+	}
+
+	for _, other := range []string{
+		"https://media.forgecdn.net/avatars/29/441/636053226359479498.png",
+		"https://example.com/avatars/thumbnails/29/441/64/64/636053226359479498.png",
+	} {
+		u, _ := url.Parse(other)
+		if variants := avatarURLVariants(u); variants != nil {
+			t.Errorf("Expected no variants for '%s', got %v", other, variants)
+		}
+	}
+	if variants := avatarURLVariants(nil); variants != nil {
+		t.Errorf("Expected no variants for nil, got %v", variants)
+	}
+}
+
+func TestParseCFImages(t *testing.T) {
+	root := parseTestdata(t, "curseforge_images_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/images")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFImages(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct{ URL, ThumbnailURL string }{
+		{
+			"https://media.forgecdn.net/attachments/152/210/2017-08-22_12.00.00.png",
+			"https://media.forgecdn.net/attachments/thumbnails/152/210/310/172/2017-08-22_12.00.00.png",
+		},
+		{
+			"https://minecraft.curseforge.com/attachments/152/211/machines.png",
+			"https://minecraft.curseforge.com/attachments/thumbnails/152/211/310/172/machines.png",
+		},
+	}
+	if len(results.Screenshots) != len(expected) {
+		t.Fatalf("Expected %d screenshots, got %d", len(expected), len(results.Screenshots))
+	}
+	for idx, s := range results.Screenshots {
+		if s.URL.String() != expected[idx].URL {
+			t.Errorf("Expected 'Screenshot/URL' '%s', got '%s'", expected[idx].URL, s.URL)
+		}
+		if s.ThumbnailURL.String() != expected[idx].ThumbnailURL {
+			t.Errorf("Expected 'Screenshot/ThumbnailURL' '%s', got '%s'", expected[idx].ThumbnailURL, s.ThumbnailURL)
+		}
+	}
+}
+
+func TestParseCFDependencies(t *testing.T) {
+	root := parseTestdata(t, "curseforge_dependencies_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/relations/dependencies")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFDependencies(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct{ Name, URL, Type string }{
+		{"MCMultiPart", "https://minecraft.curseforge.com/projects/mcmultipart", "Required"},
+		{"Just Enough Items (JEI)", "https://minecraft.curseforge.com/projects/jei", "Optional"},
+		{"Waila", "https://minecraft.curseforge.com/projects/waila", "Optional"},
+		{"CodeChicken Lib", "https://minecraft.curseforge.com/projects/codechicken-lib", "Embedded"},
+	}
+	if len(results.Dependencies) != len(expected) {
+		t.Fatalf("Expected %d dependencies, got %d", len(expected), len(results.Dependencies))
+	}
+	for idx, d := range results.Dependencies {
+		if d.Name != expected[idx].Name {
+			t.Errorf("Expected 'Dependency/Name' '%s', got '%s'", expected[idx].Name, d.Name)
+		}
+		if d.URL.String() != expected[idx].URL {
+			t.Errorf("Expected 'Dependency/URL' '%s', got '%s'", expected[idx].URL, d.URL)
+		}
+		if d.Type != expected[idx].Type {
+			t.Errorf("Expected 'Dependency/Type' '%s', got '%s'", expected[idx].Type, d.Type)
+		}
+	}
+	if results.Dependencies[0].ImageURL == nil || results.Dependencies[0].ImageURL.Host == "" {
+		t.Errorf("Empty value 'Dependency/ImageURL'")
+	}
+	if results.Dependencies[2].ImageURL != nil {
+		t.Errorf("Expected no 'Dependency/ImageURL', got '%s'", results.Dependencies[2].ImageURL)
+	}
+}
+
+func TestParseCFDependents(t *testing.T) {
+	root := parseTestdata(t, "curseforge_dependents_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/relations/dependents")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFDependents(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct{ Name, URL, Type string }{
+		{"TAAM Addons", "https://minecraft.curseforge.com/projects/taam-addons", "Required"},
+		{"Factory Pack", "https://minecraft.curseforge.com/projects/factory-pack", "Optional"},
+	}
+	if len(results.Dependents) != len(expected) {
+		t.Fatalf("Expected %d dependents, got %d", len(expected), len(results.Dependents))
+	}
+	for idx, d := range results.Dependents {
+		if d.Name != expected[idx].Name {
+			t.Errorf("Expected 'Dependent/Name' '%s', got '%s'", expected[idx].Name, d.Name)
+		}
+		if d.URL.String() != expected[idx].URL {
+			t.Errorf("Expected 'Dependent/URL' '%s', got '%s'", expected[idx].URL, d.URL)
+		}
+		if d.Type != expected[idx].Type {
+			t.Errorf("Expected 'Dependent/Type' '%s', got '%s'", expected[idx].Type, d.Type)
+		}
+	}
+	if results.Dependents[0].ImageURL == nil || results.Dependents[0].ImageURL.Host == "" {
+		t.Errorf("Empty value 'Dependent/ImageURL'")
+	}
+	if len(results.Dependencies) != 0 {
+		t.Errorf("Expected no dependencies, got %d", len(results.Dependencies))
+	}
+}
+
+func TestParseCFComments(t *testing.T) {
+	root := parseTestdata(t, "curseforge_comments_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFComments(nil, results, newPageLookup(nil, documentURL), root, CFOptionCommentsNoPagination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results.normalizeText()
+
+	if len(results.Comments) != 2 {
+		t.Fatalf("Expected %d comments, got %d", 2, len(results.Comments))
+	}
+
+	c := results.Comments[0]
+	if c.Author.Name != "founderio" {
+		t.Errorf("Expected 'Comment/Author/Name' '%s', got '%s'", "founderio", c.Author.Name)
+	}
+	if c.Author.URL.String() != "https://minecraft.curseforge.com/members/founderio" {
+		t.Errorf("Expected 'Comment/Author/URL' '%s', got '%s'", "https://minecraft.curseforge.com/members/founderio", c.Author.URL)
+	}
+	if c.Author.ImageURL == nil || c.Author.ImageURL.Host != "media.forgecdn.net" {
+		t.Errorf("Unexpected 'Comment/Author/ImageURL' '%s'", c.Author.ImageURL)
+	}
+	if c.URL.String() != "https://minecraft.curseforge.com/projects/taam/comments#comment-7" {
+		t.Errorf("Expected 'Comment/URL' '%s', got '%s'", "https://minecraft.curseforge.com/projects/taam/comments#comment-7", c.URL)
+	}
+	if !c.Date.Equal(time.Unix(1504128000, 0)) {
+		t.Errorf("Expected 'Comment/Date' '%s', got '%s'", time.Unix(1504128000, 0), c.Date)
+	}
+	// Line breaks are kept
+	expectedBody := "The conveyor changes are in 1.12 now,\n  see the changelog of the latest file."
+	if c.Body != expectedBody {
+		t.Errorf("Expected 'Comment/Body' '%s', got '%s'", expectedBody, c.Body)
+	}
+
+	// No avatar
+	c = results.Comments[1]
+	if c.Author.ImageURL != nil {
+		t.Errorf("Expected no 'Comment/Author/ImageURL', got '%s'", c.Author.ImageURL)
+	}
+	expectedBody = "Conveyors don't connect to pipes anymore & items get stuck."
+	if c.Body != expectedBody {
+		t.Errorf("Expected 'Comment/Body' '%s', got '%s'", expectedBody, c.Body)
+	}
+}
+
+func TestFetchCurseForgeImages(t *testing.T) {
+	requireNetwork(t)
+
+	pURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := FetchCurseForge(pURL, CFSectionImages, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.Screenshots) == 0 {
+		t.Errorf("Empty list 'Screenshots' when testing URL %s", pURL)
+	}
+	for _, s := range results.Screenshots {
+		if s.URL == nil || s.URL.Host == "" {
+			t.Errorf("Empty value 'Screenshot/URL' when testing URL %s", pURL)
+		}
+		if s.ThumbnailURL == nil || s.ThumbnailURL.Host == "" {
+			t.Errorf("Empty value 'Screenshot/ThumbnailURL' when testing URL %s", pURL)
+		}
+	}
+}
+
+func TestFetchCurseForgeHeaderSectionURLs(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/wow/addons/pawn":
+			http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+		case "/projects/pawn/images":
+			http.ServeFile(w, r, "testdata/curseforge_images_taam.html")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	// The derived images URL (.../pawn/images) does not exist, the navigation links to the right one
+	projectURL, err := url.Parse(server.URL + "/wow/addons/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := NewFetcher(nil).FetchCurseForge(projectURL, CFSectionOverview|CFSectionImages, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Screenshots) == 0 {
+		t.Error("Expected the screenshots from the linked images page")
+	}
+	if len(requested) != 2 || requested[1] != "/projects/pawn/images" {
+		t.Errorf("Unexpected requests %v", requested)
+	}
+}
+
+func TestFetchCurseForgeRedirect(t *testing.T) {
+	canonical := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/pawn" {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+	}))
+	defer canonical.Close()
+	legacy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, canonical.URL+"/projects/pawn", http.StatusMovedPermanently)
+	}))
+	defer legacy.Close()
+
+	projectURL, err := url.Parse(legacy.URL + "/projects/old-pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sections := range []CurseForgeSections{CFSectionHeader, CFSectionOverview} {
+		results, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if urlString(results.CanonicalURL) != canonical.URL+"/projects/pawn" {
+			t.Errorf("Expected canonical URL '%s', got '%s'", canonical.URL+"/projects/pawn", results.CanonicalURL)
+		}
+		// Relative links are resolved against the final URL
+		if urlString(results.ProjectURL) != canonical.URL+"/projects/pawn" {
+			t.Errorf("Expected project URL '%s', got '%s'", canonical.URL+"/projects/pawn", results.ProjectURL)
+		}
+	}
+}
+
+func TestParseCFHeaderCanonicalURL(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header parsed from a section page: canonical URL is the overview, not the files page
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := new(CurseForge)
+	err = results.ParseCurseForgeBytes(documentURL, raw, true, CFSectionHeader, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urlString(results.CanonicalURL) != "https://wow.curseforge.com/projects/pawn" {
+		t.Errorf("Expected the overview as canonical URL, got '%s'", results.CanonicalURL)
+	}
+
+	// A canonical link declared by the page takes precedence
+	raw = bytes.Replace(raw, []byte("<head>"), []byte(`<head><link rel="canonical" href="/projects/pawn-renamed">`), 1)
+	results = new(CurseForge)
+	err = results.ParseCurseForgeBytes(documentURL, raw, true, CFSectionHeader, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urlString(results.CanonicalURL) != "https://wow.curseforge.com/projects/pawn-renamed" {
+		t.Errorf("Expected the declared canonical URL, got '%s'", results.CanonicalURL)
+	}
+}
+
+func TestParseURLWithBase(t *testing.T) {
+	httpsBase, _ := url.Parse("https://minecraft.curseforge.com/projects/taam")
+	httpBase, _ := url.Parse("http://minecraft.curseforge.com/projects/taam")
+	schemelessBase, _ := url.Parse("//minecraft.curseforge.com/projects/taam")
+
+	tests := []struct {
+		input    string
+		base     *url.URL
+		expected string
+	}{
+		// Absolute
+		{"https://media.forgecdn.net/avatars/29/441/636053226359479498.png", httpBase, "https://media.forgecdn.net/avatars/29/441/636053226359479498.png"},
+		// Relative
+		{"/members/founderio", httpsBase, "https://minecraft.curseforge.com/members/founderio"},
+		{"/members/founderio", httpBase, "http://minecraft.curseforge.com/members/founderio"},
+		// Protocol-relative, inherits the scheme of the document
+		{"//media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png", httpsBase, "https://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png"},
+		{"//media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png", httpBase, "http://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png"},
+		// No scheme anywhere, falls back to https
+		{"//media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png", schemelessBase, "https://media.forgecdn.net/avatars/thumbnails/29/441/64/64/636053226359479498.png"},
+		// Inline data, as-is
+		{" data:image/gif;base64,R0lGODlhAQABAAAAACw= ", httpsBase, "data:image/gif;base64,R0lGODlhAQABAAAAACw="},
+		{"data:image/svg+xml;utf8,<svg width='100%'></svg>", httpsBase, "data:image/svg+xml;utf8,<svg width='100%'></svg>"},
+	}
+
+	for _, test := range tests {
+		parsed, err := ParseURLWithBase(test.input, test.base)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", test.input, err.Error())
+			continue
+		}
+		if parsed.String() != test.expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", test.expected, test.input, parsed.String())
+		}
+		if IsDataURL(parsed) != strings.HasPrefix(test.expected, "data:") {
+			t.Errorf("Unexpected IsDataURL() for '%s'", test.input)
+		}
+	}
+
+	parsed, err := ParseURL("data:image/gif;base64,R0lGODlhAQABAAAAACw=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsDataURL(parsed) || parsed.Host != "" {
+		t.Errorf("Expected a data URL without host, got '%s'", parsed)
+	}
+}
+
+func TestParseProjectID(t *testing.T) {
+	tests := map[string]uint64{
+		"https://www.curseforge.com/projects/19373":                    19373,
+		"https://minecraft.curseforge.com/projects/238424":             238424,
+		"https://minecraft.curseforge.com/projects/238424/files":       238424,
+		"https://mods.curse.com/mc-mods/minecraft/238424-taam":         238424,
+		"https://mods.curse.com/addons/wow/pawn":                       0,
+		"https://minecraft.curseforge.com/projects/taam":               0,
+		"https://minecraft.curseforge.com/projects/taam/files/2444195": 0,
+		"https://www.curseforge.com/minecraft/mc-mods/taam":            0,
+	}
+	for input, expected := range tests {
+		u, err := url.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, ok := ParseProjectID(u)
+		if ok != (expected != 0) || id != expected {
+			t.Errorf("Expected %d for '%s', got %d (%t)", expected, input, id, ok)
+		}
+	}
+	if _, ok := ParseProjectID(nil); ok {
+		t.Error("Expected no project ID for nil URL")
+	}
+}
+
+func TestParseFileSize(t *testing.T) {
+	testValues := map[string]uint64{
+		"512 bytes": 512,
+		"1.5 MB":    1572864,
+		"2 GiB":     2147483648,
+		"982.15 KB": 1005722,
+		"890KB":     911360,
+		"1,024 KiB": 1048576,
+		" 12 MiB ":  12582912,
+	}
+	for sizeInfo, expected := range testValues {
+		size, err := ParseFileSize(sizeInfo)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", sizeInfo, err.Error())
+			continue
+		}
+		if size != expected {
+			t.Errorf("Expected %d for '%s', got %d", expected, sizeInfo, size)
+		}
+	}
+
+	for _, invalid := range []string{"", "MB", "1.5 TB", "1.2.3 MB"} {
+		if _, err := ParseFileSize(invalid); err == nil {
+			t.Errorf("Expected an error for '%s'", invalid)
+		}
+	}
+}
+
+func TestParseCurseForgeReader(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(documentURL, f, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if results.ProjectID != 19373 {
+		t.Errorf("Expected project ID %d, got %d", 19373, results.ProjectID)
+	}
+	if results.TotalDownloads != 12345678 {
+		t.Errorf("Expected total downloads %d, got %d", 12345678, results.TotalDownloads)
+	}
+}
+
+func TestParseCurseForgeBytes(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeBytes(documentURL, raw, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if results.TotalDownloads != 12345678 {
+		t.Errorf("Expected total downloads %d, got %d", 12345678, results.TotalDownloads)
+	}
+}
+
+func TestParseCFAvailableGameVersions(t *testing.T) {
+	root := parseTestdata(t, "curseforge_files_taam.html")
+
+	results := new(CurseForge)
+	parseCFAvailableGameVersions(results, root)
+	// Parsing again (e.g. a second files page) does not add duplicates
+	parseCFAvailableGameVersions(results, root)
+
+	expected := []string{"1.12.1", "1.12", "1.11.2", "1.10.2"}
+	if len(results.AvailableGameVersions) != len(expected) {
+		t.Fatalf("Expected game versions %v, got %v", expected, results.AvailableGameVersions)
+	}
+	for idx, version := range expected {
+		if results.AvailableGameVersions[idx] != version {
+			t.Errorf("Expected game version '%s', got '%s'", version, results.AvailableGameVersions[idx])
+		}
+	}
+}
+
+func TestParseUIntLocale(t *testing.T) {
+	testValues := []struct {
+		Input    string
+		Sep      rune
+		Expected uint64
+	}{
+		{"12,345,678", ',', 12345678},
+		{"12.345.678", '.', 12345678},
+		{"12 345 678", ' ', 12345678},
+		{"12 345", ',', 12345},
+		{" 567 ", '.', 567},
+		{"-", ',', 0},
+	}
+	for _, v := range testValues {
+		value, err := ParseUIntLocale(v.Input, v.Sep)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", v.Input, err.Error())
+			continue
+		}
+		if value != v.Expected {
+			t.Errorf("Expected %d for '%s', got %d", v.Expected, v.Input, value)
+		}
+	}
+
+	// The wrong separator must not be silently ignored
+	if _, err := ParseUIntLocale("12.345", ','); err == nil {
+		t.Error("Expected an error for '12.345' with separator ','")
+	}
+}
+
+func TestUnixTimestamp(t *testing.T) {
+	root, err := xmlpath.ParseHTML(strings.NewReader(`<html><body><abbr id="epoch" data-epoch="0"></abbr><abbr id="date" data-epoch="1503439200"></abbr><abbr id="broken" data-epoch="soon"></abbr></body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testValues := map[string]time.Time{
+		"//abbr[@id='epoch']/@data-epoch":   time.Unix(0, 0).UTC(),
+		"//abbr[@id='date']/@data-epoch":    time.Unix(1503439200, 0).UTC(),
+		"//abbr[@id='broken']/@data-epoch":  {},
+		"//abbr[@id='missing']/@data-epoch": {},
+	}
+	for path, expected := range testValues {
+		value, err := pathCache.UnixTimestamp(root, path)
+		if value != expected || (err == nil) != !expected.IsZero() {
+			t.Errorf("Expected %v for '%s', got %v (%v)", expected, path, value, err)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	testValues := map[string]uint64{
+		"1.2M":      1200000,
+		"340k":      340000,
+		"340K":      340000,
+		"2.5 B":     2500000000,
+		"1,234,567": 1234567,
+		"1234567":   1234567,
+		"-":         0,
+		" 42 ":      42,
+	}
+	for input, expected := range testValues {
+		value, err := ParseCount(input)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", input, err.Error())
+			continue
+		}
+		if value != expected {
+			t.Errorf("Expected %d for '%s', got %d", expected, input, value)
+		}
+	}
+
+	for _, input := range []string{"", "M", "1.2.3k", "-5k", "12 apples"} {
+		if _, err := ParseCount(input); err == nil {
+			t.Errorf("Expected an error for '%s'", input)
+		}
+	}
+
+	// Localized pages use the comma as decimal separator
+	if value, err := ParseCountLocale("1,5M", '.'); err != nil || value != 1500000 {
+		t.Errorf("Expected %d for '%s', got %d (%v)", 1500000, "1,5M", value, err)
+	}
+	if value, err := ParseCountLocale("1.234.567", '.'); err != nil || value != 1234567 {
+		t.Errorf("Expected %d for '%s', got %d (%v)", 1234567, "1.234.567", value, err)
+	}
+}
+
+func TestParseCFLatestDownloadURL(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parsed without CFOptionOverviewRecentFiles
+	results := new(CurseForge)
+	err = parseCFOverview(results, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_overview_pawn.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://wow.curseforge.com/projects/pawn/files/2445001/download"
+	if results.LatestDownloadURL == nil || results.LatestDownloadURL.String() != expected {
+		t.Errorf("Expected latest download URL '%s', got '%v'", expected, results.LatestDownloadURL)
+	}
+}
+
+func TestParseCFRecentFilesByRelease(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(documentURL, f, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.RecentRelease == nil || results.RecentRelease.Name != "Pawn-2.2.0.zip" {
+		t.Errorf("Expected recent release 'Pawn-2.2.0.zip', got %v", results.RecentRelease)
+	} else if results.RecentRelease.URL.String() != "https://wow.curseforge.com/projects/pawn/files/2444300" {
+		t.Errorf("Unexpected recent release URL '%s'", results.RecentRelease.URL)
+	} else if results.RecentRelease.ID != 2444300 {
+		t.Errorf("Expected recent release ID %d, got %d", 2444300, results.RecentRelease.ID)
+	}
+	if results.RecentBeta == nil || results.RecentBeta.Name != "Pawn-2.2.1-beta1.zip" {
+		t.Errorf("Expected recent beta 'Pawn-2.2.1-beta1.zip', got %v", results.RecentBeta)
+	} else if !results.RecentBeta.Date.Equal(time.Unix(1503525600, 0)) {
+		t.Errorf("Unexpected recent beta date %v", results.RecentBeta.Date)
+	}
+	if results.RecentAlpha != nil {
+		t.Errorf("Expected no recent alpha, got %v", results.RecentAlpha)
+	}
+	// Without CFOptionOverviewRecentFiles, the recent files are not added to the downloads
+	if len(results.Downloads) != 0 {
+		t.Errorf("Expected no downloads, got %d", len(results.Downloads))
+	}
+}
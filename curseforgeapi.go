@@ -0,0 +1,247 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// The redesigned www.curseforge.com does not render the files listing on the server.
+// Instead, the page embeds its initial data as JSON (__NEXT_DATA__) and loads the files
+// in the background from a JSON API. The functions in this file use that API.
+
+// cfAPIFilesPageSize is the number of files requested per API call.
+const cfAPIFilesPageSize = 50
+
+// cfNextData is the part of the embedded __NEXT_DATA__ we are interested in.
+type cfNextData struct {
+	Props struct {
+		PageProps struct {
+			Project struct {
+				ID uint64 `json:"id"`
+			} `json:"project"`
+		} `json:"pageProps"`
+	} `json:"props"`
+}
+
+// cfAPIFilesResponse is the response of the files API.
+type cfAPIFilesResponse struct {
+	Data []struct {
+		ID                   uint64   `json:"id"`
+		DateCreated          string   `json:"dateCreated"`
+		DisplayName          string   `json:"displayName"`
+		FileName             string   `json:"fileName"`
+		FileLength           uint64   `json:"fileLength"`
+		GameVersions         []string `json:"gameVersions"`
+		ReleaseType          int      `json:"releaseType"`
+		TotalDownloads       uint64   `json:"totalDownloads"`
+		AdditionalFilesCount uint64   `json:"additionalFilesCount"`
+	} `json:"data"`
+	Pagination struct {
+		Index      uint64 `json:"index"`
+		PageSize   uint64 `json:"pageSize"`
+		TotalCount uint64 `json:"totalCount"`
+	} `json:"pagination"`
+}
+
+// cfAPIReleaseTypes maps the numeric release types of the API to the titles used on the legacy pages.
+var cfAPIReleaseTypes = map[int]string{
+	1: "Release",
+	2: "Beta",
+	3: "Alpha",
+}
+
+// nextDataProjectID returns the project ID from the embedded __NEXT_DATA__ of the redesigned site.
+// Returns false if the page does not contain that data.
+func nextDataProjectID(root *xmlpath.Node) (uint64, bool) {
+	nextData, ok := pathCache.String(root, "//script[@id='__NEXT_DATA__']")
+	if !ok {
+		return 0, false
+	}
+	var data cfNextData
+	err := json.Unmarshal([]byte(nextData), &data)
+	if err != nil || data.Props.PageProps.Project.ID == 0 {
+		return 0, false
+	}
+	return data.Props.PageProps.Project.ID, true
+}
+
+// isAPIBackedFilesPage returns true if the files listing of this page is loaded from the API,
+// i.e. there are no server-rendered file rows but the embedded page data is present.
+func isAPIBackedFilesPage(root *xmlpath.Node) (uint64, bool) {
+	if _, ok := pathCache.Node(root, "//tr[contains(@class, 'project-file-list-item')]"); ok {
+		return 0, false
+	}
+	return nextDataProjectID(root)
+}
+
+// parseCFFilesAPI loads the files of the given project from the files API.
+// All pages are loaded sequentially, unless CFOptionFilesNoPagination, Fetcher.MaxFilesPages or the cutoff of FetchCurseForgeSince is set.
+func parseCFFilesAPI(fetcher *Fetcher, results *CurseForge, documentURL *url.URL, projectID uint64, options CurseForgeOptions) error {
+	results.ProjectID = projectID
+
+	var pageIndex uint64
+	for {
+		apiURL := &url.URL{
+			Scheme:   documentURL.Scheme,
+			Host:     documentURL.Host,
+			Path:     fmt.Sprintf("/api/v1/mods/%d/files", projectID),
+			RawQuery: fmt.Sprintf("pageIndex=%d&pageSize=%d&sort=dateCreated&sortDescending=true&removeAlphas=false", pageIndex, cfAPIFilesPageSize),
+		}
+
+		resp, err := fetcher.FetchPage(apiURL.String())
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return &FetchError{
+				URL:        apiURL.String(),
+				StatusCode: resp.StatusCode,
+				Err:        fmt.Errorf("unexpected status %s", resp.Status),
+			}
+		}
+
+		pageStart := len(results.Downloads)
+		totalCount, err := parseCFFilesAPIResponse(results, documentURL, projectID, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return wrapError(err, fmt.Sprintf("error parsing files from API (page %d)", pageIndex))
+		}
+		results.TotalFiles = totalCount
+		results.TotalFilePages = (totalCount + cfAPIFilesPageSize - 1) / cfAPIFilesPageSize
+		if results.TotalFilePages == 0 {
+			results.TotalFilePages = 1
+		}
+
+		// Stop if no pagination is requested, all files are loaded or the page limit is reached
+		if options.Has(CFOptionFilesNoPagination) || (pageIndex+1)*cfAPIFilesPageSize >= totalCount {
+			return nil
+		}
+		if fetcher.MaxFilesPages > 0 && pageIndex+1 >= fetcher.MaxFilesPages {
+			return nil
+		}
+		if fetcher.reachedFilesSince(results.Downloads[pageStart:]) {
+			return nil
+		}
+		pageIndex++
+	}
+}
+
+// parseCFFilesAPIResponse parses a single response of the files API and appends the files to results.Downloads.
+// Returns the total number of files reported by the API.
+// Files that cannot be parsed are skipped and their errors added to FileErrors,
+// unless no file of the response can be parsed at all, which is returned as error.
+func parseCFFilesAPIResponse(results *CurseForge, documentURL *url.URL, projectID uint64, r io.Reader) (uint64, error) {
+	var response cfAPIFilesResponse
+	err := json.NewDecoder(r).Decode(&response)
+	if err != nil {
+		return 0, fmt.Errorf("error decoding json: %s", err.Error())
+	}
+
+	// File pages live next to the files listing: /<game>/<category>/<slug>/files/<id>
+	projectPath := documentURL.Path
+	if idx := strings.Index(projectPath, "/files"); idx >= 0 {
+		projectPath = projectPath[:idx]
+	}
+
+	var parsed int
+	var rowErrors []error
+	for _, data := range response.Data {
+		file := File{}
+
+		file.ID = data.ID
+
+		file.Name = data.DisplayName
+		if file.Name == "" {
+			file.Name = data.FileName
+		}
+
+		file.URL = &url.URL{
+			Scheme: documentURL.Scheme,
+			Host:   documentURL.Host,
+			Path:   path.Join(projectPath, "files", strconv.FormatUint(data.ID, 10)),
+		}
+
+		file.DirectURL = &url.URL{
+			Scheme: documentURL.Scheme,
+			Host:   documentURL.Host,
+			Path:   fmt.Sprintf("/api/v1/mods/%d/files/%d/download", projectID, data.ID),
+		}
+
+		file.ReleaseType = cfAPIReleaseTypes[data.ReleaseType]
+		file.Release = ParseReleaseType(file.ReleaseType)
+
+		// The game versions also list the mod loaders
+		for _, version := range data.GameVersions {
+			if isModLoader(version) {
+				file.ModLoaders = append(file.ModLoaders, version)
+			} else if file.GameVersion == "" {
+				file.GameVersion = version
+			}
+		}
+
+		file.Downloads = data.TotalDownloads
+
+		file.Date, err = time.Parse(time.RFC3339, data.DateCreated)
+		if err != nil {
+			rowErrors = append(rowErrors, newParseError(documentURL, "File/Date", "dateCreated", err))
+			continue
+		}
+		file.Date = file.Date.UTC()
+
+		file.SizeInfo = formatFileSize(data.FileLength)
+		file.SizeBytes = data.FileLength
+
+		file.HasAdditionalFiles = data.AdditionalFilesCount > 0
+		file.AdditionalFileCount = data.AdditionalFilesCount
+
+		results.Downloads = append(results.Downloads, file)
+		parsed++
+	}
+
+	// Most likely a format change instead of some odd files
+	if parsed == 0 && len(rowErrors) > 0 {
+		return 0, rowErrors[0]
+	}
+	results.FileErrors = append(results.FileErrors, rowErrors...)
+	return response.Pagination.TotalCount, nil
+}
+
+// formatFileSize formats a byte count as size info, e.g. "1.24 MiB".
+// The binary units are spelled out, so ParseFileSize reads the value back unambiguously.
+func formatFileSize(size uint64) string {
+	switch {
+	case size >= 1024*1024*1024:
+		return fmt.Sprintf("%.2f GiB", float64(size)/(1024*1024*1024))
+	case size >= 1024*1024:
+		return fmt.Sprintf("%.2f MiB", float64(size)/(1024*1024))
+	case size >= 1024:
+		return fmt.Sprintf("%.2f KiB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
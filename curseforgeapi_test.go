@@ -0,0 +1,157 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsAPIBackedFilesPage(t *testing.T) {
+	root := parseTestdata(t, "curseforge_files_nextdata.html")
+
+	projectID, ok := isAPIBackedFilesPage(root)
+	if !ok {
+		t.Fatal("Expected page to be detected as API-backed")
+	}
+	if projectID != 238222 {
+		t.Errorf("Expected project ID %d, got %d", 238222, projectID)
+	}
+}
+
+func TestParseCFFilesAPIResponse(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_files_api.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	documentURL, err := url.Parse("https://www.curseforge.com/minecraft/mc-mods/jei/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	totalCount, err := parseCFFilesAPIResponse(results, documentURL, 238222, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if totalCount != 2 {
+		t.Errorf("Expected total count %d, got %d", 2, totalCount)
+	}
+	if len(results.Downloads) != 2 {
+		t.Fatalf("Expected %d files, got %d", 2, len(results.Downloads))
+	}
+
+	file := results.Downloads[0]
+	if file.Name != "jei-1.20.1-forge-15.2.0.27.jar" {
+		t.Errorf("Expected name '%s', got '%s'", "jei-1.20.1-forge-15.2.0.27.jar", file.Name)
+	}
+	if file.URL.String() != "https://www.curseforge.com/minecraft/mc-mods/jei/files/4712868" {
+		t.Errorf("Expected URL '%s', got '%s'", "https://www.curseforge.com/minecraft/mc-mods/jei/files/4712868", file.URL)
+	}
+	if file.DirectURL.String() != "https://www.curseforge.com/api/v1/mods/238222/files/4712868/download" {
+		t.Errorf("Expected DirectURL '%s', got '%s'", "https://www.curseforge.com/api/v1/mods/238222/files/4712868/download", file.DirectURL)
+	}
+	if file.ReleaseType != "Release" {
+		t.Errorf("Expected release type '%s', got '%s'", "Release", file.ReleaseType)
+	}
+	if file.GameVersion != "1.20.1" {
+		t.Errorf("Expected game version '%s', got '%s'", "1.20.1", file.GameVersion)
+	}
+	if len(file.ModLoaders) != 1 || file.ModLoaders[0] != "Forge" {
+		t.Errorf("Expected mod loaders %v, got %v", []string{"Forge"}, file.ModLoaders)
+	}
+	if file.Downloads != 1923840 {
+		t.Errorf("Expected %d downloads, got %d", 1923840, file.Downloads)
+	}
+	if !file.Date.Equal(time.Date(2023, 8, 24, 16, 52, 11, 350000000, time.UTC)) {
+		t.Errorf("Unexpected date '%s'", file.Date)
+	}
+	if file.SizeInfo != "1.24 MiB" {
+		t.Errorf("Expected size info '%s', got '%s'", "1.24 MiB", file.SizeInfo)
+	}
+
+	file = results.Downloads[1]
+	if file.ReleaseType != "Beta" {
+		t.Errorf("Expected release type '%s', got '%s'", "Beta", file.ReleaseType)
+	}
+	if !file.HasAdditionalFiles || file.AdditionalFileCount != 2 {
+		t.Errorf("Expected 2 additional files, got %d", file.AdditionalFileCount)
+	}
+}
+
+func TestParseCFFilesAPIResponseBrokenFile(t *testing.T) {
+	documentURL, err := url.Parse("https://www.curseforge.com/minecraft/mc-mods/jei/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second file has a broken date
+	response := `{"data":[
+{"id":1,"fileName":"a.jar","dateCreated":"2023-08-24T16:52:11.35Z","releaseType":1},
+{"id":2,"fileName":"b.jar","dateCreated":"yesterday","releaseType":1}
+],"pagination":{"totalCount":2}}`
+
+	results := new(CurseForge)
+	_, err = parseCFFilesAPIResponse(results, documentURL, 238222, strings.NewReader(response))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Downloads) != 1 || results.Downloads[0].Name != "a.jar" {
+		t.Errorf("Expected only file '%s', got %+v", "a.jar", results.Downloads)
+	}
+	if len(results.FileErrors) != 1 {
+		t.Fatalf("Expected %d file error, got %v", 1, results.FileErrors)
+	}
+	if parseErr, ok := results.FileErrors[0].(*ParseError); !ok || parseErr.Field != "File/Date" {
+		t.Errorf("Expected a ParseError for 'File/Date', got %v", results.FileErrors[0])
+	}
+
+	// Not a single file can be parsed
+	results = new(CurseForge)
+	_, err = parseCFFilesAPIResponse(results, documentURL, 238222, strings.NewReader(`{"data":[{"id":2,"dateCreated":"yesterday"}]}`))
+	if _, ok := err.(*ParseError); !ok {
+		t.Errorf("Expected a ParseError, got %v", err)
+	}
+}
+
+func TestFormatFileSize(t *testing.T) {
+	testValues := map[uint64]string{
+		512:        "512 bytes",
+		1536:       "1.50 KiB",
+		1300434:    "1.24 MiB",
+		3221225472: "3.00 GiB",
+	}
+	for size, expected := range testValues {
+		formatted := formatFileSize(size)
+		if formatted != expected {
+			t.Errorf("Expected '%s' for %d, got '%s'", expected, size, formatted)
+		}
+		// Read back with the rounding of the two decimals
+		parsed, err := ParseFileSize(formatted)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", formatted, err.Error())
+		} else if parsed < size-size/200 || parsed > size+size/200 {
+			t.Errorf("Expected about %d for '%s', got %d", size, formatted, parsed)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// BrowseCurseForge parses a page of the project listing of a game, e.g. gameURL
+// https://minecraft.curseforge.com/mc-mods lists all Minecraft mods. page starts at 1.
+// Returns the projects on the page and whether there is a next page, to enumerate all projects
+// without knowing their URLs. The URL of a summary can be passed to FetchCurseForge.
+//
+// The request is sent using DefaultFetcher.
+func BrowseCurseForge(gameURL *url.URL, page int) ([]ProjectSummary, bool, error) {
+	return DefaultFetcher.BrowseCurseForge(gameURL, page)
+}
+
+// BrowseCurseForge parses a page of the project listing of a game, see BrowseCurseForge() for details.
+// The request is sent using this Fetcher.
+func (f *Fetcher) BrowseCurseForge(gameURL *url.URL, page int) ([]ProjectSummary, bool, error) {
+	browseURL := cfBrowseURL(gameURL, page)
+
+	resp, err := f.FetchPage(browseURL.String())
+	if err != nil {
+		return nil, false, err
+	}
+	err = checkPageStatus(browseURL.String(), resp)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	return parseCurseForgeBrowse(f, browseURL, resp.Body)
+}
+
+// ParseCurseForgeBrowse parses a page of a project listing of CurseForge read from r.
+// documentURL is required for resolving relative links; its page parameter is the current page.
+// Returns whether there is a next page, according to the pagination of the listing.
+func ParseCurseForgeBrowse(documentURL *url.URL, r io.Reader) ([]ProjectSummary, bool, error) {
+	return parseCurseForgeBrowse(DefaultFetcher, documentURL, r)
+}
+
+// parseCurseForgeBrowse implements ParseCurseForgeBrowse, reporting to the DebugHook of the given fetcher.
+func parseCurseForgeBrowse(fetcher *Fetcher, documentURL *url.URL, r io.Reader) ([]ProjectSummary, bool, error) {
+	root, err := xmlpath.ParseHTML(r)
+	if err != nil {
+		return nil, false, fmt.Errorf("error parsing xml/http: %s", err.Error())
+	}
+
+	projects, err := parseCFBrowseResults(newPageLookup(fetcher, documentURL), root)
+	if err != nil {
+		return nil, false, err
+	}
+
+	pageCount, err := parseCFPageCount(root)
+	if err != nil {
+		return nil, false, err
+	}
+	return projects, uint64(cfBrowsePage(documentURL)) < pageCount, nil
+}
+
+// cfBrowseURL returns the URL of the given page of a project listing,
+// e.g. https://minecraft.curseforge.com/mc-mods?page=2
+// The first page is requested without page parameter, pages below 1 are treated as the first page.
+func cfBrowseURL(gameURL *url.URL, page int) *url.URL {
+	browseURL := *gameURL
+	query := browseURL.Query()
+	if page > 1 {
+		query.Set("page", strconv.Itoa(page))
+	} else {
+		query.Del("page")
+	}
+	browseURL.RawQuery = query.Encode()
+	return &browseURL
+}
+
+// cfBrowsePage returns the page number of a listing URL, 1 if it has no (valid) page parameter.
+func cfBrowsePage(documentURL *url.URL) int {
+	page, err := strconv.Atoi(documentURL.Query().Get("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
+
+func parseCFBrowseResults(lookup *pageLookup, root *xmlpath.Node) ([]ProjectSummary, error) {
+	return parseCFProjectSummaries(lookup, root, "//ul[contains(@class, 'project-listing')]/li[@class='project-list-item']")
+}
+
+// parseCFProjectSummaries parses the project rows matched by rowsXPath,
+// used by the project listings and the widgets of the game homepages.
+func parseCFProjectSummaries(lookup *pageLookup, root *xmlpath.Node, rowsXPath string) ([]ProjectSummary, error) {
+	var ok bool
+	var err error
+	var parseString string
+
+	var projects []ProjectSummary
+
+	rows := pathCache.Iter(root, rowsXPath)
+	for rows.Next() {
+		rowTag := rows.Node()
+
+		project := ProjectSummary{}
+
+		project.Title, err = lookup.RequiredString(rowTag, "ProjectSummary/Title", ".//div[@class='name-wrapper overflow-tip']/a")
+		if err != nil {
+			return nil, err
+		}
+
+		project.URL, err = lookup.RequiredURL(rowTag, "ProjectSummary/URL", ".//div[@class='name-wrapper overflow-tip']/a/@href")
+		if err != nil {
+			return nil, err
+		}
+
+		// can be empty
+		project.Summary, ok = lookup.String(rowTag, "ProjectSummary/Summary", ".//div[@class='description']/p")
+
+		// can be non-present, e.g. for deleted accounts
+		project.Author.Name, ok = lookup.String(rowTag, "ProjectSummary/Author/Name", ".//span[@class='byline']/a")
+		project.Author.URL, _ = lookup.URL(rowTag, "ProjectSummary/Author/URL", ".//span[@class='byline']/a/@href")
+
+		// Format of this value: "123,456 Downloads" -> get the first 'field'
+		downloadsPath := ".//p[@class='e-download-count']"
+		parseString, ok = lookup.String(rowTag, "ProjectSummary/Downloads", downloadsPath)
+		if !ok || len(strings.Fields(parseString)) == 0 {
+			return nil, newParseError(lookup.url, "ProjectSummary/Downloads", downloadsPath, nil)
+		}
+		project.Downloads, err = ParseCountLocale(strings.Fields(parseString)[0], lookup.sep)
+		if err != nil {
+			return nil, newParseError(lookup.url, "ProjectSummary/Downloads", downloadsPath, err)
+		}
+
+		project.Updated, err = lookup.RequiredUnixTimestamp(rowTag, "ProjectSummary/Updated", ".//p[@class='e-update-date']/abbr/@data-epoch")
+		if err != nil {
+			return nil, err
+		}
+
+		if lookup.normalize {
+			project.Title = NormalizeString(project.Title)
+			project.Summary = NormalizeString(project.Summary)
+			project.Author.Name = NormalizeString(project.Author.Name)
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCFBrowseURL(t *testing.T) {
+	gameURL, err := url.Parse("https://minecraft.curseforge.com/mc-mods?filter-sort=popularity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[int]string{
+		0: "https://minecraft.curseforge.com/mc-mods?filter-sort=popularity",
+		1: "https://minecraft.curseforge.com/mc-mods?filter-sort=popularity",
+		3: "https://minecraft.curseforge.com/mc-mods?filter-sort=popularity&page=3",
+	}
+	for page, expected := range tests {
+		browseURL := cfBrowseURL(gameURL, page)
+		if browseURL.String() != expected {
+			t.Errorf("Expected '%s' for page %d, got '%s'", expected, page, browseURL.String())
+		}
+		if page > 0 && cfBrowsePage(browseURL) != page {
+			t.Errorf("Expected page %d of '%s', got %d", page, browseURL.String(), cfBrowsePage(browseURL))
+		}
+	}
+	if gameURL.RawQuery != "filter-sort=popularity" {
+		t.Errorf("Expected the game URL to be unmodified, got '%s'", gameURL.String())
+	}
+}
+
+func TestBrowseCurseForge(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		http.ServeFile(w, r, "testdata/curseforge_browse_mods.html")
+	}))
+	defer server.Close()
+
+	gameURL, err := url.Parse(server.URL + "/mc-mods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(nil)
+
+	projects, hasNext, err := fetcher.BrowseCurseForge(gameURL, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasNext {
+		t.Error("Expected a next page after page 1 of 3")
+	}
+	if len(projects) != 2 {
+		t.Fatalf("Expected %d projects, got %d", 2, len(projects))
+	}
+
+	taam := projects[0]
+	if taam.Title != "TAAM" || taam.URL.String() != server.URL+"/projects/taam" {
+		t.Errorf("Unexpected project '%s' at '%s'", taam.Title, taam.URL)
+	}
+	if taam.Summary != "Tech & Accessory Mod: conveyors, machines and more." {
+		t.Errorf("Unexpected summary '%s'", taam.Summary)
+	}
+	if taam.Author.Name != "founderio" || taam.Author.URL.String() != server.URL+"/members/founderio" {
+		t.Errorf("Unexpected author '%s' at '%s'", taam.Author.Name, taam.Author.URL)
+	}
+	if taam.Downloads != 123456 {
+		t.Errorf("Expected %d downloads, got %d", 123456, taam.Downloads)
+	}
+	if !taam.Updated.Equal(time.Unix(1504044000, 0)) {
+		t.Errorf("Expected update date '%s', got '%s'", time.Unix(1504044000, 0), taam.Updated)
+	}
+
+	// Without summary & author
+	addons := projects[1]
+	if addons.Summary != "" || addons.Author.Name != "" || addons.Author.URL != nil {
+		t.Errorf("Expected no summary & author, got %+v", addons)
+	}
+	if addons.Downloads != 987 {
+		t.Errorf("Expected %d downloads, got %d", 987, addons.Downloads)
+	}
+
+	// The last page
+	_, hasNext, err = fetcher.BrowseCurseForge(gameURL, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNext {
+		t.Error("Expected no next page after page 3 of 3")
+	}
+
+	if len(requested) != 2 || requested[0] != "/mc-mods" || requested[1] != "/mc-mods?page=3" {
+		t.Errorf("Unexpected requests %v", requested)
+	}
+}
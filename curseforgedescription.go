@@ -0,0 +1,161 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// htmlInlineElements are the elements that do not separate words in the text version of a description or changelog.
+var htmlInlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "em": true, "font": true, "i": true,
+	"s": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true, "u": true,
+}
+
+// parseCFDescription fills DescriptionHTML and DescriptionText from the description container of the overview page.
+// An empty, missing or unreadable description leaves both values empty; it never fails the overview.
+func parseCFDescription(results *CurseForge, raw []byte) {
+	results.DescriptionHTML, results.DescriptionText = extractHTML(raw, "div", "project-description")
+}
+
+// extractHTML returns the markup inside of the first element with the given tag & class,
+// and its text without tags and with whitespace collapsed.
+// xmlpath does not give access to the markup of a node, so the markup of the element is located in the raw page
+// by tokenizing it, and only that markup is parsed with the HTML5 parser. It copes with inline scripts
+// and unclosed tags the same way a browser does. Comments are skipped.
+// Returns empty strings if there is no such element or its markup cannot be parsed.
+func extractHTML(raw []byte, tag string, class string) (string, string) {
+	inner, ok := findHTMLElementMarkup(raw, tag, class)
+	if !ok {
+		return "", ""
+	}
+	container := &html.Node{
+		Type:     html.ElementNode,
+		Data:     tag,
+		DataAtom: atom.Lookup([]byte(tag)),
+	}
+	nodes, err := html.ParseFragment(bytes.NewReader(inner), container)
+	if err != nil {
+		return "", ""
+	}
+
+	var htmlBuf bytes.Buffer
+	var textBuf strings.Builder
+	for _, n := range nodes {
+		if n.Type == html.CommentNode {
+			continue
+		}
+		removeHTMLComments(n)
+		if err := html.Render(&htmlBuf, n); err != nil {
+			return "", ""
+		}
+		writeHTMLText(&textBuf, n)
+	}
+	return strings.TrimSpace(htmlBuf.String()), strings.Join(strings.Fields(textBuf.String()), " ")
+}
+
+// findHTMLElementMarkup returns the raw markup inside of the first element with the given tag & class.
+// The end of the element is found by counting the nested elements with the same tag.
+// An element that is not closed extends to the end of the page.
+func findHTMLElementMarkup(raw []byte, tag string, class string) ([]byte, bool) {
+	z := html.NewTokenizer(bytes.NewReader(raw))
+	// Offset of the current token in raw
+	var offset int
+	// Start of the markup inside of the element, and the depth of elements with the same tag, once found
+	var start int
+	var depth int
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if depth > 0 && z.Err() == io.EOF {
+				return raw[start:], true
+			}
+			return nil, false
+		}
+		length := len(z.Raw())
+
+		switch tt {
+		case html.StartTagToken:
+			tok := z.Token()
+			if depth > 0 && tok.Data == tag {
+				depth++
+			} else if depth == 0 && tok.Data == tag && hasHTMLClass(tok.Attr, class) {
+				depth = 1
+				start = offset + length
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			if depth > 0 && string(name) == tag {
+				depth--
+				if depth == 0 {
+					return raw[start:offset], true
+				}
+			}
+		}
+		offset += length
+	}
+}
+
+// removeHTMLComments removes all comments below n.
+func removeHTMLComments(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode {
+			n.RemoveChild(c)
+		} else {
+			removeHTMLComments(c)
+		}
+		c = next
+	}
+}
+
+// hasHTMLClass returns true if class is one of the classes in the class attribute.
+func hasHTMLClass(attrs []html.Attribute, class string) bool {
+	for _, attr := range attrs {
+		if attr.Key == "class" {
+			return containsString(strings.Fields(attr.Val), class)
+		}
+	}
+	return false
+}
+
+// writeHTMLText writes the text of n and its children, without the contents of script & style elements.
+// Elements that are not inline are surrounded by spaces.
+func writeHTMLText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(n.Data)
+	case html.ElementNode:
+		if n.Data == "script" || n.Data == "style" {
+			return
+		}
+		if !htmlInlineElements[n.Data] {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			writeHTMLText(b, c)
+		}
+		if !htmlInlineElements[n.Data] {
+			b.WriteByte(' ')
+		}
+	}
+}
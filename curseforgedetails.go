@@ -0,0 +1,182 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// fetchCFFileDetails fetches the detail page (File.URL) of every given file and fills in the detail values
+// with CFOptionFilesFetchDetails, and the additional files with CFOptionFilesFetchAdditional.
+// Files without an URL are skipped, as are files without additional files if the details are not requested.
+// gameType is the game of the project, as resolved by the header.
+// Returns the errors of the additional file rows that could not be parsed, see parseCFAdditionalFiles.
+func fetchCFFileDetails(fetcher *Fetcher, files []File, gameType GameType, options CurseForgeOptions) ([]error, error) {
+	fetchDetails := options.Has(CFOptionFilesFetchDetails)
+	fetchAdditional := options.Has(CFOptionFilesFetchAdditional)
+
+	var rowErrors []error
+	for idx := range files {
+		file := &files[idx]
+		if file.URL == nil {
+			continue
+		}
+		if !fetchDetails && !file.HasAdditionalFiles {
+			continue
+		}
+
+		resp, err := fetcher.FetchPage(file.URL.String())
+		if err != nil {
+			return rowErrors, err
+		}
+		err = checkPageStatus(file.URL.String(), resp)
+		if err != nil {
+			return rowErrors, err
+		}
+
+		raw, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return rowErrors, fmt.Errorf("error reading file details '%s': %s", file.URL.String(), err.Error())
+		}
+
+		root, err := xmlpath.ParseHTML(bytes.NewReader(raw))
+		if err != nil {
+			return rowErrors, fmt.Errorf("error parsing xml/http for file details '%s': %s", file.URL.String(), err.Error())
+		}
+
+		lookup := newPageLookup(fetcher, file.URL)
+		if fetchDetails {
+			err = parseCFFileDetails(file, lookup, root)
+			if err != nil {
+				return rowErrors, wrapError(err, fmt.Sprintf("error parsing file details '%s'", file.URL.String()))
+			}
+
+			parseCFFileChangelog(file, raw)
+		}
+
+		if fetchAdditional && file.HasAdditionalFiles {
+			rowErrors = append(rowErrors, parseCFAdditionalFiles(file, lookup, gameType, root)...)
+		}
+	}
+	return rowErrors, nil
+}
+
+// parseCFAdditionalFiles parses the additional files listed on the detail page of a file into file.AdditionalFiles.
+// The more-files tag of the files listing links to this list.
+// The rows have the same structure as the rows of the files listing.
+// Rows that cannot be parsed are skipped, their errors are returned.
+func parseCFAdditionalFiles(file *File, lookup *pageLookup, gameType GameType, root *xmlpath.Node) []error {
+	if gameType == GameTypeUnknown {
+		gameType = DetectGameType(lookup.url)
+	}
+	var rowErrors []error
+	rows := pathCache.Iter(root, "//div[@class='details-additional-files']//tr[contains(@class, 'project-file-list-item')]")
+	for rows.Next() {
+		additional, err := parseCFFileRow(rows.Node(), lookup, gameType)
+		if err != nil {
+			rowErrors = append(rowErrors, err)
+			continue
+		}
+		file.AdditionalFiles = append(file.AdditionalFiles, additional)
+	}
+	return rowErrors
+}
+
+// parseCFFileChangelog fills Changelog and ChangelogText from the changelog of the detail page of a file.
+// A missing or unreadable changelog leaves both values empty; it never fails the file details.
+func parseCFFileChangelog(file *File, raw []byte) {
+	file.Changelog, file.ChangelogText = extractHTML(raw, "div", "logbox")
+}
+
+// parseCFFileDetails parses the detail page of a single file into file.
+func parseCFFileDetails(file *File, lookup *pageLookup, root *xmlpath.Node) error {
+	var err error
+
+	var details *xmlpath.Node
+	details, err = lookup.RequiredNode(root, "details-info section", "//div[@class='details-info']")
+	if err != nil {
+		return err
+	}
+
+	/*
+		Fingerprint & Author
+	*/
+
+	// MD5
+	// can be non-present
+	file.MD5, _ = lookup.String(details, "File/MD5", "ul/li[div[@class='info-label']='MD5']/div[contains(@class, 'info-data')]")
+	file.MD5 = strings.ToLower(file.MD5)
+
+	// Uploaded By
+	// can be non-present
+	file.UploadedBy, _ = lookup.String(details, "File/UploadedBy", "ul/li[div[@class='info-label']='Uploaded by']/div[@class='info-data']")
+
+	/*
+		Dates
+	*/
+
+	// Upload Date
+	// can be non-present
+	uploadDate, err := lookup.UnixTimestamp(details, "File/UploadDate", "ul/li[div[@class='info-label']='Uploaded']/div[@class='info-data']/abbr/@data-epoch")
+	if err == nil {
+		file.UploadDate = uploadDate
+		if !file.hasDate() {
+			file.Date = uploadDate
+		}
+	}
+
+	// Release Date
+	// Only listed for early-access/delayed releases, where it differs from the upload date
+	releaseDate, err := lookup.UnixTimestamp(details, "File/ReleaseDate", "ul/li[div[@class='info-label']='Released']/div[@class='info-data']/abbr/@data-epoch")
+	if err == nil {
+		file.Date = releaseDate
+	}
+	file.ReleaseDate = file.Date
+
+	/*
+		Versions
+	*/
+
+	// Required Java Version
+	// Listed alongside the supported game versions as "Java 8"
+	// can be empty / non-present
+	versions := pathCache.Iter(details, "ul[@class='details-versions']/li")
+	for versions.Next() {
+		version := strings.TrimSpace(versions.Node().String())
+		if strings.HasPrefix(version, "Java ") {
+			file.RequiredJavaVersion = strings.TrimPrefix(version, "Java ")
+		}
+	}
+
+	// Incompatible Versions
+	// can be empty / non-present
+	incompatible := pathCache.Iter(details, "ul[@class='details-incompatible-versions']/li")
+	for incompatible.Next() {
+		version := strings.TrimSpace(incompatible.Node().String())
+		if version != "" {
+			file.IncompatibleVersions = append(file.IncompatibleVersions, version)
+		}
+	}
+
+	return nil
+}
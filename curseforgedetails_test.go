@@ -0,0 +1,233 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCFFileDetails(t *testing.T) {
+	root := parseTestdata(t, "curseforge_file_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files/2444195")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := File{URL: documentURL}
+	err = parseCFFileDetails(&file, newPageLookup(nil, documentURL), root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.MD5 != "7e6d1c86ba0c2bd5b1f9b5e77e1e0aa4" {
+		t.Errorf("Expected MD5 '%s', got '%s'", "7e6d1c86ba0c2bd5b1f9b5e77e1e0aa4", file.MD5)
+	}
+	if file.UploadedBy != "founderio" {
+		t.Errorf("Expected uploader '%s', got '%s'", "founderio", file.UploadedBy)
+	}
+	if file.RequiredJavaVersion != "8" {
+		t.Errorf("Expected required Java version '%s', got '%s'", "8", file.RequiredJavaVersion)
+	}
+	if len(file.IncompatibleVersions) != 1 || file.IncompatibleVersions[0] != "1.12.2" {
+		t.Errorf("Expected incompatible versions %v, got %v", []string{"1.12.2"}, file.IncompatibleVersions)
+	}
+}
+
+func TestParseCFFileDetailsDates(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files/2444195")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded := time.Unix(1503439200, 0).UTC()
+	released := time.Unix(1504044000, 0).UTC()
+
+	// No separate release date
+	file := File{URL: documentURL, Date: uploaded}
+	err = parseCFFileDetails(&file, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_file_taam.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.UploadDate.Equal(uploaded) {
+		t.Errorf("Expected upload date %v, got %v", uploaded, file.UploadDate)
+	}
+	if !file.ReleaseDate.Equal(file.Date) || !file.Date.Equal(uploaded) {
+		t.Errorf("Expected release date and date %v, got %v and %v", uploaded, file.ReleaseDate, file.Date)
+	}
+
+	// Early access release
+	file = File{URL: documentURL, Date: uploaded}
+	err = parseCFFileDetails(&file, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_file_taam_early_access.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !file.UploadDate.Equal(uploaded) {
+		t.Errorf("Expected upload date %v, got %v", uploaded, file.UploadDate)
+	}
+	if !file.ReleaseDate.Equal(released) || !file.Date.Equal(released) {
+		t.Errorf("Expected release date and date %v, got %v and %v", released, file.ReleaseDate, file.Date)
+	}
+}
+
+func TestParseCFFileChangelog(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_file_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := File{}
+	parseCFFileChangelog(&file, raw)
+
+	expectedHTML := "<p>Fixed conveyor belts dropping items.</p>\n<ul><li>Updated to 1.12.1</li><li>New &amp; improved machines</li></ul>"
+	if file.Changelog != expectedHTML {
+		t.Errorf("Expected changelog '%s', got '%s'", expectedHTML, file.Changelog)
+	}
+	expectedText := "Fixed conveyor belts dropping items. Updated to 1.12.1 New & improved machines"
+	if file.ChangelogText != expectedText {
+		t.Errorf("Expected changelog text '%s', got '%s'", expectedText, file.ChangelogText)
+	}
+
+	// No changelog leaves both values empty
+	file = File{}
+	parseCFFileChangelog(&file, []byte(`<html><body><div class="details-info"></div></body></html>`))
+	if file.Changelog != "" || file.ChangelogText != "" {
+		t.Errorf("Expected no changelog, got '%s' / '%s'", file.Changelog, file.ChangelogText)
+	}
+
+	// Scripts on the page do not break the changelog
+	file = File{}
+	parseCFFileChangelog(&file, []byte(`<html><head><script>if (a < b) {}</script></head><body><div class="logbox"><p>Fixed</div></body></html>`))
+	if file.Changelog != "<p>Fixed</p>" || file.ChangelogText != "Fixed" {
+		t.Errorf("Expected changelog '%s', got '%s' / '%s'", "<p>Fixed</p>", file.Changelog, file.ChangelogText)
+	}
+}
+
+func TestParseCFAdditionalFiles(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files/2398011")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := File{URL: documentURL, HasAdditionalFiles: true}
+	rowErrors := parseCFAdditionalFiles(&file, newPageLookup(nil, documentURL), GameTypeUnknown, parseTestdata(t, "curseforge_file_taam_additional.html"))
+	if rowErrors != nil {
+		t.Fatal(rowErrors)
+	}
+
+	expected := []string{"TAAM-1.11.2-0.6.2-api.jar", "TAAM-1.11.2-0.6.2-deobf.jar"}
+	if len(file.AdditionalFiles) != len(expected) {
+		t.Fatalf("Expected %d additional files, got %d", len(expected), len(file.AdditionalFiles))
+	}
+	for idx, name := range expected {
+		if file.AdditionalFiles[idx].Name != name {
+			t.Errorf("Expected additional file '%s', got '%s'", name, file.AdditionalFiles[idx].Name)
+		}
+	}
+	if file.AdditionalFiles[0].ID != 2398012 || file.AdditionalFiles[0].GameVersion != "1.11.2" {
+		t.Errorf("Unexpected additional file %v", file.AdditionalFiles[0])
+	}
+
+	// The detail page of a file without additional files
+	file = File{URL: documentURL}
+	rowErrors = parseCFAdditionalFiles(&file, newPageLookup(nil, documentURL), GameTypeUnknown, parseTestdata(t, "curseforge_file_taam.html"))
+	if rowErrors != nil {
+		t.Fatal(rowErrors)
+	}
+	if file.AdditionalFiles != nil {
+		t.Errorf("Expected no additional files, got %v", file.AdditionalFiles)
+	}
+}
+
+func TestFetchCFAdditionalFiles(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		http.ServeFile(w, r, "testdata/curseforge_file_taam_additional.html")
+	}))
+	defer server.Close()
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFiles(NewFetcher(nil), results, newPageLookup(nil, filesURL), parseTestdata(t, "curseforge_files_taam.html"), CFOptionFilesNoPagination|CFOptionFilesFetchAdditional)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the file with the more-files tag is fetched
+	if len(requested) != 1 || requested[0] != "/projects/taam/files/2398011" {
+		t.Errorf("Unexpected requests %v", requested)
+	}
+	for _, file := range results.Downloads {
+		if file.HasAdditionalFiles && len(file.AdditionalFiles) != 2 {
+			t.Errorf("Expected 2 additional files for '%s', got %d", file.Name, len(file.AdditionalFiles))
+		}
+		if !file.HasAdditionalFiles && file.AdditionalFiles != nil {
+			t.Errorf("Expected no additional files for '%s', got %d", file.Name, len(file.AdditionalFiles))
+		}
+		// The details were not requested
+		if file.MD5 != "" {
+			t.Errorf("Expected no details for '%s'", file.Name)
+		}
+	}
+}
+
+func TestFetchCFAdditionalFilesBrokenRow(t *testing.T) {
+	raw, err := ioutil.ReadFile("testdata/curseforge_file_taam_additional.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first additional file has no download button
+	page := strings.Replace(string(raw), `<div class="project-file-download-button"><a class="button tip fa-icon-download icon-only" href="/projects/taam/files/2398012/download"></a></div>`, "", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFiles(NewFetcher(nil), results, newPageLookup(nil, filesURL), parseTestdata(t, "curseforge_files_taam.html"), CFOptionFilesNoPagination|CFOptionFilesFetchAdditional)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The broken row is skipped, the other files are kept
+	for _, file := range results.Downloads {
+		if file.HasAdditionalFiles && (len(file.AdditionalFiles) != 1 || file.AdditionalFiles[0].Name != "TAAM-1.11.2-0.6.2-deobf.jar") {
+			t.Errorf("Unexpected additional files for '%s': %v", file.Name, file.AdditionalFiles)
+		}
+	}
+	if len(results.FileErrors) != 1 {
+		t.Fatalf("Expected 1 file error, got %v", results.FileErrors)
+	}
+	if parseErr, ok := results.FileErrors[0].(*ParseError); !ok || parseErr.Field != "File/DirectURL" {
+		t.Errorf("Unexpected file error %v", results.FileErrors[0])
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// ParsedDocument is a single page from curseforge.com, parsed once to a document.
+// The values of several sections can be extracted from the same document,
+// e.g. the header and the overview, without parsing the HTML again.
+//
+// Subsequent requests (e.g. further files pages) are sent using DefaultFetcher.
+// Pass CFOptionFilesNoPagination to avoid any network access.
+type ParsedDocument struct {
+	// The URL of the page, used for resolving relative links
+	URL *url.URL
+
+	fetcher *Fetcher
+	root    *xmlpath.Node
+	// The unparsed page, required for the description markup
+	raw []byte
+}
+
+// ParseDocument reads & parses a page from curseforge.com read from r, e.g. a local file or a response body.
+// documentURL is still required for resolving relative links.
+//
+// If the page is the not-found page of CurseForge, an error wrapping ErrProjectNotFound is returned.
+func ParseDocument(documentURL *url.URL, r io.Reader) (*ParsedDocument, error) {
+	return newParsedDocument(DefaultFetcher, documentURL, r)
+}
+
+// newParsedDocument implements ParseDocument, sending subsequent requests using the given fetcher.
+func newParsedDocument(fetcher *Fetcher, documentURL *url.URL, r io.Reader) (*ParsedDocument, error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading page: %s", err.Error())
+	}
+	root, err := xmlpath.ParseHTML(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing xml/http: %s", err.Error())
+	}
+	if isCFNotFoundPage(root) {
+		return nil, wrapError(ErrProjectNotFound, fmt.Sprintf("error parsing '%s'", documentURL.String()))
+	}
+	return &ParsedDocument{
+		URL:     documentURL,
+		fetcher: fetcher,
+		root:    root,
+		raw:     raw,
+	}, nil
+}
+
+// Root returns the parsed document, e.g. for custom XPath queries.
+func (d *ParsedDocument) Root() *xmlpath.Node {
+	return d.root
+}
+
+// ParseHeader parses the header values, which are present on every section page, into results.
+func (d *ParsedDocument) ParseHeader(results *CurseForge, options CurseForgeOptions) error {
+	err := parseCFHeader(results, newPageLookup(d.fetcher, d.URL), d.root, options)
+	if err != nil {
+		return wrapError(err, "error processing CF header")
+	}
+	return nil
+}
+
+// ParseOverview parses the values of the overview page into results, see CFSectionOverview.
+func (d *ParsedDocument) ParseOverview(results *CurseForge, options CurseForgeOptions) error {
+	return d.ParseSection(results, CFSectionOverview, options)
+}
+
+// ParseFiles parses the files of the files page into results, see CFSectionFiles.
+func (d *ParsedDocument) ParseFiles(results *CurseForge, options CurseForgeOptions) error {
+	return d.ParseSection(results, CFSectionFiles, options)
+}
+
+// ParseSection parses the values of a SINGLE section into results, without the header.
+func (d *ParsedDocument) ParseSection(results *CurseForge, section CurseForgeSections, options CurseForgeOptions) error {
+	return results.parseCurseForgeSection(d.fetcher, d.URL, d.root, d.raw, section, options)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParsedDocument(t *testing.T) {
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := ParseDocument(documentURL, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Header and overview from the same document
+	results := new(CurseForge)
+	err = doc.ParseHeader(results, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if results.DescriptionHTML != "" {
+		t.Errorf("Expected no description before parsing the overview, got '%s'", results.DescriptionHTML)
+	}
+	err = doc.ParseOverview(results, CFOptionOverviewRecentFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.DescriptionHTML == "" || len(results.Downloads) == 0 {
+		t.Errorf("Expected description & recent files from the overview, got '%s' / %d files", results.DescriptionHTML, len(results.Downloads))
+	}
+
+	// The document can be parsed into several results
+	other := new(CurseForge)
+	err = doc.ParseOverview(other, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.DescriptionHTML != results.DescriptionHTML || other.Title != "" {
+		t.Errorf("Expected the same description without the header, got title '%s'", other.Title)
+	}
+}
+
+func TestParseDocumentNotFound(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/does-not-exist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open("testdata/curseforge_not_found.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = ParseDocument(documentURL, f)
+	if !isError(err, ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound, got %v", err)
+	}
+}
+
+func TestParseDocumentTitle404(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/404-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A project title starting with "404" is not the not-found page
+	page := `<html><head><title>404 Challenge - Mods - Minecraft - CurseForge</title></head><body></body></html>`
+	_, err = ParseDocument(documentURL, strings.NewReader(page))
+	if err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
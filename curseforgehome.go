@@ -0,0 +1,81 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// XPaths of the widgets of a game homepage, see ParseGameHomepage.
+const (
+	cfHomepagePopular         = "//div[@id='popular-projects']"
+	cfHomepageRecentlyUpdated = "//div[@id='recently-updated']"
+	cfHomepageNew             = "//div[@id='new-projects']"
+)
+
+// ParseGameHomepage parses the curated project lists of a game homepage of CurseForge,
+// e.g. https://minecraft.curseforge.com/ - the popular, recently updated and new projects.
+// The URL of a summary can be passed to FetchCurseForge.
+func ParseGameHomepage(resp *http.Response) (*GameHomepage, error) {
+	defer resp.Body.Close()
+
+	return ParseGameHomepageReader(resp.Request.URL, resp.Body)
+}
+
+// ParseGameHomepageReader parses a game homepage of CurseForge read from r, see ParseGameHomepage().
+// documentURL is required for resolving relative links.
+func ParseGameHomepageReader(documentURL *url.URL, r io.Reader) (*GameHomepage, error) {
+	root, err := xmlpath.ParseHTML(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing xml/http: %s", err.Error())
+	}
+
+	results := new(GameHomepage)
+	lookup := newPageLookup(DefaultFetcher, documentURL)
+	var found bool
+	for _, widget := range []struct {
+		xpath    string
+		projects *[]ProjectSummary
+	}{
+		{cfHomepagePopular, &results.PopularProjects},
+		{cfHomepageRecentlyUpdated, &results.RecentlyUpdated},
+		{cfHomepageNew, &results.NewProjects},
+	} {
+		// Any widget can be non-present
+		_, ok := lookup.Node(root, "GameHomepage", widget.xpath)
+		if !ok {
+			continue
+		}
+		found = true
+
+		*widget.projects, err = parseCFProjectSummaries(lookup, root, widget.xpath+"//li[@class='project-list-item']")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Most likely a layout change, or not a homepage at all
+	if !found {
+		return nil, newParseError(documentURL, "GameHomepage", cfHomepagePopular, nil)
+	}
+	return results, nil
+}
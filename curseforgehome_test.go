@@ -0,0 +1,67 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseGameHomepage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_home_minecraft.html")
+	}))
+	defer server.Close()
+
+	resp, err := NewFetcher(nil).FetchPage(server.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := ParseGameHomepage(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results.PopularProjects) != 2 || len(results.RecentlyUpdated) != 1 || len(results.NewProjects) != 0 {
+		t.Fatalf("Unexpected number of projects: %d popular, %d recently updated, %d new",
+			len(results.PopularProjects), len(results.RecentlyUpdated), len(results.NewProjects))
+	}
+	jei := results.PopularProjects[0]
+	if jei.Title != "Just Enough Items (JEI)" || jei.URL.String() != server.URL+"/projects/jei" || jei.Downloads != 95200000 {
+		t.Errorf("Unexpected project '%s' at '%s' with %d downloads", jei.Title, jei.URL, jei.Downloads)
+	}
+	if jei.Author.Name != "mezz" {
+		t.Errorf("Expected author '%s', got '%s'", "mezz", jei.Author.Name)
+	}
+	if taam := results.RecentlyUpdated[0]; taam.Title != "TAAM" || taam.Updated.Unix() != 1504130400 {
+		t.Errorf("Unexpected project '%s' updated at %v", taam.Title, taam.Updated)
+	}
+}
+
+func TestParseGameHomepageLayoutChange(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ParseGameHomepageReader(documentURL, strings.NewReader("<html><body><div id='content'></div></body></html>"))
+	if parseErr, ok := err.(*ParseError); !ok || parseErr.Field != "GameHomepage" {
+		t.Errorf("Expected a *ParseError for a page without widgets, got %v", err)
+	}
+}
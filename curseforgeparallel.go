@@ -0,0 +1,237 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// cfMaxParallelRequests is the maximum number of section pages fetched at the same time with CFOptionParallel.
+const cfMaxParallelRequests = 4
+
+// cfSectionOrder is the order the sections are processed in by FetchCurseForge.
+// The header is parsed from the first selected section, preferring the overview page.
+var cfSectionOrder = []CurseForgeSections{
+	CFSectionOverview,
+	CFSectionFiles,
+	CFSectionImages,
+	CFSectionDependencies,
+	CFSectionComments,
+	CFSectionDependents,
+}
+
+// fetchCurseForgeParallel fetches the selected sections concurrently using a bounded worker pool.
+// Every section is fetched & parsed into its own results, including subsequent pages (files, comments).
+// They are merged into results in cfSectionOrder once all sections are done, so the order does not
+// depend on the response times.
+// If multiple sections fail, the error of the first section (in cfSectionOrder) is returned.
+// With CFOptionContinueOnError, section errors are added to errs instead.
+func (f *Fetcher) fetchCurseForgeParallel(results *CurseForge, urls map[CurseForgeSections]*url.URL, sections CurseForgeSections, options CurseForgeOptions, errs *MultiError) error {
+	var selected []CurseForgeSections
+	for _, section := range cfSectionOrder {
+		if sections.Has(section) && urls[section] != nil {
+			selected = append(selected, section)
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+
+	workers := cfMaxParallelRequests
+	if len(selected) < workers {
+		workers = len(selected)
+	}
+
+	var wg sync.WaitGroup
+	sectionResults := make([]*CurseForge, len(selected))
+	headerErrs := make([]error, len(selected))
+	sectionErrs := make([]error, len(selected))
+	jobs := make(chan int)
+	// The game type is resolved by the header of the first section, the other sections wait for it before parsing
+	header := newCFHeaderGameType()
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				// Set with CFOptionSkipHeader, required by the parsers
+				sectionResults[idx] = &CurseForge{GameType: results.GameType}
+				headerErrs[idx], sectionErrs[idx] = f.fetchCurseForgeSection(sectionResults[idx], urls[selected[idx]], selected[idx], idx == 0, options, header)
+			}
+		}()
+	}
+	for idx := range selected {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	for idx := range selected {
+		if headerErrs[idx] != nil {
+			return headerErrs[idx]
+		}
+		mergeSectionResults(results, sectionResults[idx])
+		err := addSectionError(errs, urls[selected[idx]], sectionErrs[idx], options)
+		if err != nil {
+			return err
+		}
+	}
+	updateIncludedProjects(results)
+	return nil
+}
+
+// mergeSectionResults adds the results of a single section to results.
+// The files are appended like in sequential fetching, without merging the recent files of the overview
+// with the files of the files page. All other values are copied if missing in results, see Merge.
+func mergeSectionResults(results *CurseForge, section *CurseForge) {
+	downloads := section.Downloads
+	fileErrors := section.FileErrors
+	section.Downloads = nil
+	section.FileErrors = nil
+
+	results.Merge(section)
+	results.Downloads = append(results.Downloads, downloads...)
+	results.FileErrors = append(results.FileErrors, fileErrors...)
+	updateFirstFileDate(results)
+}
+
+// cfHeaderGameType hands the game type resolved by the header to the other sections of a parallel fetch,
+// as the files & dependencies parsers depend on it.
+type cfHeaderGameType struct {
+	done     chan struct{}
+	once     sync.Once
+	gameType GameType
+}
+
+func newCFHeaderGameType() *cfHeaderGameType {
+	return &cfHeaderGameType{done: make(chan struct{})}
+}
+
+// resolve publishes the game type of the header. Only the first call has an effect.
+func (h *cfHeaderGameType) resolve(gameType GameType) {
+	if h == nil {
+		return
+	}
+	h.once.Do(func() {
+		h.gameType = gameType
+		close(h.done)
+	})
+}
+
+// wait blocks until the game type of the header is resolved.
+// Returns GameTypeUnknown if the header failed, or if h is nil (sequential fetching).
+func (h *cfHeaderGameType) wait() GameType {
+	if h == nil {
+		return GameTypeUnknown
+	}
+	<-h.done
+	return h.gameType
+}
+
+// fetchCurseForgeSection fetches a single section page and parses it into results.
+// If parseHeader is set, failing to fetch the page or to parse the header is returned as headerErr.
+// The header is not parsed with CFOptionSkipHeader, parseHeader still marks the first page.
+// All other errors are returned as sectionErr.
+// With parallel fetching, the section with parseHeader resolves the game type in header,
+// all other sections wait for it before parsing. header is nil for sequential fetching.
+func (f *Fetcher) fetchCurseForgeSection(results *CurseForge, sectionURL *url.URL, section CurseForgeSections, parseHeader bool, options CurseForgeOptions, header *cfHeaderGameType) (headerErr error, sectionErr error) {
+	if parseHeader {
+		// Also releases the other sections if the header fails
+		defer func() {
+			header.resolve(results.GameType)
+		}()
+	}
+
+	root, raw, meta, err := f.fetchCurseForgeDocument(sectionURL)
+	if err != nil {
+		if parseHeader {
+			return err, nil
+		}
+		return nil, err
+	}
+	// Resolve links against the page after redirects, e.g. for renamed projects
+	documentURL := sectionURL
+	if meta.FinalURL != nil {
+		documentURL = meta.FinalURL
+	}
+
+	if isCFNotFoundPage(root) {
+		err = wrapError(ErrProjectNotFound, fmt.Sprintf("Error parsing URL '%s'", sectionURL.String()))
+		if parseHeader {
+			return err, nil
+		}
+		return nil, err
+	}
+
+	// Parse
+	if parseHeader {
+		results.Fetch = meta
+	}
+	if parseHeader && !options.Has(CFOptionSkipHeader) {
+		err = parseCFHeader(results, newPageLookup(f, documentURL), root, options)
+		if err != nil {
+			return wrapError(err, fmt.Sprintf("Error parsing URL '%s': error processing CF header", sectionURL.String())), nil
+		}
+	}
+	if parseHeader {
+		header.resolve(results.GameType)
+	} else if results.GameType == GameTypeUnknown {
+		results.GameType = header.wait()
+	}
+	err = results.parseCurseForgeSection(f, documentURL, root, raw, section, options)
+	if err != nil {
+		return nil, wrapError(err, fmt.Sprintf("Error parsing URL '%s'", sectionURL.String()))
+	}
+	return nil, nil
+}
+
+// fetchCurseForgeDocument fetches a page and parses it to a document.
+// The unparsed page and the response metadata are returned as well, see ParsedDocument.
+func (f *Fetcher) fetchCurseForgeDocument(pageURL *url.URL) (*xmlpath.Node, []byte, *FetchMeta, error) {
+	resp, err := f.FetchPage(pageURL.String())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, nil, nil, wrapError(ErrProjectNotFound, fmt.Sprintf("Error parsing URL '%s'", pageURL.String()))
+	}
+	err = checkPageStatus(pageURL.String(), resp)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	meta := newFetchMeta(resp, time.Now())
+	raw, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, nil, &FetchError{URL: pageURL.String(), StatusCode: resp.StatusCode, Err: err}
+	}
+	root, err := xmlpath.ParseHTML(bytes.NewReader(raw))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Error parsing URL '%s': error parsing xml/http: %s", pageURL.String(), err.Error())
+	}
+	return root, raw, meta, nil
+}
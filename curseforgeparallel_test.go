@@ -0,0 +1,201 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFetchCurseForgeParallel(t *testing.T) {
+	var mutex sync.Mutex
+	requested := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requested[r.URL.Path]++
+		mutex.Unlock()
+
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+		case strings.HasSuffix(r.URL.Path, "/images"):
+			http.ServeFile(w, r, "testdata/curseforge_images_taam.html")
+		case strings.HasSuffix(r.URL.Path, "/relations/dependencies"):
+			http.ServeFile(w, r, "testdata/curseforge_dependencies_taam.html")
+		default:
+			http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+		}
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sections CurseForgeSections = CFSectionOverview | CFSectionFiles | CFSectionImages | CFSectionDependencies
+	results, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionParallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Header from the overview page
+	if results.Title != "Pawn" {
+		t.Errorf("Expected title '%s', got '%s'", "Pawn", results.Title)
+	}
+	if results.TotalDownloads != 12345678 {
+		t.Errorf("Expected total downloads %d, got %d", 12345678, results.TotalDownloads)
+	}
+	// Three files pages with three files each
+	if len(results.Downloads) != 9 {
+		t.Errorf("Expected %d files, got %d", 9, len(results.Downloads))
+	}
+	if len(results.Screenshots) != 2 {
+		t.Errorf("Expected %d screenshots, got %d", 2, len(results.Screenshots))
+	}
+	if len(results.Dependencies) != 4 {
+		t.Errorf("Expected %d dependencies, got %d", 4, len(results.Dependencies))
+	}
+
+	if requested["/projects/pawn"] != 1 {
+		t.Errorf("Expected the overview page to be requested once, got %d", requested["/projects/pawn"])
+	}
+
+	// Same results as fetching the sections one after another
+	sequential, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionOverviewRecentFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parallel, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionOverviewRecentFiles|CFOptionParallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parallel.Downloads) != len(sequential.Downloads) {
+		t.Fatalf("Expected %d files, got %d", len(sequential.Downloads), len(parallel.Downloads))
+	}
+	for idx := range sequential.Downloads {
+		if parallel.Downloads[idx].Name != sequential.Downloads[idx].Name {
+			t.Errorf("Expected file %d to be '%s', got '%s'", idx, sequential.Downloads[idx].Name, parallel.Downloads[idx].Name)
+		}
+	}
+	if parallel.Title != sequential.Title || !parallel.FirstFileDate.Equal(sequential.FirstFileDate) || parallel.Fetch == nil {
+		t.Errorf("Expected the header values of the sequential fetch, got '%s' / %v / %v", parallel.Title, parallel.FirstFileDate, parallel.Fetch)
+	}
+}
+
+func TestFetchCurseForgeContinueOnError(t *testing.T) {
+	brokenHeader := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/files"):
+			// A file row without any of the expected values
+			w.Write([]byte(`<html><body><table><tr class="project-file-list-item"><td>broken</td></tr></table></body></html>`))
+		case strings.HasSuffix(r.URL.Path, "/images"):
+			http.ServeFile(w, r, "testdata/curseforge_images_taam.html")
+		case brokenHeader:
+			w.Write([]byte(`<html><body></body></html>`))
+		default:
+			http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+		}
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filesURL := server.URL + "/projects/pawn/files"
+
+	var sections CurseForgeSections = CFSectionOverview | CFSectionFiles | CFSectionImages
+	for _, options := range []CurseForgeOptions{CFOptionNone, CFOptionParallel} {
+		// Without the option, the files section aborts
+		results, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, options)
+		if err == nil || results != nil {
+			t.Errorf("Expected an error and no results with options %s, got %v", options, err)
+		}
+
+		// With the option, the other sections are returned
+		results, err = NewFetcher(nil).FetchCurseForge(projectURL, sections, options|CFOptionContinueOnError)
+		if results == nil {
+			t.Fatalf("Expected results with options %s, got error %v", options, err)
+		}
+		multiErr, ok := err.(*MultiError)
+		if !ok {
+			t.Fatalf("Expected a *MultiError with options %s, got %v", options, err)
+		}
+		if len(multiErr.Errors) != 1 || multiErr.Errors[filesURL] == nil {
+			t.Errorf("Expected a single error for '%s', got %v", filesURL, multiErr)
+		}
+		if results.TotalDownloads != 12345678 {
+			t.Errorf("Expected total downloads %d, got %d", 12345678, results.TotalDownloads)
+		}
+		if len(results.Screenshots) != 2 {
+			t.Errorf("Expected %d screenshots, got %d", 2, len(results.Screenshots))
+		}
+	}
+
+	// The header is still fatal
+	// (Parsed from the overview page with CFOptionParallel)
+	brokenHeader = true
+	results, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionParallel|CFOptionContinueOnError)
+	if err == nil || results != nil {
+		t.Errorf("Expected an error and no results for a broken header, got %v", err)
+	}
+	if _, ok := err.(*MultiError); ok {
+		t.Errorf("Expected the header error, got %v", err)
+	}
+}
+
+func TestFetchCurseForgeParallelGameType(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/files") {
+			// WoW lists interface versions, which are only mapped if the game is known
+			w.Write([]byte(`<html><body><table><tbody><tr class="project-file-list-item">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div></td>
+<td class="project-file-name"><div class="project-file-name-container"><a class="overflow-tip twitch-link" href="/projects/pawn/files/1">Pawn-2.2.0.zip</a></div>
+<div class="project-file-download-button"><a class="button tip fa-icon-download icon-only" href="/projects/pawn/files/1/download"></a></div></td>
+<td class="project-file-size">124 KB</td>
+<td class="project-file-date-uploaded"><abbr class="tip standard-date standard-datetime" data-epoch="1503439200">Aug 22, 2017</abbr></td>
+<td class="project-file-game-version"><span class="version-label">80000</span></td>
+<td class="project-file-downloads">1,234</td>
+</tr></tbody></table></body></html>`))
+			return
+		}
+		http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+	}))
+	defer server.Close()
+
+	// The game type cannot be detected from the URL of the test server, only from the header
+	projectURL, err := url.Parse(server.URL + "/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := NewFetcher(nil).FetchCurseForge(projectURL, CFSectionOverview|CFSectionFiles, CFOptionParallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.GameType != GameTypeWoW {
+		t.Fatalf("Expected game type %s, got %s", GameTypeWoW, results.GameType)
+	}
+	if len(results.Downloads) != 1 || results.Downloads[0].GameVersion != "8.0.0" {
+		t.Errorf("Expected a file for game version '%s', got %+v", "8.0.0", results.Downloads)
+	}
+}
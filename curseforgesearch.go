@@ -0,0 +1,134 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// SearchCurseForge searches the projects of a CurseForge site by name.
+// game is the subdomain of the site, e.g. "minecraft" for minecraft.curseforge.com.
+// Only the first page of results is parsed.
+// The ProjectURL of a result can be passed to FetchCurseForge.
+//
+// The request is sent using DefaultFetcher.
+func SearchCurseForge(game string, query string) ([]SearchResult, error) {
+	return DefaultFetcher.SearchCurseForge(game, query)
+}
+
+// SearchCurseForge searches the projects of a CurseForge site by name, see SearchCurseForge() for details.
+// The request is sent using this Fetcher.
+func (f *Fetcher) SearchCurseForge(game string, query string) ([]SearchResult, error) {
+	searchURL, err := cfSearchURL(game, query)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.FetchPage(searchURL.String())
+	if err != nil {
+		return nil, err
+	}
+	err = checkPageStatus(searchURL.String(), resp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return parseCurseForgeSearch(f, searchURL, resp.Body)
+}
+
+// ParseCurseForgeSearch parses a search results page of CurseForge read from r.
+// documentURL is required for resolving relative links.
+func ParseCurseForgeSearch(documentURL *url.URL, r io.Reader) ([]SearchResult, error) {
+	return parseCurseForgeSearch(DefaultFetcher, documentURL, r)
+}
+
+// parseCurseForgeSearch implements ParseCurseForgeSearch, reporting to the DebugHook of the given fetcher.
+func parseCurseForgeSearch(fetcher *Fetcher, documentURL *url.URL, r io.Reader) ([]SearchResult, error) {
+	root, err := xmlpath.ParseHTML(r)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing xml/http: %s", err.Error())
+	}
+
+	return parseCFSearchResults(newPageLookup(fetcher, documentURL), root)
+}
+
+// cfSearchURL builds the URL of the search page for the given game & query,
+// e.g. https://minecraft.curseforge.com/search?search=taam
+func cfSearchURL(game string, query string) (*url.URL, error) {
+	game = strings.ToLower(strings.TrimSpace(game))
+	if game == "" {
+		return nil, errors.New("no game given for search")
+	}
+	return &url.URL{
+		Scheme:   "https",
+		Host:     game + ".curseforge.com",
+		Path:     "/search",
+		RawQuery: url.Values{"search": []string{query}}.Encode(),
+	}, nil
+}
+
+func parseCFSearchResults(lookup *pageLookup, root *xmlpath.Node) ([]SearchResult, error) {
+	var err error
+
+	var searchResults []SearchResult
+
+	rows := pathCache.Iter(root, "//tr[@class='results']")
+	for rows.Next() {
+		rowTag := rows.Node()
+
+		result := SearchResult{}
+
+		result.Title, err = lookup.RequiredString(rowTag, "SearchResult/Title", "td[@class='results-name']/a")
+		if err != nil {
+			return nil, err
+		}
+
+		result.ProjectURL, err = lookup.RequiredURL(rowTag, "SearchResult/ProjectURL", "td[@class='results-name']/a/@href")
+		if err != nil {
+			return nil, err
+		}
+
+		// can be empty
+		result.Summary, _ = pathCache.String(rowTag, "td[@class='results-summary']")
+
+		result.Downloads, err = lookup.RequiredCount(rowTag, "SearchResult/Downloads", "td[@class='results-downloads']")
+		if err != nil {
+			return nil, err
+		}
+
+		result.Updated, err = lookup.RequiredUnixTimestamp(rowTag, "SearchResult/Updated", "td[@class='results-date']/abbr/@data-epoch")
+		if err != nil {
+			return nil, err
+		}
+
+		if lookup.normalize {
+			result.Title = NormalizeString(result.Title)
+			result.Summary = NormalizeString(result.Summary)
+		}
+
+		searchResults = append(searchResults, result)
+	}
+
+	return searchResults, nil
+}
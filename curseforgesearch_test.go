@@ -0,0 +1,97 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCFSearchURL(t *testing.T) {
+	searchURL, err := cfSearchURL("Minecraft", "tech & accessory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "https://minecraft.curseforge.com/search?search=tech+%26+accessory"
+	if searchURL.String() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, searchURL.String())
+	}
+
+	_, err = cfSearchURL(" ", "taam")
+	if err == nil {
+		t.Error("Expected an error for an empty game")
+	}
+}
+
+func TestParseCurseForgeSearch(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_search_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/search?search=taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := ParseCurseForgeSearch(documentURL, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []SearchResult{
+		{
+			Title:     "TAAM",
+			Summary:   "Tech & Accessory Mod: conveyors, machines and more.",
+			Downloads: 123456,
+			Updated:   time.Unix(1504044000, 0),
+		},
+		{
+			Title:     "TAAM Addons",
+			Summary:   "",
+			Downloads: 0,
+			Updated:   time.Unix(1483228800, 0),
+		},
+	}
+	expectedURLs := []string{
+		"https://minecraft.curseforge.com/projects/taam",
+		"https://minecraft.curseforge.com/projects/taam-addons",
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for idx, result := range results {
+		if result.Title != expected[idx].Title {
+			t.Errorf("Expected 'SearchResult/Title' '%s', got '%s'", expected[idx].Title, result.Title)
+		}
+		if result.ProjectURL.String() != expectedURLs[idx] {
+			t.Errorf("Expected 'SearchResult/ProjectURL' '%s', got '%s'", expectedURLs[idx], result.ProjectURL)
+		}
+		if result.Summary != expected[idx].Summary {
+			t.Errorf("Expected 'SearchResult/Summary' '%s', got '%s'", expected[idx].Summary, result.Summary)
+		}
+		if result.Downloads != expected[idx].Downloads {
+			t.Errorf("Expected 'SearchResult/Downloads' %d, got %d", expected[idx].Downloads, result.Downloads)
+		}
+		if !result.Updated.Equal(expected[idx].Updated) {
+			t.Errorf("Expected 'SearchResult/Updated' '%s', got '%s'", expected[idx].Updated, result.Updated)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"gopkg.in/xmlpath.v2"
+)
+
+// FetchCurseForgeFilesStream fetches the files pages of a CurseForge project and calls fn for every file,
+// as soon as its row was read.
+//
+// In contrast to FetchCurseForge with CFSectionFiles, the pages are never loaded into memory as a whole.
+// Only a single row of the listing is parsed at any time, so this is suited for projects with many files.
+// Return false from fn to stop; the rest of the current page is not read and no further pages are requested.
+// e.g. to get the 10 newest files only, count the files in fn and return false after the 10th,
+// or to get the files since the last sync, return false for the first file uploaded before it.
+//
+// filesURL is the URL of the files page, e.g. "https://minecraft.curseforge.com/projects/taam/files".
+// Pass CFOptionFilesNoPagination to only read the first page.
+// Set Fetcher.MaxFilesPages to limit the number of pages read.
+//
+// Rows that cannot be parsed are skipped, same as FileErrors of FetchCurseForge. Their errors are returned
+// as *MultiError after all other files were passed to fn, keyed by the URL of their page.
+// If no row of a page can be parsed at all (most likely a layout change), that error is returned right away.
+//
+// All requests are sent using DefaultFetcher.
+func FetchCurseForgeFilesStream(filesURL *url.URL, options CurseForgeOptions, fn func(File) bool) error {
+	return DefaultFetcher.FetchCurseForgeFilesStream(filesURL, options, fn)
+}
+
+// FetchCurseForgeFilesStream fetches the files pages of a CurseForge project and calls fn for every file,
+// see FetchCurseForgeFilesStream() for details. All requests are sent using this Fetcher.
+func (f *Fetcher) FetchCurseForgeFilesStream(filesURL *url.URL, options CurseForgeOptions, fn func(File) bool) error {
+	return f.fetchCurseForgeFilesStream(context.Background(), filesURL, options, fn)
+}
+
+// StreamCurseForgeFiles fetches all files of a CurseForge project in the background and sends them
+// on the returned channel, newest first. The next files page is only requested once all files
+// of the current page were received, so the files can be processed and discarded one at a time.
+//
+// The files channel is closed when all files were sent, on errors, or when ctx is done.
+// Afterwards, the error channel yields the error that stopped the listing (ctx.Err() on cancellation),
+// the *MultiError of the skipped rows (see FetchCurseForgeFilesStream), or is closed without a value.
+// Cancel ctx to stop early; the pending request is aborted.
+//
+// projectURL is the URL of the project, the files URL is derived using DeriveCurseForgeURLs.
+// Set Fetcher.MaxFilesPages to limit the number of pages read.
+//
+// All requests are sent using DefaultFetcher.
+func StreamCurseForgeFiles(ctx context.Context, projectURL *url.URL) (<-chan File, <-chan error) {
+	return DefaultFetcher.StreamCurseForgeFiles(ctx, projectURL)
+}
+
+// StreamCurseForgeFiles fetches all files of a CurseForge project in the background and sends them
+// on the returned channel, see StreamCurseForgeFiles() for details. All requests are sent using this Fetcher.
+func (f *Fetcher) StreamCurseForgeFiles(ctx context.Context, projectURL *url.URL) (<-chan File, <-chan error) {
+	files := make(chan File)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(files)
+
+		urls, err := DeriveCurseForgeURLs(projectURL)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		err = f.fetchCurseForgeFilesStream(ctx, urls[CFSectionFiles], CFOptionNone, func(file File) bool {
+			select {
+			case files <- file:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+		// Aborted requests fail with a wrapped error
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return files, errs
+}
+
+// fetchCurseForgeFilesStream is FetchCurseForgeFilesStream, aborting when ctx is done.
+func (f *Fetcher) fetchCurseForgeFilesStream(ctx context.Context, filesURL *url.URL, options CurseForgeOptions, fn func(File) bool) error {
+	errs := NewMultiError()
+	var page uint64
+	var pageCount uint64 = 1
+	for page = 1; page <= pageCount; page++ {
+		pageURL := filesURL
+		if page > 1 {
+			pageURL = filesURL.ResolveReference(&url.URL{
+				Path:     "files",
+				RawQuery: fmt.Sprintf("page=%d", page),
+			})
+		}
+
+		resp, err := f.fetchPage(ctx, pageURL.String())
+		if err != nil {
+			return err
+		}
+		err = checkPageStatus(pageURL.String(), resp)
+		if err != nil {
+			return err
+		}
+
+		count, stopped, rowErrors, err := scanCFFilesPage(newPageLookup(f, filesURL), resp.Body, fn)
+		resp.Body.Close()
+		if err != nil {
+			return wrapError(err, fmt.Sprintf("error parsing files page (%d)", page))
+		}
+		addRowErrors(errs, pageURL, rowErrors)
+		if stopped || options.Has(CFOptionFilesNoPagination) {
+			return errs.ErrorOrNil()
+		}
+		// The pagination is only evaluated on the first page
+		if page == 1 {
+			pageCount = f.lastFilesPage(count)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// ParseCurseForgeFilesStream parses the files listing of a single files page read from r
+// and calls fn for every file, as soon as its row was read.
+// Return false from fn to stop parsing; the rest of r is not read.
+// documentURL is required for resolving relative links.
+//
+// Rows that cannot be parsed are skipped. Their errors are returned as *MultiError once the page was read,
+// see FetchCurseForgeFilesStream.
+func ParseCurseForgeFilesStream(documentURL *url.URL, r io.Reader, fn func(File) bool) error {
+	_, _, rowErrors, err := scanCFFilesPage(newPageLookup(DefaultFetcher, documentURL), r, fn)
+	if err != nil {
+		return err
+	}
+	errs := NewMultiError()
+	addRowErrors(errs, documentURL, rowErrors)
+	return errs.ErrorOrNil()
+}
+
+// addRowErrors records the errors of the rows skipped on the page at pageURL, keyed by the page & their position.
+func addRowErrors(errs *MultiError, pageURL *url.URL, rowErrors []error) {
+	for idx, err := range rowErrors {
+		errs.Add(fmt.Sprintf("%s (row error %d)", pageURL.String(), idx+1), err)
+	}
+}
+
+// scanCFFilesPage reads a files page token by token. Every file row is parsed on its own
+// using parseCFFileRow, as soon as it is complete.
+// The page is tokenized like a browser does, so inline scripts and unclosed tags do not break the listing.
+// Rows that cannot be parsed are skipped and their errors returned, unless no row of the page
+// can be parsed at all, which is returned as error (same as parseCFFilesSinglePage).
+// Returns the highest page number found in the pagination (at least 1) and whether fn requested a stop.
+func scanCFFilesPage(lookup *pageLookup, r io.Reader, fn func(File) bool) (uint64, bool, []error, error) {
+	z := html.NewTokenizer(r)
+	// There is no header to resolve the game from, only the URL
+	gameType := DetectGameType(lookup.url)
+
+	var pageCount uint64 = 1
+	// Markup of the current row, nil if outside of a row
+	var row []byte
+	// Depth of tables nested inside of the current row
+	var tableDepth int
+	// Text of the current pagination link, if inside of one
+	var pageLink *strings.Builder
+
+	var parsed int
+	var rowErrors []error
+	// emit parses a complete row and passes it to fn. Returns false if fn requested a stop.
+	emit := func(row []byte) bool {
+		file, err := parseCFFileRowMarkup(row, lookup, gameType)
+		if err != nil {
+			rowErrors = append(rowErrors, err)
+			return true
+		}
+		parsed++
+		return fn(file)
+	}
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			err := z.Err()
+			if err == io.EOF {
+				err = nil
+			}
+			if err != nil {
+				return pageCount, false, rowErrors, fmt.Errorf("error parsing xml/http: %s", err.Error())
+			}
+			// A row cut off by the end of the page is still complete enough to parse
+			if row != nil && !emit(row) {
+				return pageCount, true, rowErrors, nil
+			}
+			// Most likely a layout change instead of some odd rows
+			if parsed == 0 && len(rowErrors) > 0 {
+				return pageCount, false, nil, rowErrors[0]
+			}
+			return pageCount, false, rowErrors, nil
+		}
+
+		tok := z.Token()
+
+		// Rows end with their end tag, or implicitly with the next row or the end of the table
+		var rowDone bool
+		// Whether the raw markup of the token was already added to the finished row
+		var consumed bool
+		if row != nil && tableDepth == 0 {
+			switch {
+			case tt == html.EndTagToken && tok.Data == "tr":
+				row = append(row, z.Raw()...)
+				rowDone = true
+				consumed = true
+			case tt == html.StartTagToken && tok.Data == "tr",
+				tt == html.EndTagToken && (tok.Data == "tbody" || tok.Data == "table"):
+				rowDone = true
+			}
+		}
+		if rowDone {
+			if !emit(row) {
+				return pageCount, true, rowErrors, nil
+			}
+			row = nil
+		}
+
+		switch tt {
+		case html.StartTagToken:
+			if row != nil && tok.Data == "table" {
+				tableDepth++
+			}
+			// Also starts a row right after the previous one ended implicitly
+			if row == nil && tok.Data == "tr" && hasHTMLClass(tok.Attr, "project-file-list-item") {
+				row = []byte{}
+			}
+			if tok.Data == "a" && hasHTMLClass(tok.Attr, "b-pagination-item") {
+				pageLink = new(strings.Builder)
+			}
+		case html.TextToken:
+			if pageLink != nil {
+				pageLink.WriteString(tok.Data)
+			}
+		case html.EndTagToken:
+			if row != nil && tok.Data == "table" && tableDepth > 0 {
+				tableDepth--
+			}
+			if pageLink != nil && tok.Data == "a" {
+				val, err := ParseUInt(strings.TrimSpace(pageLink.String()))
+				if err != nil {
+					return pageCount, false, rowErrors, fmt.Errorf("error parsing page number: %s", err.Error())
+				}
+				if val > pageCount {
+					pageCount = val
+				}
+				pageLink = nil
+			}
+		}
+
+		if row != nil && !consumed {
+			row = append(row, z.Raw()...)
+		}
+	}
+}
+
+// parseCFFileRowMarkup parses the markup of a single file row on its own.
+func parseCFFileRowMarkup(row []byte, lookup *pageLookup, gameType GameType) (File, error) {
+	var b bytes.Buffer
+	b.WriteString("<table><tbody>")
+	b.Write(row)
+	b.WriteString("</tbody></table>")
+
+	root, err := xmlpath.ParseHTML(&b)
+	if err != nil {
+		return File{}, fmt.Errorf("error parsing file row: %s", err.Error())
+	}
+	fileTag, ok := pathCache.Node(root, "//tr")
+	if !ok {
+		return File{}, fmt.Errorf("error parsing file row: row not found")
+	}
+	return parseCFFileRow(fileTag, lookup, gameType)
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+func TestScanCFFilesPage(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parse the whole page in one go for comparison
+	expected := new(CurseForge)
+	err = parseCFFilesSinglePage(expected, newPageLookup(nil, documentURL), parseTestdata(t, "curseforge_files_taam.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected.Downloads) != 3 {
+		t.Fatalf("Expected %d files, got %d", 3, len(expected.Downloads))
+	}
+
+	f, err := os.Open("testdata/curseforge_files_taam.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var files []File
+	pageCount, stopped, rowErrors, err := scanCFFilesPage(newPageLookup(nil, documentURL), f, func(file File) bool {
+		files = append(files, file)
+		return len(files) < 2
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrors) != 0 {
+		t.Errorf("Expected no row errors, got %v", rowErrors)
+	}
+
+	if !stopped {
+		t.Errorf("Expected scan to stop early")
+	}
+	if pageCount != 3 {
+		t.Errorf("Expected %d pages, got %d", 3, pageCount)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected %d files, got %d", 2, len(files))
+	}
+	for idx, file := range files {
+		if file.Name != expected.Downloads[idx].Name || file.URL.String() != expected.Downloads[idx].URL.String() ||
+			file.Date != expected.Downloads[idx].Date || file.AdditionalFileCount != expected.Downloads[idx].AdditionalFileCount {
+			t.Errorf("Streamed file %d differs: expected %+v, got %+v", idx, expected.Downloads[idx], file)
+		}
+	}
+}
+
+func TestStreamCurseForgeFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(nil)
+
+	// All three pages
+	files, errs := fetcher.StreamCurseForgeFiles(context.Background(), projectURL)
+	count := 0
+	for range files {
+		count++
+	}
+	if err := <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if count != 9 {
+		t.Errorf("Expected %d files, got %d", 9, count)
+	}
+
+	// Cancelled after the first file
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	files, errs = fetcher.StreamCurseForgeFiles(ctx, projectURL)
+	file, ok := <-files
+	if !ok || file.Name != "TAAM-1.12.1-0.7.0.jar" {
+		t.Fatalf("Expected first file '%s', got %v", "TAAM-1.12.1-0.7.0.jar", file)
+	}
+	cancel()
+	if err := <-errs; err != context.Canceled {
+		t.Errorf("Expected %v, got %v", context.Canceled, err)
+	}
+	if _, ok := <-files; ok {
+		t.Errorf("Expected the files channel to be closed")
+	}
+}
+
+func TestScanCFFilesPageScripts(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inline scripts with '<' and rows without end tags are read like a browser does
+	row := `<tr class="project-file-list-item">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div>
+<td class="project-file-name"><div class="project-file-name-container"><a class="overflow-tip twitch-link" href="/projects/taam/files/%[1]d">taam-%[1]d.jar</a></div>
+<div class="project-file-download-button"><a class="button tip fa-icon-download icon-only" href="/projects/taam/files/%[1]d/download"></a></div>
+<td class="project-file-size">1.24 MB
+<td class="project-file-date-uploaded"><abbr class="tip standard-date standard-datetime" data-epoch="1503439200">Aug 22, 2017</abbr>
+<td class="project-file-game-version"><span class="version-label">1.12.1</span>
+<td class="project-file-downloads">1,234
+`
+	page := `<html><head><script>if (a < b && c) { x(); }</script></head><body><table><tbody>` +
+		fmt.Sprintf(row, 1) + fmt.Sprintf(row, 2) + `</tbody></table></body></html>`
+
+	var names []string
+	_, stopped, _, err := scanCFFilesPage(newPageLookup(nil, documentURL), strings.NewReader(page), func(file File) bool {
+		names = append(names, file.Name)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stopped {
+		t.Errorf("Expected scan to read the whole page")
+	}
+	if len(names) != 2 || names[0] != "taam-1.jar" || names[1] != "taam-2.jar" {
+		t.Errorf("Expected files %v, got %v", []string{"taam-1.jar", "taam-2.jar"}, names)
+	}
+}
+
+func TestScanCFFilesPageUnclosedRows(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rows without end tags, each started by the next one. The second is archived.
+	row := `<tr class="project-file-list-item%[2]s" data-file-id="900%[1]d">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div>
+<td class="project-file-name"><div class="project-file-name-container"><a class="overflow-tip twitch-link" href="/projects/taam/files/%[1]d">taam-%[1]d.jar</a></div>
+<div class="project-file-download-button"><a class="button tip fa-icon-download icon-only" href="/projects/taam/files/%[1]d/download"></a></div>
+<td class="project-file-size">1.24 MB
+<td class="project-file-date-uploaded"><abbr class="tip standard-date standard-datetime" data-epoch="1503439200">Aug 22, 2017</abbr>
+<td class="project-file-game-version"><span class="version-label">1.12.1</span>
+<td class="project-file-downloads">1,234
+`
+	page := `<html><body><table><tbody>` + fmt.Sprintf(row, 1, "") + fmt.Sprintf(row, 2, " archived") +
+		fmt.Sprintf(row, 3, "") + `</tbody></table></body></html>`
+
+	expected := new(CurseForge)
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parseCFFilesSinglePage(expected, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected.Downloads) != 3 || !expected.Downloads[1].Deprecated {
+		t.Fatalf("Expected 3 files with the second deprecated, got %+v", expected.Downloads)
+	}
+
+	var files []File
+	_, _, rowErrors, err := scanCFFilesPage(newPageLookup(nil, documentURL), strings.NewReader(page), func(file File) bool {
+		files = append(files, file)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rowErrors) != 0 {
+		t.Errorf("Expected no row errors, got %v", rowErrors)
+	}
+	if len(files) != len(expected.Downloads) {
+		t.Fatalf("Expected %d files, got %d", len(expected.Downloads), len(files))
+	}
+	for idx, file := range files {
+		if file.ID != expected.Downloads[idx].ID || file.Deprecated != expected.Downloads[idx].Deprecated {
+			t.Errorf("Streamed file %d differs: expected ID %d, deprecated %t, got ID %d, deprecated %t", idx,
+				expected.Downloads[idx].ID, expected.Downloads[idx].Deprecated, file.ID, file.Deprecated)
+		}
+	}
+}
+
+func TestParseCurseForgeFilesStreamBrokenRow(t *testing.T) {
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second row lacks the file name
+	row := `<tr class="project-file-list-item">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div></td>
+<td class="project-file-name"><div class="project-file-name-container"><a class="overflow-tip twitch-link" href="/projects/taam/files/%[1]d">%[2]s</a></div>
+<div class="project-file-download-button"><a class="button tip fa-icon-download icon-only" href="/projects/taam/files/%[1]d/download"></a></div></td>
+<td class="project-file-size">1.24 MB</td>
+<td class="project-file-date-uploaded"><abbr class="tip standard-date standard-datetime" data-epoch="1503439200">Aug 22, 2017</abbr></td>
+<td class="project-file-game-version"><span class="version-label">1.12.1</span></td>
+<td class="project-file-downloads">1,234</td>
+</tr>`
+	page := `<table><tbody>` + fmt.Sprintf(row, 1, "taam-1.jar") + fmt.Sprintf(row, 2, "") +
+		fmt.Sprintf(row, 3, "taam-3.jar") + `</tbody></table>`
+
+	var names []string
+	err = ParseCurseForgeFilesStream(documentURL, strings.NewReader(page), func(file File) bool {
+		names = append(names, file.Name)
+		return true
+	})
+	if len(names) != 2 || names[0] != "taam-1.jar" || names[1] != "taam-3.jar" {
+		t.Errorf("Expected files %v, got %v", []string{"taam-1.jar", "taam-3.jar"}, names)
+	}
+	multiErr, ok := err.(*MultiError)
+	if !ok || len(multiErr.Errors) != 1 {
+		t.Fatalf("Expected a *MultiError with %d error, got %v", 1, err)
+	}
+	for _, rowErr := range multiErr.Errors {
+		if parseErr, ok := rowErr.(*ParseError); !ok || parseErr.Field != "File/Name" {
+			t.Errorf("Expected a ParseError for 'File/Name', got %v", rowErr)
+		}
+	}
+}
@@ -18,114 +18,351 @@ package curse
 
 import (
 	"net/url"
+	"strings"
 	"time"
 )
 
 type Author struct {
-	Name     string
-	Role     string
-	URL      *url.URL
-	ImageURL *url.URL
+	Name string `json:"name"`
+	// The role as printed on the page, without the trailing colon
+	Role string `json:"role"`
+	// Role parsed to an AuthorRole, AuthorRoleUnknown if not recognized
+	NormalizedRole AuthorRole `json:"normalizedRole"`
+	URL            *url.URL   `json:"url"`
+	ImageURL       *url.URL   `json:"imageUrl"`
 }
 
 type Image struct {
-	URL          *url.URL
-	ThumbnailURL *url.URL
+	URL          *url.URL `json:"url"`
+	ThumbnailURL *url.URL `json:"thumbnailUrl"`
 }
 
 type File struct {
-	Name        string
-	URL         *url.URL
-	DirectURL   *url.URL
-	ReleaseType string
-	GameVersion string
-	Downloads   uint64
-	Date        time.Time
+	// The CurseForge file ID, 0 if unknown
+	ID          uint64   `json:"id"`
+	Name        string   `json:"name"`
+	URL         *url.URL `json:"url"`
+	DirectURL   *url.URL `json:"directUrl"`
+	ReleaseType string   `json:"releaseType"`
+	// ReleaseType parsed to a ReleaseType, ReleaseTypeUnknown if not recognized
+	Release ReleaseType `json:"release"`
+	// Set if the file is marked as archived or deprecated in the files listing.
+	// Its links may be dead, see FileFilter.ExcludeDeprecated.
+	Deprecated bool `json:"deprecated"`
+	// The game version as printed on the page. For WoW addons, interface versions
+	// ("70300") are mapped to the patch ("7.3.0").
+	GameVersion string    `json:"gameVersion"`
+	Downloads   uint64    `json:"downloads"`
+	Date        time.Time `json:"date"`
 	// The size info as printed on the page, unparsed
-	SizeInfo           string
-	HasAdditionalFiles bool
+	SizeInfo string `json:"sizeInfo"`
+	// The size in bytes, parsed from SizeInfo. 0 if unknown.
+	// As SizeInfo is rounded, this is an approximation unless loaded from the API.
+	SizeBytes          uint64 `json:"sizeBytes"`
+	HasAdditionalFiles bool   `json:"hasAdditionalFiles"`
+	// The number of additional files as printed on the more-files tag ("+3 files").
+	// 0 if there are no additional files or the tag does not show a count.
+	AdditionalFileCount uint64 `json:"additionalFileCount"`
+	// The mod loaders (e.g. "Forge", "Fabric") listed for this file, if known.
+	// Only filled for the API-backed files listing of the redesigned site.
+	ModLoaders []string `json:"modLoaders"`
+	// The additional files grouped under this file, e.g. API or source jars.
+	// Only filled when using CFOptionFilesFetchAdditional.
+	AdditionalFiles []File `json:"additionalFiles"`
+
+	// The following values are parsed from the file's detail page and
+	// are only filled when using CFOptionFilesFetchDetails.
+
+	// The required Java version, e.g. "8". Empty if not listed.
+	RequiredJavaVersion  string   `json:"requiredJavaVersion"`
+	IncompatibleVersions []string `json:"incompatibleVersions"`
+	// The MD5 hash of the file (lowercase hex), to verify downloads against
+	MD5 string `json:"md5"`
+	// The name of the member that uploaded the file
+	UploadedBy string `json:"uploadedBy"`
+	// The date the file was uploaded. For early-access releases this is before the release date.
+	UploadDate time.Time `json:"uploadDate"`
+	// The (scheduled) date the file is released. Date is set to this value.
+	// Equal to Date if the page does not list a separate release date.
+	ReleaseDate time.Time `json:"releaseDate"`
+	// The changelog of the file. Changelog is the markup, ChangelogText the text
+	// without tags and with whitespace collapsed. Both are empty if there is no changelog.
+	Changelog     string `json:"changelog"`
+	ChangelogText string `json:"changelogText"`
 }
 
 type Category struct {
-	Name     string
-	URL      *url.URL
-	ImageURL *url.URL
+	Name     string   `json:"name"`
+	URL      *url.URL `json:"url"`
+	ImageURL *url.URL `json:"imageUrl"`
+}
+
+// DescriptionMedia lists the media embedded in a project description.
+type DescriptionMedia struct {
+	// Embedded videos (e.g. YouTube embed URLs)
+	Videos []*url.URL `json:"videos"`
+	// Inline images
+	Images []*url.URL `json:"images"`
 }
 
 type Dependency struct {
-	Name     string
-	URL      *url.URL
-	ImageURL *url.URL
+	Name     string   `json:"name"`
+	URL      *url.URL `json:"url"`
+	ImageURL *url.URL `json:"imageUrl"`
+	// The dependency type as grouped on the page, e.g. "Required", "Optional", "Embedded".
+	// For dependents, this is how the dependent project depends on this one.
+	Type string `json:"type"`
+}
+
+// Comment is a single comment on the comments page of a CurseForge project.
+type Comment struct {
+	Author Author `json:"author"`
+	// The text of the comment, without markup. Line breaks are kept.
+	Body string    `json:"body"`
+	Date time.Time `json:"date"`
+	// Permalink to the comment
+	URL *url.URL `json:"url"`
+}
+
+// SearchResult is a single project found by SearchCurseForge.
+type SearchResult struct {
+	Title      string   `json:"title"`
+	ProjectURL *url.URL `json:"projectUrl"`
+	Summary    string   `json:"summary"`
+	Downloads  uint64   `json:"downloads"`
+	// Date of the last update
+	Updated time.Time `json:"updated"`
+}
+
+// ProjectSummary is a single project listed by BrowseCurseForge.
+type ProjectSummary struct {
+	Title   string   `json:"title"`
+	URL     *url.URL `json:"url"`
+	Summary string   `json:"summary"`
+	// The owner as listed in the byline, the Role is empty
+	Author    Author `json:"author"`
+	Downloads uint64 `json:"downloads"`
+	// Date of the last update
+	Updated time.Time `json:"updated"`
+}
+
+// GameHomepage represents the curated project lists of a game homepage on curseforge.com.
+// Lists not shown on the page are empty.
+type GameHomepage struct {
+	PopularProjects []ProjectSummary `json:"popularProjects"`
+	RecentlyUpdated []ProjectSummary `json:"recentlyUpdated"`
+	NewProjects     []ProjectSummary `json:"newProjects"`
+}
+
+// ManifestEntry is a single entry of the "files" list of a CurseForge modpack manifest (manifest.json),
+// as consumed by the CurseForge launcher and other modpack tools. See CurseForge.ToManifestEntry.
+type ManifestEntry struct {
+	ProjectID uint64 `json:"projectID"`
+	FileID    uint64 `json:"fileID"`
+	Required  bool   `json:"required"`
+}
+
+// FetchMeta describes the HTTP response a result was parsed from.
+type FetchMeta struct {
+	// The URL of the page after following all redirects
+	FinalURL   *url.URL `json:"finalUrl"`
+	StatusCode int      `json:"statusCode"`
+	// The time reported by the server (Date header), zero if not sent
+	ServerTime time.Time `json:"serverTime"`
+	// The local time the response was received
+	FetchedAt time.Time `json:"fetchedAt"`
 }
 
 // Curse represents a single project parsed from mods.curse.com.
 type Curse struct {
-	Title        string
-	DontationURL *url.URL
+	Title        string   `json:"title"`
+	DontationURL *url.URL `json:"donationUrl"`
+
+	Likes     uint64 `json:"likes"`
+	Favorites uint64 `json:"favorites"`
+
+	Authors    []Author   `json:"authors"`
+	Categories []Category `json:"categories"`
+	License    string     `json:"license"`
 
-	Likes     uint64
-	Favorites uint64
+	CurseforgeURL *url.URL `json:"curseforgeUrl"`
 
-	Authors    []Author
-	Categories []Category
-	License    string
+	Game    string   `json:"game"`
+	GameURL *url.URL `json:"gameUrl"`
 
-	CurseforgeURL *url.URL
+	AvgDownloads          uint64 `json:"avgDownloads"`
+	AvgDownloadsTimeframe string `json:"avgDownloadsTimeframe"`
+	TotalDownloads        uint64 `json:"totalDownloads"`
 
-	Game    string
-	GameURL *url.URL
+	Updated time.Time `json:"updated"`
+	Created time.Time `json:"created"`
 
-	AvgDownloads          uint64
-	AvgDownloadsTimeframe string
-	TotalDownloads        uint64
+	Screenshots []Image `json:"screenshots"`
+	Downloads   []File  `json:"downloads"`
 
-	Updated time.Time
-	Created time.Time
+	// CurseForge is set if the page was redirected to curseforge.com and parsed
+	// using the CurseForge parser. The values above are copied from it, where available.
+	CurseForge *CurseForge `json:"curseForge,omitempty"`
 
-	Screenshots []Image
-	Downloads   []File
+	// The response the page was parsed from, nil if parsed from a reader.
+	Fetch *FetchMeta `json:"fetch,omitempty"`
 }
 
 // CurseForge represents a single project parsed from curseforge.com.
 // The data can be parsed from several sub-pages, though.
 type CurseForge struct {
-	OverviewURL     *url.URL
-	FilesURL        *url.URL
-	ImagesURL       *url.URL
-	DependenciesURL *url.URL
-	DependentsURL   *url.URL
-
-	CurseURL         *url.URL
-	ReportProjectURL *url.URL
-	IssuesURL        *url.URL
-	WikiURL          *url.URL
-	SourceURL        *url.URL
-
-	Title               string
-	ProjectURL          *url.URL
-	DontationURL        *url.URL
-	ImageURL            *url.URL
-	ImageThumbnailURL   *url.URL
-	RootGameCategory    string
-	RootGameCategoryURL *url.URL
-	License             string
-	LicenseURL          *url.URL
-	Game                string
-	GameURL             *url.URL
+	OverviewURL     *url.URL `json:"overviewUrl"`
+	FilesURL        *url.URL `json:"filesUrl"`
+	ImagesURL       *url.URL `json:"imagesUrl"`
+	DependenciesURL *url.URL `json:"dependenciesUrl"`
+	DependentsURL   *url.URL `json:"dependentsUrl"`
+
+	CurseURL         *url.URL `json:"curseUrl"`
+	ReportProjectURL *url.URL `json:"reportProjectUrl"`
+	IssuesURL        *url.URL `json:"issuesUrl"`
+	WikiURL          *url.URL `json:"wikiUrl"`
+	SourceURL        *url.URL `json:"sourceUrl"`
+
+	// The canonical URL of the project, as declared by the page or linked as its overview, after following redirects.
+	// For renamed projects & legacy URLs, this contains the current slug instead of the requested one.
+	CanonicalURL *url.URL `json:"canonicalUrl"`
+
+	// The CurseForge project ID, 0 if unknown
+	ProjectID         uint64   `json:"projectId"`
+	Title             string   `json:"title"`
+	ProjectURL        *url.URL `json:"projectUrl"`
+	DontationURL      *url.URL `json:"donationUrl"`
+	DonationProvider  string   `json:"donationProvider"`
+	ImageURL          *url.URL `json:"imageUrl"`
+	ImageThumbnailURL *url.URL `json:"imageThumbnailUrl"`
+	// The avatar at several sizes (e.g. 32, 64, 256), keyed by width in pixels.
+	// Derived from ImageThumbnailURL, nil if it is not a forgecdn.net thumbnail.
+	ImageURLVariants    map[int]*url.URL `json:"imageUrlVariants"`
+	RootGameCategory    string           `json:"rootGameCategory"`
+	RootGameCategoryURL *url.URL         `json:"rootGameCategoryUrl"`
+	License             string           `json:"license"`
+	LicenseURL          *url.URL         `json:"licenseUrl"`
+	// The SPDX identifier of License (e.g. "MIT", "GPL-3.0-only"), see ParseLicenseSPDX.
+	// Empty for custom licenses, "All Rights Reserved" and unrecognized names.
+	LicenseSPDX string   `json:"licenseSpdx"`
+	Game        string   `json:"game"`
+	GameURL     *url.URL `json:"gameUrl"`
+	// The game detected from the project URL, or from Game if the URL does not identify it.
+	// The game version of files is mapped according to the game, see File.GameVersion.
+	GameType GameType `json:"gameType"`
 
 	//AvgDownloads          uint64
 	//AvgDownloadsTimeframe string
-	TotalDownloads uint64
+	TotalDownloads uint64 `json:"totalDownloads"`
+
+	// Rating is the average star rating, RatingCount the number of ratings.
+	// Only some games display ratings, zero otherwise.
+	Rating      float64 `json:"rating"`
+	RatingCount uint64  `json:"ratingCount"`
+
+	// The number of comments and followers ("Watchers" on some games) as listed in the sidebar.
+	// Zero if not displayed. The comments themselves are parsed into Comments, see CFSectionComments.
+	CommentCount uint64 `json:"commentCount"`
+	Followers    uint64 `json:"followers"`
 
-	Created time.Time
-	Updated time.Time
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+	// FirstFileDate is the release date of the oldest file that was parsed.
+	// For imported projects, this can predate Created.
+	// Only filled if files were parsed, see CFSectionFiles & CFOptionOverviewRecentFiles.
+	FirstFileDate time.Time `json:"firstFileDate"`
 
 	//Likes     uint64
 	//Favorites uint64
 
-	Authors    []Author
-	Categories []Category
+	Authors    []Author   `json:"authors"`
+	Categories []Category `json:"categories"`
+
+	// The one-line summary shown below the title, parsed from the header.
+	// Empty if the project does not have a summary.
+	Summary string `json:"summary"`
+	// The project description ("About This Project"), parsed from the overview page.
+	// DescriptionHTML is the markup of the description, DescriptionText the text
+	// without tags and with whitespace collapsed. Both are empty if there is no description.
+	DescriptionHTML string `json:"descriptionHtml"`
+	DescriptionText string `json:"descriptionText"`
+	// Media embedded in the project description, parsed from the overview page.
+	DescriptionMedia DescriptionMedia `json:"descriptionMedia"`
+
+	Screenshots []Image `json:"screenshots"`
+	Downloads   []File  `json:"downloads"`
+
+	// The newest file of each release channel, as listed in the "Recent Files" of the overview page.
+	// nil if there is no recent file in the channel.
+	RecentRelease *File `json:"recentRelease"`
+	RecentBeta    *File `json:"recentBeta"`
+	RecentAlpha   *File `json:"recentAlpha"`
+	// The link of the main download button of the overview page, pointing to the latest file.
+	// nil if there is no download button.
+	LatestDownloadURL *url.URL `json:"latestDownloadUrl"`
+
+	// All game versions the project released files for, as listed in the
+	// version filter of the files page. Parsed from the first files page.
+	AvailableGameVersions []string `json:"availableGameVersions"`
+	// The number of files pages and files as listed on the first files page.
+	// TotalFilePages is 0 if the pagination could not be read with CFOptionFilesNoPagination.
+	// Useful to decide whether to load more pages, see Fetcher.MaxFilesPages.
+	// TotalFiles is 0 if the page does not show a total.
+	TotalFilePages uint64 `json:"totalFilePages"`
+	TotalFiles     uint64 `json:"totalFiles"`
+	// The errors of the rows of the files listing that could not be parsed.
+	// These rows are skipped and missing in Downloads, or in File.AdditionalFiles for the rows
+	// of the additional files (CFOptionFilesFetchAdditional). Not included in JSON.
+	FileErrors []error `json:"-"`
+	// The number of files and the sum of their downloads per game version.
+	// Not filled by the parsers, call ComputeVersionStats after parsing the files.
+	FileCountByVersion map[string]uint64 `json:"fileCountByVersion"`
+	DownloadsByVersion map[string]uint64 `json:"downloadsByVersion"`
+
+	// Parsed from the dependencies page, see CFSectionDependencies.
+	Dependencies []Dependency `json:"dependencies"`
+	// Parsed from the dependents page, see CFSectionDependents.
+	// The projects that depend on this one.
+	Dependents []Dependency `json:"dependents"`
+
+	// IsModpack is true if the project is a modpack, detected from the root category or a page marker in the header.
+	IsModpack bool `json:"isModpack"`
+	// The projects included in a modpack, best-effort from the "Include" group of the dependencies page.
+	// Only Title & URL are filled. Empty if the project is not a modpack, see IsModpack.
+	IncludedProjects []ProjectSummary `json:"includedProjects"`
+
+	// Parsed from the comments page, see CFSectionComments. Newest first, as listed on the page.
+	Comments []Comment `json:"comments"`
+
+	// The response of the first page fetched, which the header is parsed from. nil if parsed from a reader.
+	// FinalURL differs from the requested URL if CurseForge redirected it, e.g. for legacy URLs.
+	Fetch *FetchMeta `json:"fetch,omitempty"`
+}
+
+// CrossSiteURL returns the URL of this project on curseforge.com,
+// or nil if the link was not present on the page.
+func (c *Curse) CrossSiteURL() *url.URL {
+	return normalizeCrossSiteURL(c.CurseforgeURL)
+}
+
+// CrossSiteURL returns the URL of this project on curse.com ("View on Curse"),
+// or nil if the link was not present on the page.
+func (c *CurseForge) CrossSiteURL() *url.URL {
+	return normalizeCrossSiteURL(c.CurseURL)
+}
 
-	Screenshots []Image
-	Downloads   []File
+// normalizeCrossSiteURL returns a normalized copy of a link to the other site.
+// Schemeless URLs ("//www.curseforge.com/...") get the https scheme, the host is lowercased
+// and fragments are removed.
+func normalizeCrossSiteURL(link *url.URL) *url.URL {
+	if link == nil || link.Host == "" {
+		return nil
+	}
+	normalized := *link
+	if normalized.Scheme == "" {
+		normalized.Scheme = "https"
+	}
+	normalized.Host = strings.ToLower(normalized.Host)
+	normalized.Fragment = ""
+	return &normalized
 }
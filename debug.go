@@ -0,0 +1,22 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+// DebugFunc is called by the parsers for every value resolved from a page, see Fetcher.DebugHook.
+// field is the name of the value as used in ParseError (e.g. "Title" or "File/Date"),
+// xpath the selector used and matched whether it resolved to a valid value.
+type DebugFunc func(field, xpath string, matched bool)
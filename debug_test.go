@@ -0,0 +1,77 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+func TestDebugHook(t *testing.T) {
+	matched := make(map[string]bool)
+	fetcher := NewFetcher(nil)
+	fetcher.DebugHook = func(field, xpath string, ok bool) {
+		if xpath == "" {
+			t.Errorf("Empty xpath for field '%s'", field)
+		}
+		matched[field] = ok
+	}
+
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := new(CurseForge)
+	err = parseCFFilesSinglePage(results, newPageLookup(fetcher, documentURL), parseTestdata(t, "curseforge_files_taam.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"File/Name", "File/URL", "File/Date", "File/GameVersion"} {
+		if ok, reported := matched[field]; !reported || !ok {
+			t.Errorf("Expected field '%s' to be reported as matched", field)
+		}
+	}
+
+	// A changed layout is reported with the field of the resulting ParseError
+	page := `<html><body><table><tr class="project-file-list-item"><td class="project-file-release-type"><div title="Release"></div></td></tr></table></body></html>`
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matched = make(map[string]bool)
+	err = parseCFFilesSinglePage(new(CurseForge), newPageLookup(fetcher, documentURL), root, CFOptionNone)
+	parseErr, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if ok, reported := matched[parseErr.Field]; !reported || ok {
+		t.Errorf("Expected field '%s' to be reported as not matched", parseErr.Field)
+	}
+
+	// Pages parsed by other fetchers are not reported
+	matched = make(map[string]bool)
+	err = parseCFFilesSinglePage(new(CurseForge), newPageLookup(NewFetcher(nil), documentURL), parseTestdata(t, "curseforge_files_taam.html"), CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 0 {
+		t.Errorf("Expected no reports from another fetcher, got %v", matched)
+	}
+}
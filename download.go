@@ -0,0 +1,107 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrDownloadMismatch is returned by DownloadFile if the downloaded data does not match
+// the MD5 hash or the size of the file. It is wrapped with more context.
+var ErrDownloadMismatch = errors.New("download does not match the file")
+
+// downloadSizeTolerance is the relative deviation from File.SizeBytes accepted by DownloadFile,
+// as sizes parsed from the files listing are rounded ("1.24 MB").
+const downloadSizeTolerance = 0.05
+
+// DownloadFile downloads the file from its DirectURL and writes it to w, see Fetcher.DownloadFile.
+// The request is sent using DefaultFetcher.
+func DownloadFile(ctx context.Context, file *File, w io.Writer) (int64, error) {
+	return DefaultFetcher.DownloadFile(ctx, file, w)
+}
+
+// DownloadFile downloads the file from its DirectURL and writes it to w.
+// Redirects (e.g. to forgecdn.net) are followed by the Client of this Fetcher.
+// Returns the number of bytes written. Cancel ctx to abort the download.
+//
+// MaxBodyBytes and the Cache do not apply to downloads.
+// If the MD5 hash of the file is known (see CFOptionFilesFetchDetails), the download is verified against it.
+// Otherwise, if SizeBytes is known, the size is verified, allowing for the rounding of the files listing.
+// Mismatches are reported as an error wrapping ErrDownloadMismatch. The data was written to w regardless.
+func (f *Fetcher) DownloadFile(ctx context.Context, file *File, w io.Writer) (int64, error) {
+	if file.DirectURL == nil {
+		return 0, fmt.Errorf("no download URL for file '%s'", file.Name)
+	}
+	downloadURL := file.DirectURL.String()
+
+	// Binaries are neither limited to the size of a page, nor cached or checked for the consent page
+	download := *f
+	download.MaxBodyBytes = 0
+	download.Cache = nil
+	download.EnableAutoConsent = false
+
+	resp, err := download.fetchPage(ctx, downloadURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, &FetchError{
+			URL:        downloadURL,
+			StatusCode: resp.StatusCode,
+			Err:        fmt.Errorf("unexpected status %s", resp.Status),
+		}
+	}
+
+	var hasher hash.Hash
+	out := w
+	if file.MD5 != "" {
+		hasher = md5.New()
+		out = io.MultiWriter(w, hasher)
+	}
+	written, err := io.Copy(out, resp.Body)
+	if err != nil {
+		return written, &FetchError{URL: downloadURL, StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if hasher != nil {
+		sum := hex.EncodeToString(hasher.Sum(nil))
+		if !strings.EqualFold(sum, file.MD5) {
+			return written, wrapError(ErrDownloadMismatch, fmt.Sprintf("MD5 %s of '%s' differs from %s", sum, downloadURL, file.MD5))
+		}
+	} else if file.SizeBytes > 0 && !sizeWithinTolerance(uint64(written), file.SizeBytes) {
+		return written, wrapError(ErrDownloadMismatch, fmt.Sprintf("downloaded %d bytes from '%s', expected about %d", written, downloadURL, file.SizeBytes))
+	}
+	return written, nil
+}
+
+// sizeWithinTolerance returns true if size deviates from expected by at most downloadSizeTolerance.
+func sizeWithinTolerance(size, expected uint64) bool {
+	diff := float64(size) - float64(expected)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= float64(expected)*downloadSizeTolerance
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDownloadFile(t *testing.T) {
+	content := strings.Repeat("jar", 1000)
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/projects/taam/files/2444195/download":
+			// Like the bounce to forgecdn.net
+			http.Redirect(w, r, "/files/2444/195/TAAM-1.12.1-0.7.0.jar", http.StatusFound)
+		case "/files/2444/195/TAAM-1.12.1-0.7.0.jar":
+			w.Write([]byte(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	directURL, _ := url.Parse(server.URL + "/projects/taam/files/2444195/download")
+	fetcher := NewFetcher(nil)
+	// Smaller than the file, does not apply to downloads
+	fetcher.MaxBodyBytes = 100
+
+	testValues := []struct {
+		file     File
+		mismatch bool
+	}{
+		{File{Name: "unverified"}, false},
+		{File{Name: "md5", MD5: "d41d8cd98f00b204e9800998ecf8427e"}, true},
+		{File{Name: "size", SizeBytes: 2930, SizeInfo: "2.93 KB"}, false},
+		{File{Name: "wrong size", SizeBytes: 5000, SizeInfo: "5 KB"}, true},
+	}
+	for _, v := range testValues {
+		file := v.file
+		file.DirectURL = directURL
+
+		var buf bytes.Buffer
+		written, err := fetcher.DownloadFile(context.Background(), &file, &buf)
+		if v.mismatch != isError(err, ErrDownloadMismatch) || (!v.mismatch && err != nil) {
+			t.Errorf("Unexpected error for '%s': %v", file.Name, err)
+		}
+		if written != int64(len(content)) || buf.String() != content {
+			t.Errorf("Expected %d bytes for '%s', got %d", len(content), file.Name, written)
+		}
+	}
+	for _, userAgent := range userAgents {
+		if userAgent != DefaultUserAgent {
+			t.Errorf("Expected user agent '%s', got '%s'", DefaultUserAgent, userAgent)
+		}
+	}
+
+	// The correct hash
+	file := File{DirectURL: directURL, MD5: fmt.Sprintf("%x", md5.Sum([]byte(content)))}
+	_, err := fetcher.DownloadFile(context.Background(), &file, new(bytes.Buffer))
+	if err != nil {
+		t.Errorf("Expected the download to match the MD5, got %v", err)
+	}
+
+	// Error status
+	missingURL, _ := url.Parse(server.URL + "/projects/taam/files/1/download")
+	_, err = fetcher.DownloadFile(context.Background(), &File{DirectURL: missingURL}, new(bytes.Buffer))
+	if fetchErr, ok := asFetchError(err); !ok || fetchErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a *FetchError with status 404, got %v", err)
+	}
+}
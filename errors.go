@@ -0,0 +1,196 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrProjectNotFound is returned by the CurseForge parsers if the page is the not-found page of CurseForge,
+// i.e. the project URL is wrong or the project was deleted. It is usually wrapped with more context.
+// All wrapping errors of this package implement Unwrap() error, so check for it by unwrapping in a loop:
+//
+//	for err != nil && err != curse.ErrProjectNotFound {
+//		unwrapper, ok := err.(interface{ Unwrap() error })
+//		if !ok {
+//			break
+//		}
+//		err = unwrapper.Unwrap()
+//	}
+//
+// (On Go 1.13 and later, errors.Is does the same.)
+var ErrProjectNotFound = errors.New("project not found")
+
+// ErrBodyTooLarge is returned when a response body exceeds Fetcher.MaxBodyBytes.
+// It is usually wrapped in a *FetchError or with more context.
+var ErrBodyTooLarge = errors.New("response body exceeds the maximum size")
+
+// ParseError is returned by the parsers when a value could not be resolved from the page,
+// which usually means the page layout changed. Network errors are not reported as ParseError.
+type ParseError struct {
+	// The name of the value that was being resolved, e.g. "File/Date"
+	Field string
+	// The XPath used to resolve the value
+	XPath string
+	// The URL of the parsed document
+	DocumentURL string
+	// The underlying error, nil if the value was not found at all
+	Err error
+}
+
+// newParseError creates a new ParseError for the given document.
+func newParseError(documentURL *url.URL, field string, xpath string, err error) *ParseError {
+	parseErr := &ParseError{
+		Field: field,
+		XPath: xpath,
+		Err:   err,
+	}
+	if documentURL != nil {
+		parseErr.DocumentURL = documentURL.String()
+	}
+	return parseErr
+}
+
+func (e *ParseError) Error() string {
+	msg := fmt.Sprintf("error resolving value '%s' in '%s' (xpath: %s)", e.Field, e.DocumentURL, e.XPath)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying error, if any.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// FetchError is returned when a page could not be fetched, e.g. on network errors, timeouts
+// or an unexpected status. Check for it using a type assertion, e.g. fetchErr, ok := err.(*curse.FetchError),
+// unwrapping the error in a loop if it carries more context (see ErrProjectNotFound; on Go 1.13 and later, errors.As).
+// Inside of a *MultiError, check the errors of the failed items individually.
+// Failures to resolve values from a fetched page are reported as ParseError instead,
+// so network errors can be retried while layout changes are not.
+type FetchError struct {
+	// The URL of the page that was being fetched
+	URL string
+	// The HTTP status code, 0 if no response was received
+	StatusCode int
+	// The underlying error
+	Err error
+}
+
+func (e *FetchError) Error() string {
+	return fmt.Sprintf("error fetching '%s': %s", e.URL, e.Err.Error())
+}
+
+// Unwrap returns the underlying error.
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError adds context to err, like fmt.Errorf("context: %s", err) would.
+// A *ParseError or *FetchError is returned unchanged, so callers can still inspect it.
+// (It carries the field, XPath or URL of the document already.)
+// Other errors can still be unwrapped, e.g. to check for ErrProjectNotFound.
+func wrapError(err error, context string) error {
+	switch err.(type) {
+	case *ParseError, *FetchError:
+		return err
+	}
+	return &contextError{
+		context: context,
+		err:     err,
+	}
+}
+
+// contextError is an error with added context, created by wrapError.
+type contextError struct {
+	context string
+	err     error
+}
+
+func (e *contextError) Error() string {
+	return e.context + ": " + e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *contextError) Unwrap() error {
+	return e.err
+}
+
+// MultiError collects the errors of a batch operation, keyed by the URL or identifier of the failed item.
+// It is returned by the batch functions of this package, so the failed items can be inspected individually.
+type MultiError struct {
+	Errors map[string]error
+}
+
+// NewMultiError creates an empty MultiError.
+func NewMultiError() *MultiError {
+	return &MultiError{
+		Errors: make(map[string]error),
+	}
+}
+
+// Add records the error for the given key. Nil errors are ignored.
+// If an error was already recorded for the key, it is replaced.
+func (m *MultiError) Add(key string, err error) {
+	if err == nil {
+		return
+	}
+	if m.Errors == nil {
+		m.Errors = make(map[string]error)
+	}
+	m.Errors[key] = err
+}
+
+// HasErrors returns true if at least one error was recorded.
+func (m *MultiError) HasErrors() bool {
+	return m != nil && len(m.Errors) > 0
+}
+
+// ErrorOrNil returns m if any errors were recorded, nil otherwise.
+// Use this when returning a MultiError as error, to avoid a non-nil error interface holding no errors.
+func (m *MultiError) ErrorOrNil() error {
+	if !m.HasErrors() {
+		return nil
+	}
+	return m
+}
+
+// Error returns a summary of all recorded errors, one per line, sorted by key.
+func (m *MultiError) Error() string {
+	if !m.HasErrors() {
+		return "no errors"
+	}
+
+	keys := make([]string, 0, len(m.Errors))
+	for key := range m.Errors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d error(s) occurred:", len(keys))
+	for _, key := range keys {
+		fmt.Fprintf(&b, "\n* %s: %s", key, m.Errors[key].Error())
+	}
+	return b.String()
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestMultiError(t *testing.T) {
+	m := NewMultiError()
+	if m.HasErrors() {
+		t.Error("Empty MultiError should not have errors")
+	}
+	if m.ErrorOrNil() != nil {
+		t.Error("ErrorOrNil should return nil for an empty MultiError")
+	}
+
+	m.Add("https://example.com/b", errors.New("not found"))
+	m.Add("https://example.com/a", errors.New("timeout"))
+	m.Add("https://example.com/c", nil)
+
+	if !m.HasErrors() {
+		t.Error("MultiError should have errors")
+	}
+	if len(m.Errors) != 2 {
+		t.Errorf("Expected 2 errors, got %d", len(m.Errors))
+	}
+	if m.ErrorOrNil() == nil {
+		t.Error("ErrorOrNil should return the MultiError")
+	}
+
+	expected := "2 error(s) occurred:\n* https://example.com/a: timeout\n* https://example.com/b: not found"
+	if m.Error() != expected {
+		t.Errorf("Unexpected error summary:\n%s", m.Error())
+	}
+}
+
+func TestParseErrorFromParser(t *testing.T) {
+	// The files page has no header, so the header parser fails on the navbar
+	root := parseTestdata(t, "curseforge_files_taam.html")
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parseCFHeader(new(CurseForge), newPageLookup(nil, documentURL), root, CFOptionNone)
+	parseErr, ok := wrapError(err, "error processing CF header").(*ParseError)
+	if !ok {
+		t.Fatalf("Expected a *ParseError, got %T: %v", err, err)
+	}
+	if parseErr.Field != "navbar" {
+		t.Errorf("Expected field '%s', got '%s'", "navbar", parseErr.Field)
+	}
+	// All candidate xpaths are reported
+	if parseErr.XPath != "//nav[@class='e-header-nav'] | //nav[contains(@class, 'e-header-nav')]" {
+		t.Errorf("Expected xpath '%s', got '%s'", "//nav[@class='e-header-nav'] | //nav[contains(@class, 'e-header-nav')]", parseErr.XPath)
+	}
+	if parseErr.DocumentURL != documentURL.String() {
+		t.Errorf("Expected document URL '%s', got '%s'", documentURL, parseErr.DocumentURL)
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	err := wrapError(errors.New("connection reset"), "error fetching page")
+	if err.Error() != "error fetching page: connection reset" {
+		t.Errorf("Unexpected error message: %s", err.Error())
+	}
+	if _, ok := err.(*ParseError); ok {
+		t.Error("Plain errors should not become a *ParseError")
+	}
+}
+
+// asFetchError returns the first *FetchError in the chain of err, like errors.As.
+func asFetchError(err error) (*FetchError, bool) {
+	for err != nil {
+		if fetchErr, ok := err.(*FetchError); ok {
+			return fetchErr, true
+		}
+		wrapped, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return nil, false
+		}
+		err = wrapped.Unwrap()
+	}
+	return nil, false
+}
+
+func TestFetchError(t *testing.T) {
+	// Nothing is listening on a closed server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	projectURL, _ := url.Parse(server.URL + "/projects/taam")
+
+	for _, options := range []CurseForgeOptions{CFOptionNone, CFOptionParallel} {
+		fetcher := NewFetcher(nil)
+		fetcher.MaxAttempts = 1
+		_, err := fetcher.FetchCurseForge(projectURL, CFSectionOverview|CFSectionFiles, options)
+		fetchErr, ok := asFetchError(err)
+		if !ok {
+			t.Fatalf("Expected a *FetchError for options %s, got %T: %v", options, err, err)
+		}
+		if fetchErr.URL != projectURL.String() || fetchErr.StatusCode != 0 || fetchErr.Err == nil {
+			t.Errorf("Unexpected fetch error %+v", fetchErr)
+		}
+		if _, ok := err.(*ParseError); ok {
+			t.Error("Fetch errors should not be a *ParseError")
+		}
+	}
+
+	// Error pages are not parsed, e.g. a 503 page after all retries
+	for _, status := range []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusForbidden} {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte("<html><body><h1>Service Unavailable</h1></body></html>"))
+		}))
+		projectURL, _ = url.Parse(server.URL + "/projects/taam")
+
+		for _, sections := range []CurseForgeSections{CFSectionHeader, CFSectionOverview} {
+			for _, options := range []CurseForgeOptions{CFOptionNone, CFOptionParallel} {
+				fetcher := NewFetcher(nil)
+				fetcher.MaxAttempts = 1
+				_, err := fetcher.FetchCurseForge(projectURL, sections, options)
+				fetchErr, ok := asFetchError(err)
+				if !ok || fetchErr.StatusCode != status {
+					t.Errorf("Expected a *FetchError with status %d for sections %s and options %s, got %T: %v", status, sections, options, err, err)
+				}
+			}
+		}
+		server.Close()
+	}
+
+	// Returned unchanged, like a *ParseError
+	fetchErr := &FetchError{URL: "https://example.com", Err: errors.New("connection reset")}
+	if wrapError(fetchErr, "error fetching page") != fetchErr {
+		t.Error("A *FetchError should be returned unchanged")
+	}
+	if fetchErr.Error() != "error fetching 'https://example.com': connection reset" {
+		t.Errorf("Unexpected error message: %s", fetchErr.Error())
+	}
+}
+
+// isError reports whether target is in the chain of err, like errors.Is.
+// (Implemented here, as errors.Is is not available on all tested Go versions.)
+func isError(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+		wrapped, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = wrapped.Unwrap()
+	}
+	return false
+}
+
+func TestProjectNotFound(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_not_found.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/does-not-exist")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(documentURL, f, true, CFSectionOverview, CFOptionNone)
+	if !isError(err, ErrProjectNotFound) {
+		t.Errorf("Expected ErrProjectNotFound, got %v", err)
+	}
+
+	// Also through FetchCurseForge, with the status 404 and with status 200
+	for _, status := range []int{http.StatusNotFound, http.StatusOK} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			page, _ := ioutil.ReadFile("testdata/curseforge_not_found.html")
+			w.WriteHeader(status)
+			w.Write(page)
+		}))
+
+		projectURL, _ := url.Parse(server.URL + "/projects/does-not-exist")
+		var sections CurseForgeSections = CFSectionOverview | CFSectionFiles
+		for _, options := range []CurseForgeOptions{CFOptionNone, CFOptionParallel} {
+			_, err = NewFetcher(nil).FetchCurseForge(projectURL, sections, options)
+			if !isError(err, ErrProjectNotFound) {
+				t.Errorf("Expected ErrProjectNotFound for status %d and options %s, got %v", status, options, err)
+			}
+		}
+		server.Close()
+	}
+
+	// A 404 status is enough, even without the not-found page
+	server := httptest.NewServer(http.NotFoundHandler())
+	projectURL, _ := url.Parse(server.URL + "/projects/does-not-exist")
+	for _, sections := range []CurseForgeSections{CFSectionHeader, CFSectionOverview} {
+		_, err = NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionNone)
+		if !isError(err, ErrProjectNotFound) {
+			t.Errorf("Expected ErrProjectNotFound for sections %s, got %v", sections, err)
+		}
+	}
+	server.Close()
+
+	// Context is kept in the message
+	err = wrapError(ErrProjectNotFound, "error parsing 'x'")
+	if err.Error() != "error parsing 'x': project not found" {
+		t.Errorf("Unexpected error message: %s", err.Error())
+	}
+}
@@ -0,0 +1,673 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// Fetcher performs the HTTP requests of this package.
+//
+// The Client (including its Transport, redirect policy, cookie jar and timeout)
+// is used as-is for every request. The Fetcher never modifies or replaces it,
+// so any retries, metrics or tracing implemented in a custom http.RoundTripper
+// apply to all requests made through this Fetcher.
+type Fetcher struct {
+	// Client is used to perform all requests.
+	// If nil, http.DefaultClient is used.
+	Client *http.Client
+
+	// EnableAutoConsent enables handling of the cookie/consent interstitial
+	// that CurseForge shows to first-time visitors from some regions.
+	// If a consent page is received, ConsentCookie is stored in the cookie jar
+	// of the Client (or sent with the request, if the Client has no jar)
+	// and the request is retried once.
+	// Only HTML responses are checked (and buffered for that), other responses are passed on as-is.
+	EnableAutoConsent bool
+	// ConsentCookie is the cookie set when EnableAutoConsent is on.
+	// If nil, DefaultConsentCookie is used.
+	ConsentCookie *http.Cookie
+
+	// Cookies are sent with every request, including retries and subsequent pages,
+	// e.g. a consent or session cookie for gated projects. See WithCookies.
+	// To keep the cookies set by the server, use a Client with a cookie jar (net/http/cookiejar) instead.
+	Cookies []*http.Cookie
+
+	// MaxAttempts is the maximum number of attempts for a request answered
+	// with 429 (Too Many Requests) or a 5xx status. Values below 2 disable retries.
+	MaxAttempts int
+	// RetryBaseDelay is the delay before the first retry. It is doubled for every further retry.
+	// A Retry-After header sent by the server takes precedence.
+	RetryBaseDelay time.Duration
+	// MaxRetryDelay is the longest delay waited for before a retry. Longer backoff delays are shortened to it.
+	// If the server requests a longer delay with Retry-After, the request is not retried
+	// and the last response is returned. If 0, DefaultMaxRetryDelay is used.
+	MaxRetryDelay time.Duration
+
+	// UserAgent is sent with every request, including subsequent files pages.
+	// If empty, DefaultUserAgent is used.
+	UserAgent string
+
+	// AcceptLanguage is sent with every request, so the pages are not localized
+	// (release types, number & date formats) depending on the location of the server.
+	// The parsers expect English pages. If empty, DefaultAcceptLanguage is used.
+	AcceptLanguage string
+
+	// MaxFilesPages limits the number of files pages loaded by the files parsers, including the first page.
+	// e.g. 3 loads the files of the 3 most recent pages only. 0 loads all pages.
+	// CFOptionFilesNoPagination still loads the first page only.
+	MaxFilesPages uint64
+
+	// MaxBodyBytes limits the size of every response body (after decompression),
+	// as the pages are loaded into memory completely for parsing.
+	// Reading beyond it fails with ErrBodyTooLarge. 0 disables the limit.
+	MaxBodyBytes int64
+
+	// Limiter limits the rate of all requests of this Fetcher, including retries and subsequent pages.
+	// Every request waits for the limiter before it is sent. If nil, requests are not limited.
+	// See SetRateLimit.
+	Limiter RateLimiter
+
+	// Cache enables conditional requests if set. Pages answered with an ETag or Last-Modified header
+	// are stored, and sent again if the server answers a later request with 304 (Not Modified).
+	// Such responses are marked, see IsNotModified. If nil, nothing is cached.
+	Cache ResponseCache
+
+	// DebugHook is called by the parsers for every value they try to resolve on the pages of this Fetcher, if set.
+	// Use it to find the selectors that stopped matching after a layout change, e.g. by logging all unmatched fields.
+	// Optional values that are missing on a page are reported as well.
+	// With CFOptionParallel it is called from multiple goroutines. The package-level parse functions
+	// (e.g. ParseCurseForgeReader) report to the DebugHook of DefaultFetcher. nil disables the hook.
+	DebugHook DebugFunc
+
+	// ThousandsSeparator is the thousands separator used for parsing counts
+	// (downloads, likes, favorites, ratings) from the pages of this Fetcher.
+	// Set this to '.' when parsing localized pages, e.g. German "1.234.567".
+	// The package-level parse functions use the one of DefaultFetcher. If 0, DefaultThousandsSeparator is used.
+	ThousandsSeparator rune
+
+	// RawText disables the cleanup pass over the parsed text values (titles, names, versions, ...)
+	// of the pages of this Fetcher. By default, leftover HTML entities (e.g. "Tom&#39;s Mod") are unescaped,
+	// non-breaking spaces are replaced and runs of whitespace are collapsed to a single space, see NormalizeString.
+	// Set this to get the text exactly as extracted from the page.
+	// The package-level parse functions use the setting of DefaultFetcher.
+	RawText bool
+
+	// ctx aborts all requests of this Fetcher when done, see withContext. nil if not bound to a context.
+	ctx context.Context
+	// filesSince stops loading further files pages, see withFilesSince. The zero time loads all pages.
+	filesSince time.Time
+	// skipFilesAPI does not load the files of API-backed files pages, see ValidateCurseForgeLayout.
+	skipFilesAPI bool
+}
+
+// Defaults used by NewFetcher.
+const (
+	DefaultMaxAttempts    = 3
+	DefaultRetryBaseDelay = 2 * time.Second
+	DefaultMaxRetryDelay  = time.Minute
+	DefaultUserAgent      = "Go-http-client/1.1 (compatible; curse-parser)"
+	DefaultAcceptLanguage = "en-US,en"
+	DefaultMaxBodyBytes   = 10 << 20
+)
+
+// DefaultThousandsSeparator is the thousands separator used for parsing counts,
+// unless the Fetcher specifies its own.
+const DefaultThousandsSeparator = ','
+
+// DefaultConsentCookie is the cookie used to accept the consent interstitial,
+// unless the Fetcher specifies its own.
+var DefaultConsentCookie = &http.Cookie{
+	Name:  "cookie-consent",
+	Value: "accepted",
+}
+
+// NewFetcher creates a new Fetcher using the given client.
+// Pass nil to use http.DefaultClient.
+func NewFetcher(client *http.Client) *Fetcher {
+	return &Fetcher{
+		Client:         client,
+		MaxAttempts:    DefaultMaxAttempts,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		MaxRetryDelay:  DefaultMaxRetryDelay,
+		UserAgent:      DefaultUserAgent,
+		AcceptLanguage: DefaultAcceptLanguage,
+		MaxBodyBytes:   DefaultMaxBodyBytes,
+	}
+}
+
+// DefaultFetcher is used by FetchPage, FetchCurseForge and for fetching subsequent files pages.
+// Replace its Client to use a custom http.Client or http.RoundTripper for all of these.
+// It sends every request only once; set its MaxAttempts to retry failed requests.
+var DefaultFetcher = newDefaultFetcher()
+
+// newDefaultFetcher creates the Fetcher used by the package-level functions.
+// Retries are disabled to keep their behaviour unchanged.
+func newDefaultFetcher() *Fetcher {
+	f := NewFetcher(&http.Client{})
+	f.MaxAttempts = 1
+	return f
+}
+
+// SetHTTPClient replaces the client of DefaultFetcher, which is used by all package-level functions.
+// Use this to set a custom transport, proxy or timeouts.
+// Passing nil resets it to http.DefaultClient.
+func SetHTTPClient(c *http.Client) {
+	DefaultFetcher.Client = c
+}
+
+// SetUserAgent replaces the user agent of DefaultFetcher, which is used by all package-level functions.
+// e.g. to include a contact URL as requested by the crawl policy of the site.
+// Passing an empty string resets it to DefaultUserAgent.
+func SetUserAgent(userAgent string) {
+	DefaultFetcher.UserAgent = userAgent
+}
+
+// SetAcceptLanguage replaces the Accept-Language header of DefaultFetcher, which is used by all package-level functions.
+// Passing an empty string resets it to DefaultAcceptLanguage.
+func SetAcceptLanguage(acceptLanguage string) {
+	DefaultFetcher.AcceptLanguage = acceptLanguage
+}
+
+// SetCookies replaces the cookies of DefaultFetcher, which is used by all package-level functions.
+// See Fetcher.Cookies. Passing nil removes all cookies.
+func SetCookies(cookies []*http.Cookie) {
+	DefaultFetcher.Cookies = cookies
+}
+
+// WithCookies returns a copy of this Fetcher that additionally sends the given cookies with every request.
+// This Fetcher is not modified. See Fetcher.Cookies.
+func (f *Fetcher) WithCookies(cookies []*http.Cookie) *Fetcher {
+	withCookies := *f
+	withCookies.Cookies = append(append([]*http.Cookie(nil), f.Cookies...), cookies...)
+	return &withCookies
+}
+
+// SetRateLimit limits the requests of this Fetcher to requestsPerSecond, allowing bursts of up to burst requests.
+// A requestsPerSecond of 0 or less removes the limit.
+func (f *Fetcher) SetRateLimit(requestsPerSecond float64, burst int) {
+	if requestsPerSecond <= 0 {
+		f.Limiter = nil
+		return
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	f.Limiter = newTokenBucket(requestsPerSecond, burst)
+}
+
+// SetRateLimit limits the requests of DefaultFetcher, which is used by all package-level functions.
+// See Fetcher.SetRateLimit.
+func SetRateLimit(requestsPerSecond float64, burst int) {
+	DefaultFetcher.SetRateLimit(requestsPerSecond, burst)
+}
+
+// FetchPage performs a simple http get, sending the UserAgent of this Fetcher.
+// The request is sent using the Client of this Fetcher, without touching its Transport.
+// Errors are returned as *FetchError. Responses with an error status are returned as-is.
+func (f *Fetcher) FetchPage(url string) (*http.Response, error) {
+	return f.fetchPage(f.requestContext(), url)
+}
+
+// checkPageStatus returns a *FetchError if resp has a status other than 2xx, and closes its body in that case.
+// Such a response (e.g. a 503 page after all retries) is an error page that must not be parsed,
+// it would be reported as a ParseError otherwise.
+func checkPageStatus(url string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	resp.Body.Close()
+	return &FetchError{
+		URL:        url,
+		StatusCode: resp.StatusCode,
+		Err:        fmt.Errorf("unexpected status %s", resp.Status),
+	}
+}
+
+// withContext returns a copy of this Fetcher that aborts all requests when ctx is done.
+// This covers all requests of a call, e.g. subsequent files pages, without passing ctx to every parser.
+func (f *Fetcher) withContext(ctx context.Context) *Fetcher {
+	bound := *f
+	bound.ctx = ctx
+	return &bound
+}
+
+// withFilesSince returns a copy of this Fetcher that stops loading further files pages
+// once a page lists a file uploaded before since, see FetchCurseForgeSince.
+func (f *Fetcher) withFilesSince(since time.Time) *Fetcher {
+	bound := *f
+	bound.filesSince = since
+	return &bound
+}
+
+// requestContext returns the context the requests of this Fetcher are bound to, see withContext.
+func (f *Fetcher) requestContext() context.Context {
+	if f.ctx == nil {
+		return context.Background()
+	}
+	return f.ctx
+}
+
+// fetchPage is FetchPage, aborting the request(s) when ctx is done.
+// All errors are returned as *FetchError.
+func (f *Fetcher) fetchPage(ctx context.Context, url string) (*http.Response, error) {
+	resp, err := f.fetchPageConsent(ctx, url)
+	if err != nil {
+		return nil, &FetchError{URL: url, Err: err}
+	}
+	return resp, nil
+}
+
+// fetchPageConsent performs the request(s) of fetchPage, accepting the consent interstitial if enabled.
+func (f *Fetcher) fetchPageConsent(ctx context.Context, url string) (*http.Response, error) {
+	resp, err := f.do(ctx, url, nil)
+	if err != nil || !f.EnableAutoConsent {
+		return resp, err
+	}
+
+	// The interstitial is an HTML page, other responses (e.g. API or downloads) are passed on unbuffered
+	if !isHTMLResponse(resp) {
+		return resp, nil
+	}
+
+	// Check for the consent interstitial; this requires reading the body.
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, wrapError(err, "Error reading response body")
+	}
+	if !isConsentPage(body) {
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return resp, nil
+	}
+
+	// Accept & retry once
+	cookie := f.ConsentCookie
+	if cookie == nil {
+		cookie = DefaultConsentCookie
+	}
+	jar := f.client().Jar
+	if jar == nil {
+		return f.do(ctx, url, cookie)
+	}
+	jar.SetCookies(resp.Request.URL, []*http.Cookie{cookie})
+	return f.do(ctx, url, nil)
+}
+
+// do performs a GET request, optionally adding the given cookie to the request.
+// Responses with status 429 or 5xx are retried, up to MaxAttempts.
+// If all attempts fail, the last response is returned.
+func (f *Fetcher) do(ctx context.Context, url string, cookie *http.Cookie) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := f.doOnce(ctx, url, cookie)
+		if err != nil || attempt >= f.MaxAttempts || !isRetryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		delay := retryAfter(resp, time.Now())
+		if delay > f.maxRetryDelay() {
+			// Not worth waiting for
+			return resp, nil
+		}
+		if delay < 0 {
+			delay = f.RetryBaseDelay << uint(attempt-1)
+			if delay > f.maxRetryDelay() || delay < 0 {
+				delay = f.maxRetryDelay()
+			}
+		}
+		// Drain the body so the connection can be reused
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// doOnce performs a single GET request, optionally adding the given cookie to the request.
+func (f *Fetcher) doOnce(ctx context.Context, url string, cookie *http.Cookie) (*http.Response, error) {
+	if f.Limiter != nil {
+		err := f.Limiter.Wait(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Error waiting for rate limit: %s", err.Error())
+		}
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("Error creating http request: %s", err.Error())
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", f.userAgent())
+	req.Header.Set("Accept-Language", f.acceptLanguage())
+	for _, c := range f.Cookies {
+		// The consent cookie takes precedence
+		if c != nil && (cookie == nil || c.Name != cookie.Name) {
+			req.AddCookie(c)
+		}
+	}
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	cached := f.setConditionalHeaders(req, url)
+
+	resp, err := f.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	err = decodeResponseBody(resp)
+	if err == nil {
+		err = f.limitResponseBody(resp)
+	}
+	if err == nil {
+		err = f.applyCache(url, resp, cached)
+	}
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// decodeResponseBody replaces the body of resp with a decoding reader if the response is compressed.
+// net/http only decodes responses transparently if it requested compression itself,
+// which is not the case if Accept-Encoding is set by a custom client or transport.
+// Supported are gzip and deflate (zlib-wrapped or raw), other encodings are left as-is.
+func decodeResponseBody(resp *http.Response) error {
+	var decoder io.ReadCloser
+	var err error
+
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		decoder, err = gzip.NewReader(resp.Body)
+		if err != nil {
+			return fmt.Errorf("Error decoding gzip response: %s", err.Error())
+		}
+	case "deflate":
+		// Most servers send zlib-wrapped data as specified, some send raw deflate data
+		buffered := bufio.NewReader(resp.Body)
+		header, _ := buffered.Peek(2)
+		if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			decoder, err = zlib.NewReader(buffered)
+			if err != nil {
+				return fmt.Errorf("Error decoding deflate response: %s", err.Error())
+			}
+		} else {
+			decoder = flate.NewReader(buffered)
+		}
+	default:
+		return nil
+	}
+
+	resp.Body = &decodedBody{decoder, resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// decodedBody reads from a decoder and closes both the decoder and the underlying body.
+type decodedBody struct {
+	decoder io.ReadCloser
+	body    io.ReadCloser
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	return b.decoder.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	b.decoder.Close()
+	return b.body.Close()
+}
+
+// limitResponseBody replaces the body of resp with a reader failing with ErrBodyTooLarge
+// beyond MaxBodyBytes. Fails right away if the announced length exceeds it.
+func (f *Fetcher) limitResponseBody(resp *http.Response) error {
+	if f.MaxBodyBytes <= 0 {
+		return nil
+	}
+	if resp.ContentLength > f.MaxBodyBytes {
+		return ErrBodyTooLarge
+	}
+	resp.Body = &limitedBody{
+		reader: io.LimitReader(resp.Body, f.MaxBodyBytes+1),
+		body:   resp.Body,
+		limit:  f.MaxBodyBytes,
+	}
+	return nil
+}
+
+// limitedBody reads up to limit bytes from the body, reading more fails with ErrBodyTooLarge.
+type limitedBody struct {
+	// Limited to limit+1 bytes, to detect exceeding the limit
+	reader io.Reader
+	body   io.ReadCloser
+	limit  int64
+	read   int64
+	// Set once the limit was exceeded, all further reads fail
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, ErrBodyTooLarge
+	}
+	n, err := b.reader.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		b.exceeded = true
+		// Only the bytes up to the limit are passed on
+		n -= int(b.read - b.limit)
+		if n < 0 {
+			n = 0
+		}
+		return n, ErrBodyTooLarge
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
+
+// lastFilesPage returns the number of the last files page to be loaded (counting from 1),
+// given the number of pages available. See MaxFilesPages.
+func (f *Fetcher) lastFilesPage(pageCount uint64) uint64 {
+	if f != nil && f.MaxFilesPages > 0 && f.MaxFilesPages < pageCount {
+		return f.MaxFilesPages
+	}
+	return pageCount
+}
+
+// beforeFilesSince returns true if the file was uploaded before filesSince,
+// i.e. no further files pages need to be loaded. Files without a parsed date are ignored.
+func (f *Fetcher) beforeFilesSince(file *File) bool {
+	return f != nil && !f.filesSince.IsZero() && file.hasDate() && file.Date.Before(f.filesSince)
+}
+
+// reachedFilesSince returns true if any of the files was uploaded before filesSince, see beforeFilesSince.
+func (f *Fetcher) reachedFilesSince(files []File) bool {
+	for idx := range files {
+		if f.beforeFilesSince(&files[idx]) {
+			return true
+		}
+	}
+	return false
+}
+
+// userAgent returns the user agent to be sent with requests.
+func (f *Fetcher) userAgent() string {
+	if f.UserAgent == "" {
+		return DefaultUserAgent
+	}
+	return f.UserAgent
+}
+
+// maxRetryDelay returns the longest delay waited for before a retry.
+func (f *Fetcher) maxRetryDelay() time.Duration {
+	if f.MaxRetryDelay <= 0 {
+		return DefaultMaxRetryDelay
+	}
+	return f.MaxRetryDelay
+}
+
+// acceptLanguage returns the Accept-Language header to be sent with requests.
+func (f *Fetcher) acceptLanguage() string {
+	if f.AcceptLanguage == "" {
+		return DefaultAcceptLanguage
+	}
+	return f.AcceptLanguage
+}
+
+// thousandsSeparator returns the thousands separator used for parsing counts.
+func (f *Fetcher) thousandsSeparator() rune {
+	if f.ThousandsSeparator == 0 {
+		return DefaultThousandsSeparator
+	}
+	return f.ThousandsSeparator
+}
+
+// client returns the client to be used for requests.
+func (f *Fetcher) client() *http.Client {
+	if f.Client == nil {
+		return http.DefaultClient
+	}
+	return f.Client
+}
+
+// FetchDocument fetches the given page and parses it to a document root node.
+// Use ExtractString(), ExtractURL() & co. to get values from it.
+// Responses with a status other than 2xx are returned as *FetchError.
+func (f *Fetcher) FetchDocument(url string) (*xmlpath.Node, error) {
+	resp, err := f.FetchPage(url)
+	if err != nil {
+		return nil, err
+	}
+	err = checkPageStatus(url, resp)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	root, err := xmlpath.ParseHTML(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing xml/http: %s", err.Error())
+	}
+	return root, nil
+}
+
+// FetchPage performs a simple http get, sending the UserAgent of DefaultFetcher.
+// The request is sent using DefaultFetcher.
+func FetchPage(url string) (*http.Response, error) {
+	return DefaultFetcher.FetchPage(url)
+}
+
+// FetchDocument fetches the given page and parses it to a document root node.
+// The request is sent using DefaultFetcher.
+func FetchDocument(url string) (*xmlpath.Node, error) {
+	return DefaultFetcher.FetchDocument(url)
+}
+
+// newFetchMeta describes resp, which was received at fetchedAt.
+func newFetchMeta(resp *http.Response, fetchedAt time.Time) *FetchMeta {
+	meta := &FetchMeta{
+		StatusCode: resp.StatusCode,
+		FetchedAt:  fetchedAt,
+	}
+	if resp.Request != nil {
+		meta.FinalURL = cloneURL(resp.Request.URL)
+	}
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		meta.ServerTime = date
+	}
+	return meta
+}
+
+// responseURL returns the URL resp was received from after following all redirects,
+// or requestURL if the response does not record its request (e.g. a response built by hand).
+// Links of the page have to be resolved against this URL, not the requested one.
+func responseURL(resp *http.Response, requestURL *url.URL) *url.URL {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return requestURL
+	}
+	return resp.Request.URL
+}
+
+// isRetryableStatus returns true for the status codes worth retrying: 429 and all 5xx codes.
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || (status >= 500 && status <= 599)
+}
+
+// retryAfter returns the delay requested by the Retry-After header of resp,
+// given in seconds or as HTTP date. Returns -1 if the header is missing or invalid.
+func retryAfter(resp *http.Response, now time.Time) time.Duration {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return -1
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := date.Sub(now); delay > 0 {
+			return delay
+		}
+		return 0
+	}
+	return -1
+}
+
+// isHTMLResponse returns true if resp is declared as HTML page.
+func isHTMLResponse(resp *http.Response) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Type"))), "text/html")
+}
+
+// isConsentPage returns true if the given page is the consent interstitial instead of actual content.
+// It is detected by the form submitting the consent. Cookie banners on real pages
+// (e.g. id="onetrust-consent-sdk") are no interstitial.
+func isConsentPage(body []byte) bool {
+	root, err := xmlpath.ParseHTML(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	// Real pages always have the header navigation
+	if _, ok := pathCache.NodeAny(root, cfNavbarPaths...); ok {
+		return false
+	}
+	_, ok := pathCache.Node(root, "//form[contains(@action, 'consent')]")
+	return ok
+}
@@ -0,0 +1,906 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingTransport counts the requests passing through it.
+type countingTransport struct {
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.count++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestFetcherUsesCustomTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	fetcher := NewFetcher(&http.Client{Transport: transport})
+
+	resp, err := fetcher.FetchPage(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if transport.count != 1 {
+		t.Errorf("Expected 1 request through the custom transport, got %d", transport.count)
+	}
+	if fetcher.Client.Transport != transport {
+		t.Errorf("Custom transport was replaced by FetchPage")
+	}
+}
+
+func TestFetcherAutoConsent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie(DefaultConsentCookie.Name); err != nil {
+			w.Write([]byte(`<html><body><form id="consent-form" action="/consent"><button>Accept</button></form></body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><body><nav class="e-header-nav"></nav></body></html>`))
+	}))
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetchers := []*Fetcher{
+		{EnableAutoConsent: true},
+		{EnableAutoConsent: true, Client: &http.Client{Jar: jar}},
+	}
+	for idx, fetcher := range fetchers {
+		resp, err := fetcher.FetchPage(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if isConsentPage(body) {
+			t.Errorf("Fetcher %d returned the consent page", idx)
+		}
+	}
+}
+
+func TestIsConsentPage(t *testing.T) {
+	pages := map[string]bool{
+		`<html><body><form id="consent-form" action="/consent"><button>Accept</button></form></body></html>`:  true,
+		`<html><body><div id="onetrust-consent-sdk"></div><h1>Pawn</h1></body></html>`:                        false,
+		`<html><body><nav class="e-header-nav is-sticky"></nav><form action="/consent"></form></body></html>`: false,
+		`{"data":[{"id":2444195,"displayName":"consent"}]}`:                                                   false,
+	}
+	for page, expected := range pages {
+		if isConsentPage([]byte(page)) != expected {
+			t.Errorf("Expected %t for page %s", expected, page)
+		}
+	}
+
+	// Responses other than HTML are not checked, nor retried
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`<form action="/consent"></form>`))
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{EnableAutoConsent: true}
+	resp, err := fetcher.FetchPage(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if requests != 1 {
+		t.Errorf("Expected %d request, got %d", 1, requests)
+	}
+}
+
+func TestFetchDocument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div id="custom"> Custom Value </div><a class="link" href="/members/founderio">founderio</a></body></html>`))
+	}))
+	defer server.Close()
+
+	root, err := NewFetcher(nil).FetchDocument(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, ok, err := ExtractString(root, "//div[@id='custom']")
+	if err != nil || !ok || value != "Custom Value" {
+		t.Errorf("Expected '%s', got '%s' (%v)", "Custom Value", value, err)
+	}
+
+	base, err := url.Parse("https://minecraft.curseforge.com/projects/taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := ExtractURL(root, "//a[@class='link']/@href", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.String() != "https://minecraft.curseforge.com/members/founderio" {
+		t.Errorf("Expected '%s', got '%s'", "https://minecraft.curseforge.com/members/founderio", link)
+	}
+
+	// Invalid xpaths are an error, not a panic, and are not cached
+	invalid := "//div[@id='custom'"
+	if _, _, err := ExtractString(root, invalid); err == nil {
+		t.Error("Expected an error for an invalid xpath")
+	}
+	if _, _, err := ExtractNode(root, invalid); err == nil {
+		t.Error("Expected an error for an invalid xpath")
+	}
+	if _, err := ExtractIter(root, invalid); err == nil {
+		t.Error("Expected an error for an invalid xpath")
+	}
+	if _, err := ExtractURL(root, invalid, base); err == nil {
+		t.Error("Expected an error for an invalid xpath")
+	}
+	pathCache.mutex.RLock()
+	_, cached := pathCache.paths[invalid]
+	pathCache.mutex.RUnlock()
+	if cached {
+		t.Error("Expected the invalid xpath not to be cached")
+	}
+}
+
+func TestFetcherDecodesCompressedResponses(t *testing.T) {
+	page := `<html><body><div id="custom"> Custom Value </div></body></html>`
+	compressors := map[string]func(io.Writer) io.WriteCloser{
+		"gzip":    func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) },
+		"deflate": func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) },
+		// Raw deflate data, as sent by some servers
+		"Deflate": func(w io.Writer) io.WriteCloser {
+			fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return fw
+		},
+	}
+
+	for encoding, compressor := range compressors {
+		var compressed bytes.Buffer
+		cw := compressor(&compressed)
+		cw.Write([]byte(page))
+		cw.Close()
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", encoding)
+			w.Write(compressed.Bytes())
+		}))
+
+		// Transparent decompression is disabled, as it is when sending Accept-Encoding manually
+		fetcher := NewFetcher(&http.Client{Transport: &http.Transport{DisableCompression: true}})
+		root, err := fetcher.FetchDocument(server.URL)
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: %s", encoding, err.Error())
+			continue
+		}
+		value, ok, err := ExtractString(root, "//div[@id='custom']")
+		if err != nil || !ok || value != "Custom Value" {
+			t.Errorf("%s: Expected '%s', got '%s'", encoding, "Custom Value", value)
+		}
+	}
+}
+
+func TestFetcherFilesPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &countingTransport{}
+	fetcher := NewFetcher(&http.Client{Transport: transport})
+
+	resp, err := fetcher.FetchPage(filesURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fixture has no header, so parse it without
+	results := new(CurseForge)
+	err = results.parseCurseForge(fetcher, filesURL, resp.Body, false, CFSectionFiles, CFOptionNone)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First page and the two subsequent pages
+	if transport.count != 3 {
+		t.Errorf("Expected 3 requests through the custom transport, got %d", transport.count)
+	}
+	if len(results.Downloads) != 9 {
+		t.Errorf("Expected 9 files, got %d", len(results.Downloads))
+	}
+}
+
+func TestFetcherMaxFilesPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fixture lists 3 pages with 3 files each
+	for _, maxPages := range []uint64{1, 2, 5} {
+		expectedPages := maxPages
+		if expectedPages > 3 {
+			expectedPages = 3
+		}
+
+		transport := &countingTransport{}
+		fetcher := NewFetcher(&http.Client{Transport: transport})
+		fetcher.MaxFilesPages = maxPages
+
+		resp, err := fetcher.FetchPage(filesURL.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := new(CurseForge)
+		err = results.parseCurseForge(fetcher, filesURL, resp.Body, false, CFSectionFiles, CFOptionNone)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uint64(transport.count) != expectedPages {
+			t.Errorf("Expected %d requests with MaxFilesPages %d, got %d", expectedPages, maxPages, transport.count)
+		}
+		if uint64(len(results.Downloads)) != expectedPages*3 {
+			t.Errorf("Expected %d files with MaxFilesPages %d, got %d", expectedPages*3, maxPages, len(results.Downloads))
+		}
+
+		// Same for the streaming parser
+		transport.count = 0
+		var streamed uint64
+		err = fetcher.FetchCurseForgeFilesStream(filesURL, CFOptionNone, func(File) bool {
+			streamed++
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uint64(transport.count) != expectedPages || streamed != expectedPages*3 {
+			t.Errorf("Expected %d requests and %d files streamed with MaxFilesPages %d, got %d and %d", expectedPages, expectedPages*3, maxPages, transport.count, streamed)
+		}
+	}
+}
+
+func TestFetchCurseForgeSince(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The fixture lists 3 pages, each with files from Sep 2016 to Aug 2017
+	testValues := map[int64]int{
+		0:          3,
+		1400000000: 3,
+		1480000000: 1,
+		1600000000: 1,
+	}
+	transport := &countingTransport{}
+	fetcher := NewFetcher(&http.Client{Transport: transport})
+	for since, expectedPages := range testValues {
+		var sinceTime time.Time
+		if since > 0 {
+			sinceTime = time.Unix(since, 0)
+		}
+
+		transport.count = 0
+		results, err := fetcher.FetchCurseForgeSince(projectURL, CFSectionFiles, CFOptionSkipHeader, sinceTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if transport.count != expectedPages || len(results.Downloads) != expectedPages*3 {
+			t.Errorf("Expected %d requests and %d files since %d, got %d and %d", expectedPages, expectedPages*3, since, transport.count, len(results.Downloads))
+		}
+	}
+
+	// The cutoff only applies to the call, not to the shared Fetcher
+	transport.count = 0
+	_, err = fetcher.FetchCurseForge(projectURL, CFSectionFiles, CFOptionSkipHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.count != 3 {
+		t.Errorf("Expected %d requests without cutoff, got %d", 3, transport.count)
+	}
+}
+
+func TestFetcherCommentsPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/curseforge_comments_taam.html")
+	}))
+	defer server.Close()
+
+	commentsURL, err := url.Parse(server.URL + "/projects/taam/comments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport := &countingTransport{}
+	fetcher := NewFetcher(&http.Client{Transport: transport})
+
+	resp, err := fetcher.FetchPage(commentsURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The fixture has no header, so parse it without
+	results := new(CurseForge)
+	err = results.parseCurseForge(fetcher, commentsURL, resp.Body, false, CFSectionComments, CFOptionNone)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First page and the subsequent page
+	if transport.count != 2 {
+		t.Errorf("Expected 2 requests through the custom transport, got %d", transport.count)
+	}
+	if len(results.Comments) != 4 {
+		t.Errorf("Expected 4 comments, got %d", len(results.Comments))
+	}
+}
+
+func TestSetHTTPClient(t *testing.T) {
+	previous := DefaultFetcher.Client
+	defer SetHTTPClient(previous)
+
+	client := &http.Client{Transport: &countingTransport{}}
+	SetHTTPClient(client)
+	if DefaultFetcher.Client != client {
+		t.Error("SetHTTPClient did not replace the client of DefaultFetcher")
+	}
+}
+
+func TestFetcherUserAgent(t *testing.T) {
+	var userAgents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents = append(userAgents, r.UserAgent())
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const userAgent = "curse-parser-test (+https://example.com/contact)"
+	fetcher := NewFetcher(nil)
+	fetcher.UserAgent = userAgent
+
+	resp, err := fetcher.FetchPage(filesURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := new(CurseForge)
+	err = results.parseCurseForge(fetcher, filesURL, resp.Body, false, CFSectionFiles, CFOptionNone)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// First page and the two subsequent pages
+	if len(userAgents) != 3 {
+		t.Fatalf("Expected 3 requests, got %d", len(userAgents))
+	}
+	for _, ua := range userAgents {
+		if ua != userAgent {
+			t.Errorf("Expected user agent '%s', got '%s'", userAgent, ua)
+		}
+	}
+
+	// The default is used if not set
+	if (&Fetcher{}).userAgent() != DefaultUserAgent {
+		t.Errorf("Expected user agent '%s', got '%s'", DefaultUserAgent, (&Fetcher{}).userAgent())
+	}
+}
+
+func TestSetUserAgent(t *testing.T) {
+	previous := DefaultFetcher.UserAgent
+	defer SetUserAgent(previous)
+
+	SetUserAgent("curse-parser-test")
+	if DefaultFetcher.UserAgent != "curse-parser-test" {
+		t.Errorf("SetUserAgent did not replace the user agent of DefaultFetcher")
+	}
+}
+
+func TestFetcherAcceptLanguage(t *testing.T) {
+	var languages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		languages = append(languages, r.Header.Get("Accept-Language"))
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	for _, fetcher := range []*Fetcher{NewFetcher(nil), {}, {AcceptLanguage: "de-DE"}} {
+		resp, err := fetcher.FetchPage(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// The default is used if not set
+	expected := []string{DefaultAcceptLanguage, DefaultAcceptLanguage, "de-DE"}
+	if len(languages) != len(expected) {
+		t.Fatalf("Expected %d requests, got %d", len(expected), len(languages))
+	}
+	for idx, language := range expected {
+		if languages[idx] != language {
+			t.Errorf("Expected Accept-Language '%s', got '%s'", language, languages[idx])
+		}
+	}
+}
+
+func TestFetcherCookies(t *testing.T) {
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/projects/taam/files" {
+			cookies = append(cookies, r.Header.Get("Cookie"))
+		}
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	base := NewFetcher(nil)
+	fetcher := base.WithCookies([]*http.Cookie{{Name: "age-gate", Value: "passed"}})
+	if len(base.Cookies) != 0 {
+		t.Errorf("Expected the original fetcher to be unmodified, got %v", base.Cookies)
+	}
+
+	filesURL, err := url.Parse(server.URL + "/projects/taam/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := fetcher.FetchPage(filesURL.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = new(CurseForge).parseCurseForge(fetcher, filesURL, resp.Body, false, CFSectionFiles, CFOptionNone)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first page and both subsequent pages
+	if len(cookies) != 3 {
+		t.Fatalf("Expected %d requests, got %d", 3, len(cookies))
+	}
+	for idx, cookie := range cookies {
+		if cookie != "age-gate=passed" {
+			t.Errorf("Expected the cookie on request %d, got '%s'", idx, cookie)
+		}
+	}
+}
+
+func TestFetcherMaxBodyBytes(t *testing.T) {
+	page := []byte("<html><body>" + strings.Repeat("x", 2048) + "</body></html>")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(page)))
+		}
+		w.Write(page[:100])
+		w.(http.Flusher).Flush()
+		w.Write(page[100:])
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(nil)
+	if fetcher.MaxBodyBytes != DefaultMaxBodyBytes {
+		t.Errorf("Expected default limit %d, got %d", DefaultMaxBodyBytes, fetcher.MaxBodyBytes)
+	}
+
+	// Known length, fails right away
+	fetcher.MaxBodyBytes = 1024
+	_, err := fetcher.FetchPage(server.URL)
+	if !isError(err, ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+	}
+
+	// Unknown length, fails while reading
+	resp, err := fetcher.FetchPage(server.URL + "?chunked=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != ErrBodyTooLarge || len(body) != 1024 {
+		t.Errorf("Expected ErrBodyTooLarge after %d bytes, got %v after %d bytes", 1024, err, len(body))
+	}
+
+	// Exactly at the limit, and without limit
+	for _, limit := range []int64{int64(len(page)), 0} {
+		fetcher.MaxBodyBytes = limit
+		resp, err = fetcher.FetchPage(server.URL + "?chunked=1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || len(body) != len(page) {
+			t.Errorf("Expected the complete page with limit %d, got %v after %d bytes", limit, err, len(body))
+		}
+	}
+}
+
+func TestLimitedBodyReadAfterLimit(t *testing.T) {
+	body := ioutil.NopCloser(strings.NewReader("0123456789"))
+	limited := &limitedBody{
+		reader: io.LimitReader(body, 5),
+		body:   body,
+		limit:  4,
+	}
+
+	// The read crossing the limit passes on the bytes up to it
+	p := make([]byte, 3)
+	if n, err := limited.Read(p); n != 3 || err != nil {
+		t.Fatalf("Expected %d bytes, got %d (%v)", 3, n, err)
+	}
+	if n, err := limited.Read(p); n != 1 || err != ErrBodyTooLarge {
+		t.Fatalf("Expected %d byte and ErrBodyTooLarge, got %d (%v)", 1, n, err)
+	}
+
+	// Reading on keeps failing without returning any bytes
+	for i := 0; i < 2; i++ {
+		if n, err := limited.Read(p); n != 0 || err != ErrBodyTooLarge {
+			t.Errorf("Expected %d bytes and ErrBodyTooLarge, got %d (%v)", 0, n, err)
+		}
+	}
+}
+
+func TestFetcherRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(nil)
+	// One request every 50ms, no bursts
+	fetcher.SetRateLimit(20, 1)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := fetcher.FetchPage(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The first request is sent immediately, the others have to wait
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected the requests to take at least 100ms, took %s", elapsed)
+	}
+
+	// Waiting is aborted with the context
+	limiter := newTokenBucket(1, 1)
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.Wait(ctx); err != context.Canceled {
+		t.Errorf("Expected %v while waiting, got %v", context.Canceled, err)
+	}
+
+	fetcher.SetRateLimit(0, 1)
+	if fetcher.Limiter != nil {
+		t.Error("Expected no limiter after removing the rate limit")
+	}
+}
+
+func TestFetcherRetry(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(nil)
+	fetcher.RetryBaseDelay = time.Millisecond
+
+	resp, err := fetcher.FetchPage(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 3 {
+		t.Errorf("Expected status 200 after 3 requests, got %d after %d requests", resp.StatusCode, requests)
+	}
+
+	// Retries exhausted: the last response is returned
+	requests = 0
+	fetcher.MaxAttempts = 2
+	resp, err = fetcher.FetchPage(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || requests != 2 {
+		t.Errorf("Expected status 502 after 2 requests, got %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestDefaultFetcherNoRetry(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// The package-level functions send every request only once
+	resp, err := FetchPage(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Errorf("Expected status 503 after 1 request, got %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestFetcherMaxRetryDelay(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/later" {
+			w.Header().Set("Retry-After", "86400")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(nil)
+	fetch := func(path string, expectedRequests int) {
+		requests = 0
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			resp, err := fetcher.FetchPage(server.URL + path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusServiceUnavailable || requests != expectedRequests {
+				t.Errorf("Expected status 503 after %d requests, got %d after %d requests", expectedRequests, resp.StatusCode, requests)
+			}
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Request to '%s' waited beyond MaxRetryDelay", path)
+		}
+	}
+
+	// Retry-After beyond the limit: no retry, the response is returned right away
+	fetch("/later", 1)
+
+	// Backoff delays are shortened to the limit
+	fetcher.RetryBaseDelay = time.Hour
+	fetcher.MaxRetryDelay = time.Millisecond
+	fetch("/", fetcher.MaxAttempts)
+
+	if delay := (&Fetcher{}).maxRetryDelay(); delay != DefaultMaxRetryDelay {
+		t.Errorf("Expected default max retry delay %v, got %v", DefaultMaxRetryDelay, delay)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2017, 8, 22, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              -1,
+		"120":                           2 * time.Minute,
+		"Tue, 22 Aug 2017 12:00:30 GMT": 30 * time.Second,
+		"Tue, 22 Aug 2017 11:00:00 GMT": 0,
+		"soon":                          -1,
+	}
+	for header, expected := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if header != "" {
+			resp.Header.Set("Retry-After", header)
+		}
+		if actual := retryAfter(resp, now); actual != expected {
+			t.Errorf("Retry-After '%s': expected %v, got %v", header, expected, actual)
+		}
+	}
+}
+
+func TestFetchCurseForgeFetchMeta(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/projects/legacy" {
+			http.Redirect(w, r, "/projects/pawn", http.StatusMovedPermanently)
+			return
+		}
+		http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/legacy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Header only, and the header parsed from the overview page
+	for _, sections := range []CurseForgeSections{CFSectionHeader, CFSectionOverview} {
+		before := time.Now()
+		results, err := NewFetcher(nil).FetchCurseForge(projectURL, sections, CFOptionNone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		meta := results.Fetch
+		if meta == nil {
+			t.Fatalf("Expected fetch metadata for sections %s", sections)
+		}
+		if meta.FinalURL == nil || meta.FinalURL.String() != server.URL+"/projects/pawn" {
+			t.Errorf("Expected final URL '%s', got '%v'", server.URL+"/projects/pawn", meta.FinalURL)
+		}
+		if meta.StatusCode != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, meta.StatusCode)
+		}
+		// The Date header has a resolution of one second
+		if meta.ServerTime.IsZero() || meta.ServerTime.Before(before.Add(-time.Second)) {
+			t.Errorf("Expected the server time to be set from the Date header, got %s", meta.ServerTime)
+		}
+		if meta.FetchedAt.Before(before) || meta.FetchedAt.After(time.Now()) {
+			t.Errorf("Expected the fetch time to be between %s and now, got %s", before, meta.FetchedAt)
+		}
+	}
+
+	// Parsing from a reader has no response
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	results := new(CurseForge)
+	err = results.ParseCurseForgeReader(projectURL, f, true, CFSectionOverview, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Fetch != nil {
+		t.Errorf("Expected no fetch metadata when parsing from a reader, got %+v", results.Fetch)
+	}
+}
+
+func TestFetchCurseForgeTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/files") {
+			// Every files page is slow, the three pages together exceed the timeout
+			time.Sleep(100 * time.Millisecond)
+			http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+			return
+		}
+		http.ServeFile(w, r, "testdata/curseforge_overview_pawn.html")
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/pawn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(nil)
+
+	results, err := fetcher.FetchCurseForgeTimeout(projectURL, CFSectionOverview|CFSectionFiles, CFOptionNone, 250*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if results != nil {
+		t.Errorf("Expected no results, got %+v", results)
+	}
+
+	// A single slow page is within the timeout
+	results, err = fetcher.FetchCurseForgeTimeout(projectURL, CFSectionOverview|CFSectionFiles, CFOptionFilesNoPagination, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Downloads) != 3 {
+		t.Errorf("Expected %d files, got %d", 3, len(results.Downloads))
+	}
+}
+
+func TestFetchCurseForgeSkipHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The fixture has no header
+		http.ServeFile(w, r, "testdata/curseforge_files_taam.html")
+	}))
+	defer server.Close()
+
+	projectURL, err := url.Parse(server.URL + "/projects/taam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fetcher := NewFetcher(nil)
+
+	_, err = fetcher.FetchCurseForge(projectURL, CFSectionFiles, CFOptionNone)
+	if err == nil {
+		t.Error("Expected an error parsing the missing header")
+	}
+
+	for _, options := range []CurseForgeOptions{CFOptionSkipHeader, CFOptionSkipHeader | CFOptionParallel} {
+		results, err := fetcher.FetchCurseForge(projectURL, CFSectionFiles, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Downloads) != 9 {
+			t.Errorf("Expected %d files, got %d", 9, len(results.Downloads))
+		}
+		if results.Title != "" || results.FilesURL != nil {
+			t.Errorf("Expected no header values, got title '%s' and files URL '%v'", results.Title, results.FilesURL)
+		}
+		if results.Fetch == nil || results.Fetch.FinalURL.String() != server.URL+"/projects/taam/files" {
+			t.Errorf("Expected the fetch metadata of the files page, got %+v", results.Fetch)
+		}
+	}
+
+	_, err = fetcher.FetchCurseForge(projectURL, CFSectionHeader, CFOptionSkipHeader)
+	if err == nil {
+		t.Error("Expected an error skipping the header without any section")
+	}
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// modLoaders are the mod loaders listed alongside the game versions of a file.
+var modLoaders = []string{"Forge", "NeoForge", "Fabric", "Quilt", "LiteLoader", "Rift"}
+
+// isModLoader returns true if the given game version tag is actually a mod loader.
+func isModLoader(tag string) bool {
+	for _, loader := range modLoaders {
+		if strings.EqualFold(loader, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDate returns true if the date of this file was parsed successfully.
+// Failed parses leave the zero time.
+func (f *File) hasDate() bool {
+	return !f.Date.IsZero()
+}
+
+// filesByDateDesc sorts files newest-first.
+type filesByDateDesc []File
+
+func (s filesByDateDesc) Len() int           { return len(s) }
+func (s filesByDateDesc) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s filesByDateDesc) Less(i, j int) bool { return s[i].Date.After(s[j].Date) }
+
+// FilesSince returns all files in Downloads released after t, sorted newest-first.
+// Files without a parsed date are skipped.
+// The returned slice is a copy, Downloads is not modified.
+func (c *CurseForge) FilesSince(t time.Time) []File {
+	var files []File
+	for _, file := range c.Downloads {
+		if file.hasDate() && file.Date.After(t) {
+			files = append(files, file)
+		}
+	}
+	sort.Stable(filesByDateDesc(files))
+	return files
+}
+
+// ComputeVersionStats fills FileCountByVersion and DownloadsByVersion from Downloads,
+// grouping the files by GameVersion. Files without a game version are not counted.
+// Call it again after modifying Downloads, the maps are replaced.
+func (c *CurseForge) ComputeVersionStats() {
+	c.FileCountByVersion = make(map[string]uint64)
+	c.DownloadsByVersion = make(map[string]uint64)
+	for _, file := range c.Downloads {
+		if file.GameVersion == "" {
+			continue
+		}
+		c.FileCountByVersion[file.GameVersion]++
+		c.DownloadsByVersion[file.GameVersion] += file.Downloads
+	}
+}
+
+// HasModLoader returns true if the file is made for the given mod loader (e.g. "Forge" or "Fabric").
+// If the loaders are known (see ModLoaders), these are checked.
+// Otherwise, the file name is checked for the loader name as a whole word,
+// so "Forge" matches "TAAM-Forge-1.12.jar", but not "TAAM-NeoForge-1.20.jar". Case is ignored.
+func (f *File) HasModLoader(modLoader string) bool {
+	if len(f.ModLoaders) > 0 {
+		for _, loader := range f.ModLoaders {
+			if strings.EqualFold(loader, modLoader) {
+				return true
+			}
+		}
+		return false
+	}
+	tokens := strings.FieldsFunc(f.Name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, token := range tokens {
+		if strings.EqualFold(token, modLoader) {
+			return true
+		}
+	}
+	return false
+}
+
+// FileFilter selects files for LatestFile. Zero values do not constrain the files.
+type FileFilter struct {
+	// The least stable release type allowed, e.g. ReleaseTypeBeta allows Beta & Release files.
+	MinReleaseType ReleaseType
+	// The game version of the file, or a pattern like "1.12.x", see GameVersion.Matches.
+	GameVersion string
+	// The mod loader of the file, see File.HasModLoader.
+	ModLoader string
+	// Only files released after this time are selected.
+	Since time.Time
+	// Skip files marked as archived or deprecated, see File.Deprecated.
+	ExcludeDeprecated bool
+}
+
+// Matches returns true if the file passes the filter.
+func (filter FileFilter) Matches(file *File) bool {
+	if ParseReleaseType(file.ReleaseType) < filter.MinReleaseType {
+		return false
+	}
+	if filter.GameVersion != "" && file.GameVersion != filter.GameVersion {
+		version, err := ParseGameVersion(file.GameVersion)
+		if err != nil || !version.Matches(filter.GameVersion) {
+			return false
+		}
+	}
+	if filter.ModLoader != "" && !file.HasModLoader(filter.ModLoader) {
+		return false
+	}
+	if !filter.Since.IsZero() && !file.Date.After(filter.Since) {
+		return false
+	}
+	if filter.ExcludeDeprecated && file.Deprecated {
+		return false
+	}
+	return true
+}
+
+// LatestFile returns the newest file in Downloads passing the filter.
+// Returns false if there is no such file. Files without a parsed date are skipped.
+func (c *CurseForge) LatestFile(filter FileFilter) (*File, bool) {
+	file := c.latestFile(filter.Matches)
+	return file, file != nil
+}
+
+// BestFile returns the newest file in Downloads matching the given criteria, or nil if there is none.
+// It is a shorthand for LatestFile, see FileFilter for the criteria.
+//
+// gameVersion: The game version of the file. Pass "" to allow any.
+// modLoader: The mod loader of the file, see File.HasModLoader(). Pass "" to allow any.
+// minReleaseType: The least stable release type allowed, e.g. ReleaseTypeBeta allows Beta & Release files.
+// Pass ReleaseTypeUnknown to allow any.
+//
+// Files without a parsed date are skipped.
+func (c *CurseForge) BestFile(gameVersion, modLoader string, minReleaseType ReleaseType) *File {
+	file, _ := c.LatestFile(FileFilter{
+		GameVersion:    gameVersion,
+		ModLoader:      modLoader,
+		MinReleaseType: minReleaseType,
+	})
+	return file
+}
+
+// latestFile returns the newest file in Downloads for which match returns true, or nil if there is none.
+// Files without a parsed date are skipped.
+func (c *CurseForge) latestFile(match func(file *File) bool) *File {
+	var latest *File
+	for idx := range c.Downloads {
+		file := &c.Downloads[idx]
+		if !file.hasDate() || !match(file) {
+			continue
+		}
+		if latest == nil || file.Date.After(latest.Date) {
+			latest = file
+		}
+	}
+	return latest
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilesSince(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "old", Date: time.Unix(1400000000, 0).UTC()},
+			{Name: "newer", Date: time.Unix(1500000000, 0).UTC()},
+			{Name: "unparsed", Date: time.Time{}},
+			{Name: "newest", Date: time.Unix(1600000000, 0).UTC()},
+		},
+	}
+
+	files := results.FilesSince(time.Unix(1450000000, 0))
+	if len(files) != 2 {
+		t.Fatalf("Expected %d files, got %d", 2, len(files))
+	}
+	if files[0].Name != "newest" || files[1].Name != "newer" {
+		t.Errorf("Expected files sorted newest-first, got '%s', '%s'", files[0].Name, files[1].Name)
+	}
+
+	files = results.FilesSince(time.Time{})
+	if len(files) != 3 {
+		t.Errorf("Expected %d files with a parsed date, got %d", 3, len(files))
+	}
+}
+
+func TestComputeVersionStats(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "a", GameVersion: "1.12.2", Downloads: 100},
+			{Name: "b", GameVersion: "1.16.5", Downloads: 20},
+			{Name: "c", GameVersion: "1.12.2", Downloads: 5},
+			{Name: "unknown", Downloads: 1000},
+		},
+	}
+
+	results.ComputeVersionStats()
+	expected := map[string][2]uint64{
+		"1.12.2": {2, 105},
+		"1.16.5": {1, 20},
+	}
+	if len(results.DownloadsByVersion) != len(expected) || len(results.FileCountByVersion) != len(expected) {
+		t.Fatalf("Expected %d versions, got %v and %v", len(expected), results.FileCountByVersion, results.DownloadsByVersion)
+	}
+	for version, stats := range expected {
+		if results.FileCountByVersion[version] != stats[0] {
+			t.Errorf("Expected %d files for '%s', got %d", stats[0], version, results.FileCountByVersion[version])
+		}
+		if results.DownloadsByVersion[version] != stats[1] {
+			t.Errorf("Expected %d downloads for '%s', got %d", stats[1], version, results.DownloadsByVersion[version])
+		}
+	}
+
+	// Recomputed from scratch
+	results.Downloads = results.Downloads[:1]
+	results.ComputeVersionStats()
+	if len(results.DownloadsByVersion) != 1 || results.DownloadsByVersion["1.12.2"] != 100 {
+		t.Errorf("Expected only the downloads of the remaining file, got %v", results.DownloadsByVersion)
+	}
+}
+
+func TestBestFile(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "mod-forge-1.12.2-1.0.jar", GameVersion: "1.12.2", ReleaseType: "Release", Date: time.Unix(1400000000, 0).UTC()},
+			{Name: "mod-forge-1.12.2-1.1.jar", GameVersion: "1.12.2", ReleaseType: "Beta", Date: time.Unix(1500000000, 0).UTC()},
+			{Name: "mod-forge-1.12.2-1.2.jar", GameVersion: "1.12.2", ReleaseType: "Alpha", Date: time.Unix(1600000000, 0).UTC()},
+			{Name: "mod-1.16.5-2.0.jar", GameVersion: "1.16.5", ModLoaders: []string{"Fabric"}, ReleaseType: "Release", Date: time.Unix(1610000000, 0).UTC()},
+			{Name: "mod-1.16.5-2.1.jar", GameVersion: "1.16.5", ModLoaders: []string{"Forge"}, ReleaseType: "Beta", Date: time.Unix(1620000000, 0).UTC()},
+			{Name: "mod-1.16.5-unparsed.jar", GameVersion: "1.16.5", ModLoaders: []string{"Forge"}, ReleaseType: "Release", Date: time.Time{}},
+		},
+	}
+
+	testValues := []struct {
+		gameVersion    string
+		modLoader      string
+		minReleaseType ReleaseType
+		expected       string
+	}{
+		{"", "", ReleaseTypeUnknown, "mod-1.16.5-2.1.jar"},
+		{"", "", ReleaseTypeRelease, "mod-1.16.5-2.0.jar"},
+		{"1.12.2", "", ReleaseTypeUnknown, "mod-forge-1.12.2-1.2.jar"},
+		{"1.12.2", "", ReleaseTypeAlpha, "mod-forge-1.12.2-1.2.jar"},
+		{"1.12.2", "", ReleaseTypeBeta, "mod-forge-1.12.2-1.1.jar"},
+		{"1.12.2", "", ReleaseTypeRelease, "mod-forge-1.12.2-1.0.jar"},
+		{"1.12.2", "forge", ReleaseTypeRelease, "mod-forge-1.12.2-1.0.jar"},
+		{"1.12.2", "Fabric", ReleaseTypeUnknown, ""},
+		{"1.16.5", "Forge", ReleaseTypeUnknown, "mod-1.16.5-2.1.jar"},
+		{"1.16.5", "Forge", ReleaseTypeRelease, ""},
+		{"1.16.5", "Fabric", ReleaseTypeBeta, "mod-1.16.5-2.0.jar"},
+		{"", "Fabric", ReleaseTypeUnknown, "mod-1.16.5-2.0.jar"},
+		{"1.7.10", "", ReleaseTypeUnknown, ""},
+	}
+
+	for _, v := range testValues {
+		file := results.BestFile(v.gameVersion, v.modLoader, v.minReleaseType)
+		name := ""
+		if file != nil {
+			name = file.Name
+		}
+		if name != v.expected {
+			t.Errorf("Expected '%s' for (%s, %s, %d), got '%s'", v.expected, v.gameVersion, v.modLoader, v.minReleaseType, name)
+		}
+	}
+}
+
+func TestLatestFileVersionPattern(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "mod-1.12-1.0.jar", GameVersion: "1.12", ReleaseType: "Release", Date: time.Unix(1400000000, 0).UTC()},
+			{Name: "mod-1.12.2-1.1.jar", GameVersion: "1.12.2", ReleaseType: "Release", Date: time.Unix(1500000000, 0).UTC()},
+			{Name: "mod-1.12.2-1.2.jar", GameVersion: "1.12.2", ReleaseType: "Beta", Date: time.Unix(1600000000, 0).UTC()},
+			{Name: "mod-1.7.10-0.9.jar", GameVersion: "1.7.10", ReleaseType: "Release", Date: time.Unix(1300000000, 0).UTC()},
+			{Name: "mod-1.16-snapshot.jar", GameVersion: "1.16-Snapshot", ReleaseType: "Alpha", Date: time.Unix(1610000000, 0).UTC()},
+			{Name: "mod-java8.jar", GameVersion: "Java 8", ReleaseType: "Release", Date: time.Unix(1700000000, 0).UTC()},
+		},
+	}
+
+	testValues := []struct {
+		versionPattern string
+		minReleaseType ReleaseType
+		expected       string
+	}{
+		{"1.12.x", ReleaseTypeUnknown, "mod-1.12.2-1.2.jar"},
+		{"1.12.x", ReleaseTypeRelease, "mod-1.12.2-1.1.jar"},
+		{"1.12", ReleaseTypeRelease, "mod-1.12-1.0.jar"},
+		{"1.12.0", ReleaseTypeRelease, "mod-1.12-1.0.jar"},
+		{"1.7.*", ReleaseTypeUnknown, "mod-1.7.10-0.9.jar"},
+		{"1.x", ReleaseTypeUnknown, "mod-1.16-snapshot.jar"},
+		{"1.x", ReleaseTypeBeta, "mod-1.12.2-1.2.jar"},
+		{"1.16-snapshot", ReleaseTypeUnknown, "mod-1.16-snapshot.jar"},
+		{"1.16", ReleaseTypeUnknown, ""},
+		{"2.x", ReleaseTypeUnknown, ""},
+		{"invalid", ReleaseTypeUnknown, ""},
+	}
+
+	for _, v := range testValues {
+		file, ok := results.LatestFile(FileFilter{GameVersion: v.versionPattern, MinReleaseType: v.minReleaseType})
+		name := ""
+		if ok {
+			name = file.Name
+		}
+		if name != v.expected {
+			t.Errorf("Expected '%s' for (%s, %d), got '%s'", v.expected, v.versionPattern, v.minReleaseType, name)
+		}
+	}
+}
+
+func TestLatestFile(t *testing.T) {
+	results := &CurseForge{
+		Downloads: []File{
+			{Name: "mod-forge-1.12.2-1.0.jar", GameVersion: "1.12.2", ReleaseType: "Release", Date: time.Unix(1400000000, 0).UTC()},
+			{Name: "mod-forge-1.12.2-1.1.jar", GameVersion: "1.12.2", ReleaseType: "Beta", Date: time.Unix(1500000000, 0).UTC()},
+			{Name: "mod-1.12-1.2.jar", GameVersion: "1.12", ReleaseType: "Release", Date: time.Unix(1550000000, 0).UTC()},
+			{Name: "mod-1.16.5-2.0.jar", GameVersion: "1.16.5", ModLoaders: []string{"Fabric"}, ReleaseType: "Release", Date: time.Unix(1610000000, 0).UTC()},
+			{Name: "mod-1.16.5-unparsed.jar", GameVersion: "1.16.5", ReleaseType: "Release", Date: time.Time{}},
+			{Name: "mod-1.12-1.3.jar", GameVersion: "1.12", ReleaseType: "Release", Date: time.Unix(1560000000, 0).UTC(), Deprecated: true},
+		},
+	}
+
+	testValues := []struct {
+		filter   FileFilter
+		expected string
+	}{
+		{FileFilter{}, "mod-1.16.5-2.0.jar"},
+		{FileFilter{GameVersion: "1.12.2"}, "mod-forge-1.12.2-1.1.jar"},
+		{FileFilter{GameVersion: "1.12.2", MinReleaseType: ReleaseTypeRelease}, "mod-forge-1.12.2-1.0.jar"},
+		{FileFilter{GameVersion: "1.12.x"}, "mod-1.12-1.3.jar"},
+		{FileFilter{GameVersion: "1.12.x", ExcludeDeprecated: true}, "mod-1.12-1.2.jar"},
+		{FileFilter{ModLoader: "forge", Since: time.Unix(1450000000, 0)}, "mod-forge-1.12.2-1.1.jar"},
+		{FileFilter{ModLoader: "Fabric", Since: time.Unix(1610000000, 0)}, ""},
+		{FileFilter{GameVersion: "1.7.10"}, ""},
+	}
+
+	for _, v := range testValues {
+		file, ok := results.LatestFile(v.filter)
+		name := ""
+		if file != nil {
+			name = file.Name
+		}
+		if name != v.expected || ok != (v.expected != "") {
+			t.Errorf("Expected '%s' for %+v, got '%s' (%t)", v.expected, v.filter, name, ok)
+		}
+	}
+}
+
+func TestFileHasModLoader(t *testing.T) {
+	testValues := []struct {
+		File      File
+		ModLoader string
+		Expected  bool
+	}{
+		{File{Name: "TAAM-Forge-1.12.2.jar"}, "forge", true},
+		{File{Name: "TAAM-1.20.1-neoforge.jar"}, "Forge", false},
+		{File{Name: "TAAM-1.20.1-neoforge.jar"}, "NeoForge", true},
+		{File{Name: "curseforge-helper-1.0.jar"}, "Forge", false},
+		{File{Name: "TAAM_fabric_1.16.5.jar"}, "Fabric", true},
+		{File{Name: "TAAM-Forge-1.12.2.jar", ModLoaders: []string{"Fabric"}}, "Forge", false},
+		{File{Name: "TAAM-1.12.2.jar", ModLoaders: []string{"Forge"}}, "forge", true},
+	}
+	for _, value := range testValues {
+		if hasLoader := value.File.HasModLoader(value.ModLoader); hasLoader != value.Expected {
+			t.Errorf("Expected %t for '%s' in %s / %v, got %t", value.Expected, value.ModLoader, value.File.Name, value.File.ModLoaders, hasLoader)
+		}
+	}
+}
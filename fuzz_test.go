@@ -0,0 +1,97 @@
+//go:build go1.18
+// +build go1.18
+
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+// offlineTransport fails all requests, so parsing never reaches the network.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+// FuzzParseCurseForge parses arbitrary pages with all section parsers.
+// Malformed pages have to return an error (or incomplete results), never panic.
+// Run with: go test -run '^$' -fuzz FuzzParseCurseForge
+func FuzzParseCurseForge(f *testing.F) {
+	pages, err := filepath.Glob("testdata/*.html")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, page := range pages {
+		raw, err := ioutil.ReadFile(page)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(raw)
+	}
+	f.Add([]byte(""))
+	f.Add([]byte("<html><body><nav class=\"e-header-nav\"></nav></body></html>"))
+
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/projects/taam")
+	if err != nil {
+		f.Fatal(err)
+	}
+	fetcher := NewFetcher(&http.Client{Transport: offlineTransport{}})
+	var options CurseForgeOptions = CFOptionFilesNoPagination | CFOptionOverviewRecentFiles | CFOptionCommentsNoPagination
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, section := range append([]CurseForgeSections{CFSectionHeader}, cfSectionOrder...) {
+			results := new(CurseForge)
+			results.parseCurseForge(fetcher, documentURL, bytes.NewReader(data), section == CFSectionHeader, section, options)
+		}
+	})
+}
+
+// FuzzParseCurseReader parses arbitrary pages with the parser of mods.curse.com.
+// Every page has to return either an error or results, never panic.
+// Run with: go test -run '^$' -fuzz FuzzParseCurseReader
+func FuzzParseCurseReader(f *testing.F) {
+	pages, err := filepath.Glob("testdata/curse_*.html")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, page := range pages {
+		raw, err := ioutil.ReadFile(page)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(raw)
+		// Empty count cells, which used to panic
+		f.Add(bytes.Replace(raw, []byte(`<li class="downloads">56,789 Total Downloads</li>`), []byte(`<li class="downloads"> </li>`), 1))
+	}
+	f.Add([]byte(""))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		results, err := ParseCurseReader("https://mods.curse.com/mc-mods/minecraft/238424-taam", bytes.NewReader(data))
+		if err == nil && results == nil {
+			t.Error("Expected an error or results, got neither")
+		}
+	})
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// GameType is the game a project belongs to, for the games that are parsed differently.
+type GameType uint8
+
+const (
+	// GameTypeUnknown is used for all other games, and if the game could not be detected.
+	GameTypeUnknown GameType = iota
+	// GameTypeMinecraft marks Minecraft projects (mods, modpacks, ...).
+	GameTypeMinecraft
+	// GameTypeWoW marks World of Warcraft addons.
+	GameTypeWoW
+)
+
+// DetectGameType detects the game of a project from its URL, e.g.
+// https://minecraft.curseforge.com/projects/taam, https://www.curseforge.com/wow/addons/pawn or
+// https://mods.curse.com/mc-mods/minecraft/238424-taam.
+// Returns GameTypeUnknown if the URL does not identify the game.
+func DetectGameType(u *url.URL) GameType {
+	if u == nil {
+		return GameTypeUnknown
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case strings.HasPrefix(host, "minecraft."):
+		return GameTypeMinecraft
+	case strings.HasPrefix(host, "wow."), host == "wowace.com", strings.HasSuffix(host, ".wowace.com"):
+		return GameTypeWoW
+	}
+
+	// www.curseforge.com and mods.curse.com name the game in the path
+	for _, segment := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+		switch strings.ToLower(segment) {
+		case "minecraft":
+			return GameTypeMinecraft
+		case "wow":
+			return GameTypeWoW
+		}
+	}
+	return GameTypeUnknown
+}
+
+// gameTypeByName maps the name of the game as printed on the page ("Minecraft", "WoW") to a GameType.
+func gameTypeByName(name string) GameType {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "minecraft":
+		return GameTypeMinecraft
+	case "wow", "world of warcraft":
+		return GameTypeWoW
+	}
+	return GameTypeUnknown
+}
+
+// String returns the name of the game, as printed on the page.
+func (g GameType) String() string {
+	switch g {
+	case GameTypeMinecraft:
+		return "Minecraft"
+	case GameTypeWoW:
+		return "WoW"
+	}
+	return "Unknown"
+}
+
+// mapGameVersion maps the game version of a file as printed on the page to the format of the game.
+// For WoW, interface versions as used in TOC files ("70300") are mapped to the patch ("7.3.0").
+// Other values are returned as-is.
+func mapGameVersion(gameType GameType, version string) string {
+	version = strings.TrimSpace(version)
+	if gameType != GameTypeWoW {
+		return version
+	}
+	// Interface versions have two digits each for minor & patch version
+	if len(version) < 5 || len(version) > 6 {
+		return version
+	}
+	iface, err := strconv.ParseUint(version, 10, 32)
+	if err != nil {
+		return version
+	}
+	return strconv.FormatUint(iface/10000, 10) + "." + strconv.FormatUint(iface/100%100, 10) + "." + strconv.FormatUint(iface%100, 10)
+}
+
+// GameVersion is a game version parsed for comparison, e.g. "1.12.2" or "1.16-Snapshot".
+type GameVersion struct {
+	Major uint64
+	Minor uint64
+	Patch uint64
+	// The part after the numbers, e.g. "Snapshot" for "1.16-Snapshot". Empty for releases.
+	Suffix string
+}
+
+// ParseGameVersion parses a game version like "1.12.2", "1.7.10" or "1.16-Snapshot".
+// Missing minor & patch versions are 0. The suffix may be separated by "-" or a space.
+func ParseGameVersion(version string) (GameVersion, error) {
+	var v GameVersion
+	version = strings.TrimSpace(version)
+
+	numbers := version
+	if idx := strings.IndexAny(version, "- "); idx >= 0 {
+		numbers = version[:idx]
+		v.Suffix = strings.TrimSpace(version[idx+1:])
+		if v.Suffix == "" {
+			return v, fmt.Errorf("invalid game version '%s'", version)
+		}
+	}
+
+	parts := strings.Split(numbers, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid game version '%s'", version)
+	}
+	values := []*uint64{&v.Major, &v.Minor, &v.Patch}
+	for idx, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid game version '%s'", version)
+		}
+		*values[idx] = value
+	}
+	return v, nil
+}
+
+// String returns the version in the format "1.12.2" or "1.16.0-Snapshot".
+func (v GameVersion) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Suffix != "" {
+		s += "-" + v.Suffix
+	}
+	return s
+}
+
+// Compare returns -1 if v is older than other, 1 if it is newer and 0 if both are equal.
+// A version with suffix (a snapshot or pre-release) is older than the same version without.
+// Suffixes are compared case-insensitively as strings.
+func (v GameVersion) Compare(other GameVersion) int {
+	numbers := []uint64{v.Major, v.Minor, v.Patch}
+	otherNumbers := []uint64{other.Major, other.Minor, other.Patch}
+	for idx := range numbers {
+		if numbers[idx] < otherNumbers[idx] {
+			return -1
+		}
+		if numbers[idx] > otherNumbers[idx] {
+			return 1
+		}
+	}
+
+	suffix, otherSuffix := strings.ToLower(v.Suffix), strings.ToLower(other.Suffix)
+	switch {
+	case suffix == otherSuffix:
+		return 0
+	case suffix == "":
+		return 1
+	case otherSuffix == "":
+		return -1
+	case suffix < otherSuffix:
+		return -1
+	}
+	return 1
+}
+
+// Less returns true if v is older than other, see Compare.
+func (v GameVersion) Less(other GameVersion) bool {
+	return v.Compare(other) < 0
+}
+
+// Matches returns true if v matches the pattern. Use "x" or "*" as wildcard for a part of the version,
+// e.g. "1.12.x" matches all 1.12 versions including "1.12", and "1.x" all 1.* versions.
+// Without wildcard, the pattern has to be equal to v (see Compare).
+// Invalid patterns match nothing.
+func (v GameVersion) Matches(pattern string) bool {
+	parts := strings.Split(strings.TrimSpace(pattern), ".")
+	wildcard := len(parts) - 1
+	if len(parts) < 2 || len(parts) > 3 || (parts[wildcard] != "x" && parts[wildcard] != "*") {
+		p, err := ParseGameVersion(pattern)
+		return err == nil && v.Compare(p) == 0
+	}
+
+	// Only the last part may be a wildcard, which includes snapshots of the version
+	values := []uint64{v.Major, v.Minor}
+	for idx, part := range parts[:wildcard] {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil || value != values[idx] {
+			return false
+		}
+	}
+	return true
+}
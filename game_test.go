@@ -0,0 +1,198 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+func TestDetectGameType(t *testing.T) {
+	tests := map[string]GameType{
+		"https://minecraft.curseforge.com/projects/taam":       GameTypeMinecraft,
+		"https://www.curseforge.com/minecraft/mc-mods/taam":    GameTypeMinecraft,
+		"https://mods.curse.com/mc-mods/minecraft/238424-taam": GameTypeMinecraft,
+		"https://wow.curseforge.com/projects/pawn":             GameTypeWoW,
+		"https://www.curseforge.com/wow/addons/pawn":           GameTypeWoW,
+		"https://www.wowace.com/projects/pawn":                 GameTypeWoW,
+		"https://mods.curse.com/addons/wow/pawn":               GameTypeWoW,
+		"https://www.curseforge.com/projects/19373":            GameTypeUnknown,
+		"https://kerbal.curseforge.com/projects/mechjeb":       GameTypeUnknown,
+	}
+	for input, expected := range tests {
+		u, err := url.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gameType := DetectGameType(u); gameType != expected {
+			t.Errorf("Expected %s for '%s', got %s", expected, input, gameType)
+		}
+	}
+}
+
+func TestMapGameVersion(t *testing.T) {
+	tests := []struct {
+		gameType GameType
+		input    string
+		expected string
+	}{
+		{GameTypeWoW, "70300", "7.3.0"},
+		{GameTypeWoW, "11302", "1.13.2"},
+		{GameTypeWoW, "100002", "10.0.2"},
+		{GameTypeWoW, " 7.3.0 ", "7.3.0"},
+		{GameTypeMinecraft, "1.12.1", "1.12.1"},
+		{GameTypeMinecraft, "70300", "70300"},
+		{GameTypeUnknown, "70300", "70300"},
+	}
+	for _, test := range tests {
+		if version := mapGameVersion(test.gameType, test.input); version != test.expected {
+			t.Errorf("Expected '%s' for %s '%s', got '%s'", test.expected, test.gameType, test.input, version)
+		}
+	}
+}
+
+func TestParseCFFilesWoW(t *testing.T) {
+	page := `<html><body><table><tbody>
+<tr class="project-file-list-item">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div></td>
+<td class="project-file-name">
+<div class="project-file-name-container"><a href="/projects/pawn/files/2444300">Pawn-2.2.0.zip</a></div>
+<div class="project-file-download-button"><a href="/projects/pawn/files/2444300/download"></a></div>
+</td>
+<td class="project-file-size">120 KB</td>
+<td class="project-file-date-uploaded"><abbr data-epoch="1503439200">Aug 22, 2017</abbr></td>
+<td class="project-file-game-version"><span class="version-label">70300</span></td>
+<td class="project-file-downloads">1,234</td>
+</tr>
+<tr class="project-file-list-item">
+<td class="project-file-release-type"><div class="release-phase tip" title="Release"></div></td>
+<td class="project-file-name">
+<div class="project-file-name-container"><a href="/projects/pawn/files/2398100">Pawn-2.1.0.zip</a></div>
+<div class="project-file-download-button"><a href="/projects/pawn/files/2398100/download"></a></div>
+</td>
+<td class="project-file-size">118 KB</td>
+<td class="project-file-date-uploaded"><abbr data-epoch="1493589600">Apr 30, 2017</abbr></td>
+<td class="project-file-game-version">7.2.0</td>
+<td class="project-file-downloads">567</td>
+</tr>
+</tbody></table></body></html>`
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documentURL, err := url.Parse("https://wow.curseforge.com/projects/pawn/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := new(CurseForge)
+	err = parseCFFilesSinglePage(results, newPageLookup(nil, documentURL), root, CFOptionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"7.3.0", "7.2.0"}
+	if len(results.Downloads) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(results.Downloads))
+	}
+	for idx, version := range expected {
+		if results.Downloads[idx].GameVersion != version {
+			t.Errorf("Expected game version '%s', got '%s'", version, results.Downloads[idx].GameVersion)
+		}
+	}
+}
+
+func TestParseGameVersion(t *testing.T) {
+	tests := map[string]GameVersion{
+		"1.12.2":             {1, 12, 2, ""},
+		"1.7.10":             {1, 7, 10, ""},
+		"1.12":               {1, 12, 0, ""},
+		" 1.16-Snapshot":     {1, 16, 0, "Snapshot"},
+		"1.14 Pre-Release 2": {1, 14, 0, "Pre-Release 2"},
+		"7.3.0":              {7, 3, 0, ""},
+	}
+	for input, expected := range tests {
+		version, err := ParseGameVersion(input)
+		if err != nil {
+			t.Errorf("Error parsing '%s': %s", input, err.Error())
+			continue
+		}
+		if version != expected {
+			t.Errorf("Expected %v for '%s', got %v", expected, input, version)
+		}
+	}
+
+	for _, input := range []string{"", "Java 8", "Forge", "1.12.2.1", "1.x", "1.12-"} {
+		if _, err := ParseGameVersion(input); err == nil {
+			t.Errorf("Expected error parsing '%s'", input)
+		}
+	}
+}
+
+func TestGameVersionCompare(t *testing.T) {
+	// Sorted oldest to newest
+	sorted := []string{"1.7.10", "1.10", "1.10.2", "1.12", "1.12.2", "1.16-Pre-Release", "1.16-Snapshot", "1.16"}
+	for i := range sorted {
+		for j := range sorted {
+			a, _ := ParseGameVersion(sorted[i])
+			b, _ := ParseGameVersion(sorted[j])
+			expected := 0
+			if i < j {
+				expected = -1
+			} else if i > j {
+				expected = 1
+			}
+			if compared := a.Compare(b); compared != expected {
+				t.Errorf("Expected %d comparing '%s' to '%s', got %d", expected, sorted[i], sorted[j], compared)
+			}
+			if a.Less(b) != (i < j) {
+				t.Errorf("Unexpected Less() for '%s' and '%s'", sorted[i], sorted[j])
+			}
+		}
+	}
+}
+
+func TestGameVersionMatches(t *testing.T) {
+	tests := []struct {
+		version  string
+		pattern  string
+		expected bool
+	}{
+		{"1.12.2", "1.12.x", true},
+		{"1.12", "1.12.*", true},
+		{"1.12-Snapshot", "1.12.x", true},
+		{"1.12.2", "1.x", true},
+		{"1.12.2", "1.12.2", true},
+		{"1.12", "1.12.0", true},
+		{"1.12.2", "1.12", false},
+		{"1.11.2", "1.12.x", false},
+		{"2.0", "1.x", false},
+		{"1.12.2", "x", false},
+		{"1.12.2", "1.x.2", false},
+	}
+	for _, test := range tests {
+		version, err := ParseGameVersion(test.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.Matches(test.pattern) != test.expected {
+			t.Errorf("Expected %t matching '%s' against '%s'", test.expected, test.version, test.pattern)
+		}
+	}
+}
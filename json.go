@@ -0,0 +1,539 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+// The types of this package are serialized with their URLs in String() form.
+// *url.URL would otherwise be serialized as an object of its parts.
+// Each type shadows its URL fields with jsonURL during (un)marshalling.
+
+// jsonURL is an url.URL that is serialized to and from its String() form.
+type jsonURL url.URL
+
+// MarshalJSON implements json.Marshaler.
+func (u *jsonURL) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*url.URL)(u).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (u *jsonURL) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return err
+	}
+	// data: URIs are kept as-is, as when parsing
+	parsed, ok := parseDataURL(s)
+	if !ok {
+		parsed, err = url.Parse(s)
+		if err != nil {
+			return err
+		}
+	}
+	*u = jsonURL(*parsed)
+	return nil
+}
+
+// toJSONURLs converts a list of URLs for marshalling.
+func toJSONURLs(urls []*url.URL) []*jsonURL {
+	if urls == nil {
+		return nil
+	}
+	converted := make([]*jsonURL, len(urls))
+	for idx, u := range urls {
+		converted[idx] = (*jsonURL)(u)
+	}
+	return converted
+}
+
+// fromJSONURLs converts a list of unmarshalled URLs.
+func fromJSONURLs(urls []*jsonURL) []*url.URL {
+	if urls == nil {
+		return nil
+	}
+	converted := make([]*url.URL, len(urls))
+	for idx, u := range urls {
+		converted[idx] = (*url.URL)(u)
+	}
+	return converted
+}
+
+// toJSONURLMap converts a map of URLs for marshalling.
+func toJSONURLMap(urls map[int]*url.URL) map[int]*jsonURL {
+	if urls == nil {
+		return nil
+	}
+	converted := make(map[int]*jsonURL, len(urls))
+	for key, u := range urls {
+		converted[key] = (*jsonURL)(u)
+	}
+	return converted
+}
+
+// fromJSONURLMap converts a map of unmarshalled URLs.
+func fromJSONURLMap(urls map[int]*jsonURL) map[int]*url.URL {
+	if urls == nil {
+		return nil
+	}
+	converted := make(map[int]*url.URL, len(urls))
+	for key, u := range urls {
+		converted[key] = (*url.URL)(u)
+	}
+	return converted
+}
+
+// MarshalJSON implements json.Marshaler.
+func (a Author) MarshalJSON() ([]byte, error) {
+	type alias Author
+	return json.Marshal(struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}{
+		alias:    alias(a),
+		URL:      (*jsonURL)(a.URL),
+		ImageURL: (*jsonURL)(a.ImageURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *Author) UnmarshalJSON(data []byte) error {
+	type alias Author
+	var aux struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*a = Author(aux.alias)
+	a.URL = (*url.URL)(aux.URL)
+	a.ImageURL = (*url.URL)(aux.ImageURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (i Image) MarshalJSON() ([]byte, error) {
+	type alias Image
+	return json.Marshal(struct {
+		alias
+		URL          *jsonURL `json:"url"`
+		ThumbnailURL *jsonURL `json:"thumbnailUrl"`
+	}{
+		alias:        alias(i),
+		URL:          (*jsonURL)(i.URL),
+		ThumbnailURL: (*jsonURL)(i.ThumbnailURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (i *Image) UnmarshalJSON(data []byte) error {
+	type alias Image
+	var aux struct {
+		alias
+		URL          *jsonURL `json:"url"`
+		ThumbnailURL *jsonURL `json:"thumbnailUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*i = Image(aux.alias)
+	i.URL = (*url.URL)(aux.URL)
+	i.ThumbnailURL = (*url.URL)(aux.ThumbnailURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f File) MarshalJSON() ([]byte, error) {
+	type alias File
+	return json.Marshal(struct {
+		alias
+		URL       *jsonURL `json:"url"`
+		DirectURL *jsonURL `json:"directUrl"`
+	}{
+		alias:     alias(f),
+		URL:       (*jsonURL)(f.URL),
+		DirectURL: (*jsonURL)(f.DirectURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *File) UnmarshalJSON(data []byte) error {
+	type alias File
+	var aux struct {
+		alias
+		URL       *jsonURL `json:"url"`
+		DirectURL *jsonURL `json:"directUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*f = File(aux.alias)
+	f.URL = (*url.URL)(aux.URL)
+	f.DirectURL = (*url.URL)(aux.DirectURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Category) MarshalJSON() ([]byte, error) {
+	type alias Category
+	return json.Marshal(struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}{
+		alias:    alias(c),
+		URL:      (*jsonURL)(c.URL),
+		ImageURL: (*jsonURL)(c.ImageURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Category) UnmarshalJSON(data []byte) error {
+	type alias Category
+	var aux struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*c = Category(aux.alias)
+	c.URL = (*url.URL)(aux.URL)
+	c.ImageURL = (*url.URL)(aux.ImageURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Dependency) MarshalJSON() ([]byte, error) {
+	type alias Dependency
+	return json.Marshal(struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}{
+		alias:    alias(d),
+		URL:      (*jsonURL)(d.URL),
+		ImageURL: (*jsonURL)(d.ImageURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Dependency) UnmarshalJSON(data []byte) error {
+	type alias Dependency
+	var aux struct {
+		alias
+		URL      *jsonURL `json:"url"`
+		ImageURL *jsonURL `json:"imageUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*d = Dependency(aux.alias)
+	d.URL = (*url.URL)(aux.URL)
+	d.ImageURL = (*url.URL)(aux.ImageURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Comment) MarshalJSON() ([]byte, error) {
+	type alias Comment
+	return json.Marshal(struct {
+		alias
+		URL *jsonURL `json:"url"`
+	}{
+		alias: alias(c),
+		URL:   (*jsonURL)(c.URL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Comment) UnmarshalJSON(data []byte) error {
+	type alias Comment
+	var aux struct {
+		alias
+		URL *jsonURL `json:"url"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*c = Comment(aux.alias)
+	c.URL = (*url.URL)(aux.URL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	type alias SearchResult
+	return json.Marshal(struct {
+		alias
+		ProjectURL *jsonURL `json:"projectUrl"`
+	}{
+		alias:      alias(r),
+		ProjectURL: (*jsonURL)(r.ProjectURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *SearchResult) UnmarshalJSON(data []byte) error {
+	type alias SearchResult
+	var aux struct {
+		alias
+		ProjectURL *jsonURL `json:"projectUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*r = SearchResult(aux.alias)
+	r.ProjectURL = (*url.URL)(aux.ProjectURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (p ProjectSummary) MarshalJSON() ([]byte, error) {
+	type alias ProjectSummary
+	return json.Marshal(struct {
+		alias
+		URL *jsonURL `json:"url"`
+	}{
+		alias: alias(p),
+		URL:   (*jsonURL)(p.URL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *ProjectSummary) UnmarshalJSON(data []byte) error {
+	type alias ProjectSummary
+	var aux struct {
+		alias
+		URL *jsonURL `json:"url"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*p = ProjectSummary(aux.alias)
+	p.URL = (*url.URL)(aux.URL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m DescriptionMedia) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Videos []*jsonURL `json:"videos"`
+		Images []*jsonURL `json:"images"`
+	}{
+		Videos: toJSONURLs(m.Videos),
+		Images: toJSONURLs(m.Images),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *DescriptionMedia) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Videos []*jsonURL `json:"videos"`
+		Images []*jsonURL `json:"images"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	m.Videos = fromJSONURLs(aux.Videos)
+	m.Images = fromJSONURLs(aux.Images)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m FetchMeta) MarshalJSON() ([]byte, error) {
+	type alias FetchMeta
+	return json.Marshal(struct {
+		alias
+		FinalURL *jsonURL `json:"finalUrl"`
+	}{
+		alias:    alias(m),
+		FinalURL: (*jsonURL)(m.FinalURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *FetchMeta) UnmarshalJSON(data []byte) error {
+	type alias FetchMeta
+	var aux struct {
+		alias
+		FinalURL *jsonURL `json:"finalUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*m = FetchMeta(aux.alias)
+	m.FinalURL = (*url.URL)(aux.FinalURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Curse) MarshalJSON() ([]byte, error) {
+	type alias Curse
+	return json.Marshal(struct {
+		alias
+		DontationURL  *jsonURL `json:"donationUrl"`
+		CurseforgeURL *jsonURL `json:"curseforgeUrl"`
+		GameURL       *jsonURL `json:"gameUrl"`
+	}{
+		alias:         alias(c),
+		DontationURL:  (*jsonURL)(c.DontationURL),
+		CurseforgeURL: (*jsonURL)(c.CurseforgeURL),
+		GameURL:       (*jsonURL)(c.GameURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Curse) UnmarshalJSON(data []byte) error {
+	type alias Curse
+	var aux struct {
+		alias
+		DontationURL  *jsonURL `json:"donationUrl"`
+		CurseforgeURL *jsonURL `json:"curseforgeUrl"`
+		GameURL       *jsonURL `json:"gameUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*c = Curse(aux.alias)
+	c.DontationURL = (*url.URL)(aux.DontationURL)
+	c.CurseforgeURL = (*url.URL)(aux.CurseforgeURL)
+	c.GameURL = (*url.URL)(aux.GameURL)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c CurseForge) MarshalJSON() ([]byte, error) {
+	type alias CurseForge
+	return json.Marshal(struct {
+		alias
+		OverviewURL         *jsonURL         `json:"overviewUrl"`
+		FilesURL            *jsonURL         `json:"filesUrl"`
+		ImagesURL           *jsonURL         `json:"imagesUrl"`
+		DependenciesURL     *jsonURL         `json:"dependenciesUrl"`
+		DependentsURL       *jsonURL         `json:"dependentsUrl"`
+		CurseURL            *jsonURL         `json:"curseUrl"`
+		ReportProjectURL    *jsonURL         `json:"reportProjectUrl"`
+		IssuesURL           *jsonURL         `json:"issuesUrl"`
+		WikiURL             *jsonURL         `json:"wikiUrl"`
+		SourceURL           *jsonURL         `json:"sourceUrl"`
+		CanonicalURL        *jsonURL         `json:"canonicalUrl"`
+		ProjectURL          *jsonURL         `json:"projectUrl"`
+		DontationURL        *jsonURL         `json:"donationUrl"`
+		ImageURL            *jsonURL         `json:"imageUrl"`
+		ImageThumbnailURL   *jsonURL         `json:"imageThumbnailUrl"`
+		ImageURLVariants    map[int]*jsonURL `json:"imageUrlVariants"`
+		RootGameCategoryURL *jsonURL         `json:"rootGameCategoryUrl"`
+		LicenseURL          *jsonURL         `json:"licenseUrl"`
+		GameURL             *jsonURL         `json:"gameUrl"`
+		LatestDownloadURL   *jsonURL         `json:"latestDownloadUrl"`
+	}{
+		alias:               alias(c),
+		OverviewURL:         (*jsonURL)(c.OverviewURL),
+		FilesURL:            (*jsonURL)(c.FilesURL),
+		ImagesURL:           (*jsonURL)(c.ImagesURL),
+		DependenciesURL:     (*jsonURL)(c.DependenciesURL),
+		DependentsURL:       (*jsonURL)(c.DependentsURL),
+		CurseURL:            (*jsonURL)(c.CurseURL),
+		ReportProjectURL:    (*jsonURL)(c.ReportProjectURL),
+		IssuesURL:           (*jsonURL)(c.IssuesURL),
+		WikiURL:             (*jsonURL)(c.WikiURL),
+		SourceURL:           (*jsonURL)(c.SourceURL),
+		CanonicalURL:        (*jsonURL)(c.CanonicalURL),
+		ProjectURL:          (*jsonURL)(c.ProjectURL),
+		DontationURL:        (*jsonURL)(c.DontationURL),
+		ImageURL:            (*jsonURL)(c.ImageURL),
+		ImageThumbnailURL:   (*jsonURL)(c.ImageThumbnailURL),
+		ImageURLVariants:    toJSONURLMap(c.ImageURLVariants),
+		RootGameCategoryURL: (*jsonURL)(c.RootGameCategoryURL),
+		LicenseURL:          (*jsonURL)(c.LicenseURL),
+		GameURL:             (*jsonURL)(c.GameURL),
+		LatestDownloadURL:   (*jsonURL)(c.LatestDownloadURL),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *CurseForge) UnmarshalJSON(data []byte) error {
+	type alias CurseForge
+	var aux struct {
+		alias
+		OverviewURL         *jsonURL         `json:"overviewUrl"`
+		FilesURL            *jsonURL         `json:"filesUrl"`
+		ImagesURL           *jsonURL         `json:"imagesUrl"`
+		DependenciesURL     *jsonURL         `json:"dependenciesUrl"`
+		DependentsURL       *jsonURL         `json:"dependentsUrl"`
+		CurseURL            *jsonURL         `json:"curseUrl"`
+		ReportProjectURL    *jsonURL         `json:"reportProjectUrl"`
+		IssuesURL           *jsonURL         `json:"issuesUrl"`
+		WikiURL             *jsonURL         `json:"wikiUrl"`
+		SourceURL           *jsonURL         `json:"sourceUrl"`
+		CanonicalURL        *jsonURL         `json:"canonicalUrl"`
+		ProjectURL          *jsonURL         `json:"projectUrl"`
+		DontationURL        *jsonURL         `json:"donationUrl"`
+		ImageURL            *jsonURL         `json:"imageUrl"`
+		ImageThumbnailURL   *jsonURL         `json:"imageThumbnailUrl"`
+		ImageURLVariants    map[int]*jsonURL `json:"imageUrlVariants"`
+		RootGameCategoryURL *jsonURL         `json:"rootGameCategoryUrl"`
+		LicenseURL          *jsonURL         `json:"licenseUrl"`
+		GameURL             *jsonURL         `json:"gameUrl"`
+		LatestDownloadURL   *jsonURL         `json:"latestDownloadUrl"`
+	}
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+	*c = CurseForge(aux.alias)
+	c.OverviewURL = (*url.URL)(aux.OverviewURL)
+	c.FilesURL = (*url.URL)(aux.FilesURL)
+	c.ImagesURL = (*url.URL)(aux.ImagesURL)
+	c.DependenciesURL = (*url.URL)(aux.DependenciesURL)
+	c.DependentsURL = (*url.URL)(aux.DependentsURL)
+	c.CurseURL = (*url.URL)(aux.CurseURL)
+	c.ReportProjectURL = (*url.URL)(aux.ReportProjectURL)
+	c.IssuesURL = (*url.URL)(aux.IssuesURL)
+	c.WikiURL = (*url.URL)(aux.WikiURL)
+	c.SourceURL = (*url.URL)(aux.SourceURL)
+	c.CanonicalURL = (*url.URL)(aux.CanonicalURL)
+	c.ProjectURL = (*url.URL)(aux.ProjectURL)
+	c.DontationURL = (*url.URL)(aux.DontationURL)
+	c.ImageURL = (*url.URL)(aux.ImageURL)
+	c.ImageThumbnailURL = (*url.URL)(aux.ImageThumbnailURL)
+	c.ImageURLVariants = fromJSONURLMap(aux.ImageURLVariants)
+	c.RootGameCategoryURL = (*url.URL)(aux.RootGameCategoryURL)
+	c.LicenseURL = (*url.URL)(aux.LicenseURL)
+	c.GameURL = (*url.URL)(aux.GameURL)
+	c.LatestDownloadURL = (*url.URL)(aux.LatestDownloadURL)
+	return nil
+}
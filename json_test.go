@@ -0,0 +1,128 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCurseForgeJSONRoundTrip(t *testing.T) {
+	f, err := os.Open("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	results, err := ParseCurseReader("https://wow.curseforge.com/projects/pawn", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results.Downloads = []File{{Name: "Pawn-2.2.0.zip", URL: results.CurseforgeURL}}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"projectUrl":"https://wow.curseforge.com/projects/pawn"`) {
+		t.Errorf("Expected URLs in String() form, got %s", data)
+	}
+
+	decoded := new(Curse)
+	err = json.Unmarshal(data, decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(results.CurseForge.ProjectURL, decoded.CurseForge.ProjectURL) {
+		t.Errorf("Expected project URL '%s', got '%s'", results.CurseForge.ProjectURL, decoded.CurseForge.ProjectURL)
+	}
+	if decoded.CurseForge.Title != "Pawn" || len(decoded.CurseForge.Authors) != 1 {
+		t.Errorf("Unexpected decoded values: %+v", decoded.CurseForge)
+	}
+	if decoded.Authors[0].URL.String() != results.Authors[0].URL.String() {
+		t.Errorf("Expected author URL '%s', got '%s'", results.Authors[0].URL, decoded.Authors[0].URL)
+	}
+	if decoded.Downloads[0].URL.String() != results.CurseforgeURL.String() {
+		t.Errorf("Expected file URL '%s', got '%s'", results.CurseforgeURL, decoded.Downloads[0].URL)
+	}
+	if len(decoded.CurseForge.DescriptionMedia.Images) != len(results.CurseForge.DescriptionMedia.Images) {
+		t.Errorf("Expected %d description images, got %d", len(results.CurseForge.DescriptionMedia.Images), len(decoded.CurseForge.DescriptionMedia.Images))
+	}
+	if decoded.CurseForge.IssuesURL.String() != results.CurseForge.IssuesURL.String() {
+		t.Errorf("Expected issues URL '%s', got '%s'", results.CurseForge.IssuesURL, decoded.CurseForge.IssuesURL)
+	}
+	if decoded.CurseForge.SourceURL != nil {
+		t.Errorf("Expected no source URL, got '%s'", decoded.CurseForge.SourceURL)
+	}
+}
+
+func TestEnumJSON(t *testing.T) {
+	author := Author{Name: "founderio", NormalizedRole: AuthorRoleFormerAuthor}
+	file := File{Name: "TAAM-1.12.1-0.7.0.jar", Release: ReleaseTypeBeta}
+
+	data, err := json.Marshal(author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"normalizedRole":"Former Author"`) {
+		t.Errorf("Expected the role by name, got %s", data)
+	}
+	var decodedAuthor Author
+	err = json.Unmarshal(data, &decodedAuthor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedAuthor.NormalizedRole != AuthorRoleFormerAuthor {
+		t.Errorf("Expected role %s, got %s", AuthorRoleFormerAuthor, decodedAuthor.NormalizedRole)
+	}
+
+	data, err = json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"release":"Beta"`) {
+		t.Errorf("Expected the release type by name, got %s", data)
+	}
+	var decodedFile File
+	err = json.Unmarshal(data, &decodedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedFile.Release != ReleaseTypeBeta {
+		t.Errorf("Expected release type %s, got %s", ReleaseTypeBeta, decodedFile.Release)
+	}
+
+	// Unknown values survive the round trip as well
+	data, err = json.Marshal(File{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"release":"Unknown"`) {
+		t.Errorf("Expected the unknown release type by name, got %s", data)
+	}
+	err = json.Unmarshal(data, &decodedFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedFile.Release != ReleaseTypeUnknown {
+		t.Errorf("Expected release type %s, got %s", ReleaseTypeUnknown, decodedFile.Release)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// FieldStatus reports whether the selector of a field matched, see ValidateCurseForgeLayout.
+type FieldStatus struct {
+	// The name of the value as used in ParseError, e.g. "Title" or "File/Date"
+	Field string `json:"field"`
+	// The XPath used to resolve the value
+	XPath string `json:"xpath"`
+	// Matched is true if the value was resolved at least once
+	Matched bool `json:"matched"`
+	// The number of times the value was resolved, e.g. once per row of the files listing
+	Matches int `json:"matches"`
+}
+
+// layoutCollector collects the lookups reported while ValidateCurseForgeLayout parses a page.
+// The mutex guards statuses and indexes, as the parsers may report concurrently.
+type layoutCollector struct {
+	mutex    sync.Mutex
+	statuses []FieldStatus
+	indexes  map[[2]string]int
+}
+
+// collect is the DebugFunc receiving the lookups.
+func (c *layoutCollector) collect(field, xpath string, matched bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.add(field, xpath, matched)
+}
+
+// collectMissing lists the field unless it was already looked up.
+// Used for the field of a ParseError, which may not have been reported by a lookup.
+func (c *layoutCollector) collectMissing(field, xpath string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.indexes[[2]string{field, xpath}]; !ok {
+		c.add(field, xpath, false)
+	}
+}
+
+// add records a lookup, c.mutex must be held.
+func (c *layoutCollector) add(field, xpath string, matched bool) {
+	if c.indexes == nil {
+		c.indexes = make(map[[2]string]int)
+	}
+	key := [2]string{field, xpath}
+	idx, ok := c.indexes[key]
+	if !ok {
+		idx = len(c.statuses)
+		c.indexes[key] = idx
+		c.statuses = append(c.statuses, FieldStatus{Field: field, XPath: xpath})
+	}
+	if matched {
+		c.statuses[idx].Matched = true
+		c.statuses[idx].Matches++
+	}
+}
+
+// ValidateCurseForgeLayout parses the CurseForge page in resp and reports, for every field the parsers
+// look up on it, whether its selector matched. Use it to detect layout changes of CurseForge
+// without asserting specific values, e.g. by alerting on any unmatched field.
+//
+// The header is always checked. The section is detected from the URL of the page,
+// e.g. https://minecraft.curseforge.com/projects/taam/files checks the files listing.
+// Subsequent pages and the files API of the redesigned files pages are not requested.
+// The fields are listed in the order they were looked up. resp.Body is closed.
+//
+// Parsing stops at the first required field that is missing, so the fields after it are not listed.
+// Optional values (e.g. the donation URL) are listed as unmatched if the project does not have them,
+// so compare against a project known to have all values.
+// An error is only returned if the page could not be read or is not a project page;
+// ParseErrors are reported as unmatched fields instead.
+func ValidateCurseForgeLayout(resp *http.Response) ([]FieldStatus, error) {
+	defer resp.Body.Close()
+	if resp.Request == nil || resp.Request.URL == nil {
+		return nil, errors.New("response has no request URL")
+	}
+	documentURL := resp.Request.URL
+
+	// Parse with a copy of DefaultFetcher reporting to this call only
+	collector := &layoutCollector{}
+	fetcher := *DefaultFetcher
+	fetcher.DebugHook = collector.collect
+	fetcher.skipFilesAPI = true
+
+	results := new(CurseForge)
+	err := results.parseCurseForge(&fetcher, documentURL, resp.Body, true, cfSectionOfURL(documentURL),
+		CFOptionFilesNoPagination|CFOptionCommentsNoPagination)
+
+	if parseErr, ok := err.(*ParseError); ok {
+		// Make sure the field that stopped the parser is listed
+		collector.collectMissing(parseErr.Field, parseErr.XPath)
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return collector.statuses, nil
+}
+
+// cfSectionOfURL returns the section of a CurseForge page, based on the sub-page path of its URL.
+// Returns CFSectionOverview for project URLs without a known sub-page.
+func cfSectionOfURL(u *url.URL) CurseForgeSections {
+	pagePath := strings.TrimSuffix(u.Path, "/")
+	switch {
+	case strings.HasSuffix(pagePath, "/files"):
+		return CFSectionFiles
+	case strings.HasSuffix(pagePath, "/images"), strings.HasSuffix(pagePath, "/screenshots"):
+		return CFSectionImages
+	case strings.HasSuffix(pagePath, "/relations/dependencies"):
+		return CFSectionDependencies
+	case strings.HasSuffix(pagePath, "/relations/dependents"):
+		return CFSectionDependents
+	case strings.HasSuffix(pagePath, "/comments"):
+		return CFSectionComments
+	}
+	return CFSectionOverview
+}
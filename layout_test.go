@@ -0,0 +1,162 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidateCurseForgeLayout(t *testing.T) {
+	page, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/broken") {
+			// The title moved to a different heading
+			w.Write([]byte(strings.Replace(string(page), `<h1 class="project-title"><a href="/projects/pawn"><span class="overflow-tip">Pawn</span></a></h1>`,
+				`<h2 class="project-title"><a href="/projects/pawn"><span class="overflow-tip">Pawn</span></a></h2>`, 1)))
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	validate := func(path string) map[string]FieldStatus {
+		resp, err := NewFetcher(nil).FetchPage(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		statuses, err := ValidateCurseForgeLayout(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byField := make(map[string]FieldStatus, len(statuses))
+		for _, status := range statuses {
+			if status.XPath == "" {
+				t.Errorf("Empty xpath for field '%s'", status.Field)
+			}
+			byField[status.Field] = status
+		}
+		return byField
+	}
+
+	statuses := validate("/projects/pawn")
+	for _, field := range []string{"Title", "TotalDownloads", "Author/Name", "Category/ImageURL"} {
+		if status, ok := statuses[field]; !ok || !status.Matched || status.Matches == 0 {
+			t.Errorf("Expected field '%s' to be reported as matched, got %+v", field, status)
+		}
+	}
+	if status := statuses["Category/Name"]; status.Matches != 2 {
+		t.Errorf("Expected 'Category/Name' to match %d times, got %d", 2, status.Matches)
+	}
+
+	// The parser stops at the title, which is reported as unmatched
+	statuses = validate("/projects/broken")
+	if status, ok := statuses["Title"]; !ok || status.Matched {
+		t.Errorf("Expected field 'Title' to be reported as unmatched, got %+v", status)
+	}
+
+	// Validations running concurrently only see the lookups of their own page
+	done := make(chan []FieldStatus)
+	for i := 0; i < 2; i++ {
+		go func() {
+			resp, err := NewFetcher(nil).FetchPage(server.URL + "/projects/pawn")
+			if err != nil {
+				t.Error(err)
+				done <- nil
+				return
+			}
+			defer resp.Body.Close()
+			statuses, err := ValidateCurseForgeLayout(resp)
+			if err != nil {
+				t.Error(err)
+			}
+			done <- statuses
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		for _, status := range <-done {
+			if status.Field == "Category/Name" && status.Matches != 2 {
+				t.Errorf("Expected 'Category/Name' to match %d times in concurrent validation, got %d", 2, status.Matches)
+			}
+		}
+	}
+}
+
+func TestValidateCurseForgeLayoutFilesAPI(t *testing.T) {
+	overview, err := ioutil.ReadFile("testdata/curseforge_overview_pawn.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A page with a valid header, but without file rows, listing the files from the API
+	nextData := `<script id="__NEXT_DATA__" type="application/json">{"props":{"pageProps":{"project":{"id":238222}}}}</script>`
+	page := []byte(strings.Replace(string(overview), "</body>", nextData+"</body>", 1))
+	var apiRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			atomic.AddInt32(&apiRequests, 1)
+			http.ServeFile(w, r, "testdata/curseforge_files_api.json")
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	resp, err := NewFetcher(nil).FetchPage(server.URL + "/minecraft/mc-mods/jei/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := ValidateCurseForgeLayout(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) == 0 {
+		t.Errorf("Expected the header fields to be listed")
+	}
+	if n := atomic.LoadInt32(&apiRequests); n != 0 {
+		t.Errorf("Expected no requests to the files API, got %d", n)
+	}
+}
+
+func TestCFSectionOfURL(t *testing.T) {
+	tests := map[string]CurseForgeSections{
+		"https://minecraft.curseforge.com/projects/taam":                        CFSectionOverview,
+		"https://minecraft.curseforge.com/projects/taam/files/":                 CFSectionFiles,
+		"https://minecraft.curseforge.com/projects/taam/images":                 CFSectionImages,
+		"https://www.curseforge.com/minecraft/mc-mods/taam/screenshots":         CFSectionImages,
+		"https://minecraft.curseforge.com/projects/taam/relations/dependencies": CFSectionDependencies,
+		"https://minecraft.curseforge.com/projects/taam/relations/dependents":   CFSectionDependents,
+		"https://minecraft.curseforge.com/projects/taam/comments":               CFSectionComments,
+	}
+	for input, expected := range tests {
+		u, err := url.Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if section := cfSectionOfURL(u); section != expected {
+			t.Errorf("Expected section %s for '%s', got %s", expected, input, section)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// spdxLicenses maps the license names used on CurseForge to SPDX identifiers (https://spdx.org/licenses/).
+// The names are normalized, see normalizeLicenseName.
+// "All Rights Reserved" and custom licenses have no SPDX identifier and are not listed.
+var spdxLicenses = map[string]string{
+	"academic free license v3.0":             "AFL-3.0",
+	"apache license v2.0":                    "Apache-2.0",
+	"apache license 2.0":                     "Apache-2.0",
+	"apache license":                         "Apache-2.0",
+	"artistic license 2.0":                   "Artistic-2.0",
+	"bsd 2-clause license":                   "BSD-2-Clause",
+	"bsd 3-clause license":                   "BSD-3-Clause",
+	"creative commons zero":                  "CC0-1.0",
+	"creative commons zero v1.0 universal":   "CC0-1.0",
+	"eclipse public license 1.0":             "EPL-1.0",
+	"eclipse public license 2.0":             "EPL-2.0",
+	"gnu affero general public license v3":   "AGPL-3.0-only",
+	"gnu affero general public license v3.0": "AGPL-3.0-only",
+	"gnu general public license v2":          "GPL-2.0-only",
+	"gnu general public license v2.0":        "GPL-2.0-only",
+	"gnu general public license v3":          "GPL-3.0-only",
+	"gnu general public license v3.0":        "GPL-3.0-only",
+	"gnu lesser general public license v2.1": "LGPL-2.1-only",
+	"gnu lesser general public license v3":   "LGPL-3.0-only",
+	"gnu lesser general public license v3.0": "LGPL-3.0-only",
+	"isc license":                            "ISC",
+	"mit license":                            "MIT",
+	"microsoft public license":               "MS-PL",
+	"mozilla public license 2.0":             "MPL-2.0",
+	"mozilla public license v2.0":            "MPL-2.0",
+	"unlicense":                              "Unlicense",
+	"wtfpl":                                  "WTFPL",
+	"zlib/libpng license":                    "Zlib",
+}
+
+// licenseAbbreviation matches an abbreviation in parentheses, e.g. "(GPLv3)".
+var licenseAbbreviation = regexp.MustCompile(`\([^)]*\)`)
+
+// ParseLicenseSPDX maps a license name as printed on CurseForge (e.g. "MIT License",
+// "GNU General Public License version 3 (GPLv3)") to its SPDX identifier, e.g. "GPL-3.0-only".
+// SPDX identifiers are returned as-is (normalized in case).
+// Returns "" for custom licenses, "All Rights Reserved" and unrecognized names.
+func ParseLicenseSPDX(license string) string {
+	name := normalizeLicenseName(license)
+	if id, ok := spdxLicenses[name]; ok {
+		return id
+	}
+	for _, id := range spdxLicenses {
+		if strings.EqualFold(id, strings.TrimSpace(license)) {
+			return id
+		}
+	}
+	return ""
+}
+
+// normalizeLicenseName lowercases the name, removes abbreviations in parentheses and a leading "the",
+// shortens "version" to "v" and collapses whitespace.
+func normalizeLicenseName(license string) string {
+	name := strings.ToLower(licenseAbbreviation.ReplaceAllString(license, " "))
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.TrimPrefix(name, "the ")
+	return strings.Replace(name, "version ", "v", -1)
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"testing"
+)
+
+func TestParseLicenseSPDX(t *testing.T) {
+	testValues := map[string]string{
+		"MIT License": "MIT",
+		"GNU General Public License version 3 (GPLv3)":             "GPL-3.0-only",
+		"GNU General Public License v3":                            "GPL-3.0-only",
+		"GNU Lesser General Public License version 2.1 (LGPLv2.1)": "LGPL-2.1-only",
+		"Apache License version 2.0":                               "Apache-2.0",
+		"  Mozilla Public   License 2.0 ":                          "MPL-2.0",
+		"The Unlicense":                                            "Unlicense",
+		"apache-2.0":                                               "Apache-2.0",
+		"All Rights Reserved":                                      "",
+		"Custom License":                                           "",
+		"":                                                         "",
+	}
+	for license, expected := range testValues {
+		if id := ParseLicenseSPDX(license); id != expected {
+			t.Errorf("Expected '%s' for '%s', got '%s'", expected, license, id)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"time"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+// pageLookup resolves the values of a single page using pathCache.
+// Every lookup is reported with the name of its field to the DebugHook of the Fetcher parsing the page,
+// so the parsers name each XPath once.
+type pageLookup struct {
+	// The URL of the page, used for resolving relative links
+	url *url.URL
+	// Receives every lookup, nil if disabled
+	debug DebugFunc
+	// The thousands separator used for parsing counts
+	sep rune
+	// Whether to run the cleanup pass over the parsed text values, see Fetcher.RawText
+	normalize bool
+}
+
+// newPageLookup returns the lookup for the page at documentURL, reporting to the DebugHook of fetcher
+// and parsing with its ThousandsSeparator & RawText settings.
+// A nil fetcher reports nothing and uses the defaults.
+func newPageLookup(fetcher *Fetcher, documentURL *url.URL) *pageLookup {
+	lookup := &pageLookup{url: documentURL, sep: DefaultThousandsSeparator, normalize: true}
+	if fetcher != nil {
+		lookup.debug = fetcher.DebugHook
+		lookup.sep = fetcher.thousandsSeparator()
+		lookup.normalize = !fetcher.RawText
+	}
+	return lookup
+}
+
+// report reports the resolution of a field to the debug hook, if set.
+func (lookup *pageLookup) report(field, xpath string, matched bool) {
+	if lookup.debug != nil {
+		lookup.debug(field, xpath, matched)
+	}
+}
+
+// String is XpathCache.String, reporting the lookup as field.
+func (lookup *pageLookup) String(context *xmlpath.Node, field, path string) (string, bool) {
+	s, ok := pathCache.String(context, path)
+	lookup.report(field, path, ok)
+	return s, ok
+}
+
+// Node is XpathCache.Node, reporting the lookup as field.
+func (lookup *pageLookup) Node(context *xmlpath.Node, field, path string) (*xmlpath.Node, bool) {
+	node, ok := pathCache.Node(context, path)
+	lookup.report(field, path, ok)
+	return node, ok
+}
+
+// StringAny is XpathCache.StringAny, reporting the lookup as field.
+func (lookup *pageLookup) StringAny(context *xmlpath.Node, field string, paths ...string) (string, bool) {
+	s, ok := pathCache.StringAny(context, paths...)
+	lookup.report(field, anyPaths(paths...), ok)
+	return s, ok
+}
+
+// NodeAny is XpathCache.NodeAny, reporting the lookup as field.
+func (lookup *pageLookup) NodeAny(context *xmlpath.Node, field string, paths ...string) (*xmlpath.Node, bool) {
+	node, ok := pathCache.NodeAny(context, paths...)
+	lookup.report(field, anyPaths(paths...), ok)
+	return node, ok
+}
+
+// URL is XpathCache.URLWithBaseURL, resolving relative links against the URL of the page.
+// The lookup is reported as field.
+func (lookup *pageLookup) URL(context *xmlpath.Node, field, path string) (*url.URL, error) {
+	u, err := pathCache.URLWithBaseURL(context, path, lookup.url)
+	lookup.report(field, path, err == nil)
+	return u, err
+}
+
+// UInt is XpathCache.UInt, reporting the lookup as field.
+func (lookup *pageLookup) UInt(context *xmlpath.Node, field, path string) (uint64, error) {
+	value, err := pathCache.UInt(context, path)
+	lookup.report(field, path, err == nil)
+	return value, err
+}
+
+// UIntLocale is XpathCache.UIntLocale using the thousands separator of the page, reporting the lookup as field.
+func (lookup *pageLookup) UIntLocale(context *xmlpath.Node, field, path string) (uint64, error) {
+	value, err := pathCache.UIntLocale(context, path, lookup.sep)
+	lookup.report(field, path, err == nil)
+	return value, err
+}
+
+// Count is XpathCache.CountLocale using the thousands separator of the page, reporting the lookup as field.
+func (lookup *pageLookup) Count(context *xmlpath.Node, field, path string) (uint64, error) {
+	value, err := pathCache.CountLocale(context, path, lookup.sep)
+	lookup.report(field, path, err == nil)
+	return value, err
+}
+
+// UnixTimestamp is XpathCache.UnixTimestamp, reporting the lookup as field.
+func (lookup *pageLookup) UnixTimestamp(context *xmlpath.Node, field, path string) (time.Time, error) {
+	value, err := pathCache.UnixTimestamp(context, path)
+	lookup.report(field, path, err == nil)
+	return value, err
+}
+
+// RequiredString is String, returning a ParseError if the field is not present.
+func (lookup *pageLookup) RequiredString(context *xmlpath.Node, field, path string) (string, error) {
+	s, ok := lookup.String(context, field, path)
+	if !ok {
+		return s, newParseError(lookup.url, field, path, nil)
+	}
+	return s, nil
+}
+
+// RequiredNode is Node, returning a ParseError if the field is not present.
+func (lookup *pageLookup) RequiredNode(context *xmlpath.Node, field, path string) (*xmlpath.Node, error) {
+	node, ok := lookup.Node(context, field, path)
+	if !ok {
+		return nil, newParseError(lookup.url, field, path, nil)
+	}
+	return node, nil
+}
+
+// RequiredNodeAny is NodeAny, returning a ParseError if none of the paths is present.
+func (lookup *pageLookup) RequiredNodeAny(context *xmlpath.Node, field string, paths ...string) (*xmlpath.Node, error) {
+	node, ok := lookup.NodeAny(context, field, paths...)
+	if !ok {
+		return nil, newParseError(lookup.url, field, anyPaths(paths...), nil)
+	}
+	return node, nil
+}
+
+// RequiredURL is URL, returning a ParseError if the field is not present or not a valid URL.
+func (lookup *pageLookup) RequiredURL(context *xmlpath.Node, field, path string) (*url.URL, error) {
+	u, err := lookup.URL(context, field, path)
+	if err != nil {
+		return nil, newParseError(lookup.url, field, path, err)
+	}
+	return u, nil
+}
+
+// RequiredCount is Count, returning a ParseError if the field is not present or not a valid count.
+func (lookup *pageLookup) RequiredCount(context *xmlpath.Node, field, path string) (uint64, error) {
+	value, err := lookup.Count(context, field, path)
+	if err != nil {
+		return 0, newParseError(lookup.url, field, path, err)
+	}
+	return value, nil
+}
+
+// RequiredUnixTimestamp is UnixTimestamp, returning a ParseError if the field is not present or not a valid timestamp.
+func (lookup *pageLookup) RequiredUnixTimestamp(context *xmlpath.Node, field, path string) (time.Time, error) {
+	value, err := lookup.UnixTimestamp(context, field, path)
+	if err != nil {
+		return value, newParseError(lookup.url, field, path, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"errors"
+)
+
+// ToManifestEntry returns the manifest entry of the latest file in Downloads passing the filter, see LatestFile.
+// e.g. FileFilter{MinReleaseType: ReleaseTypeRelease, GameVersion: "1.12.2"} for the latest stable file of a pack.
+// Archived or deprecated files are never exported, regardless of filter.ExcludeDeprecated.
+// The entry is marked as required.
+//
+// Requires ProjectID to be parsed, and the file ID of the latest file.
+func (c *CurseForge) ToManifestEntry(filter FileFilter) (ManifestEntry, error) {
+	if c.ProjectID == 0 {
+		return ManifestEntry{}, errors.New("project ID unknown")
+	}
+	filter.ExcludeDeprecated = true
+	file, ok := c.LatestFile(filter)
+	if !ok {
+		return ManifestEntry{}, errors.New("no file passing the filter")
+	}
+	if file.ID == 0 {
+		return ManifestEntry{}, errors.New("file ID unknown")
+	}
+	return ManifestEntry{
+		ProjectID: c.ProjectID,
+		FileID:    file.ID,
+		Required:  true,
+	}, nil
+}
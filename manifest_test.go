@@ -0,0 +1,75 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestToManifestEntry(t *testing.T) {
+	results := &CurseForge{
+		ProjectID: 238424,
+		Downloads: []File{
+			{ID: 2398011, ReleaseType: "Release", Date: time.Unix(1500000000, 0)},
+			{ID: 2444195, ReleaseType: "Release", Date: time.Unix(1503439200, 0)},
+			// Newer, but filtered
+			{ID: 2450000, ReleaseType: "Beta", Date: time.Unix(1504000000, 0)},
+			{ID: 2460000, ReleaseType: "Release", Date: time.Unix(1505000000, 0), Deprecated: true},
+		},
+	}
+
+	entry, err := results.ToManifestEntry(FileFilter{MinReleaseType: ReleaseTypeRelease})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ManifestEntry{ProjectID: 238424, FileID: 2444195, Required: true}
+	if entry != expected {
+		t.Errorf("Expected %+v, got %+v", expected, entry)
+	}
+
+	// Deprecated files are skipped even without the filter
+	entry, err = results.ToManifestEntry(FileFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.FileID != 2450000 {
+		t.Errorf("Expected file %d, got %d", 2450000, entry.FileID)
+	}
+
+	raw, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != `{"projectID":238424,"fileID":2444195,"required":true}` {
+		t.Errorf("Unexpected JSON %s", raw)
+	}
+
+	// Missing IDs
+	results.Downloads[1].ID = 0
+	if _, err := results.ToManifestEntry(FileFilter{MinReleaseType: ReleaseTypeRelease}); err == nil {
+		t.Error("Expected an error for a file without ID")
+	}
+	results.ProjectID = 0
+	if _, err := results.ToManifestEntry(FileFilter{}); err == nil {
+		t.Error("Expected an error for a project without ID")
+	}
+	if _, err := (&CurseForge{ProjectID: 238424}).ToManifestEntry(FileFilter{}); err == nil {
+		t.Error("Expected an error for a project without files")
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+)
+
+// Merge adds the values of other to c, e.g. to combine the results of separate
+// FetchCurseForge calls for the overview and the files page.
+//
+// Downloads are combined without duplicates: files with the same URL are added once,
+// preferring the entry with the game version filled in (recent files of the overview lack it,
+// see CFOptionOverviewRecentFiles). Files without URL are always added.
+// All other values are copied from other if c has none (the zero value), e.g. the header values & counts
+// of the overview when merging it into the results of the files page. Values set in c are kept.
+// other is not modified, nothing is shared with it.
+func (c *CurseForge) Merge(other *CurseForge) {
+	if other == nil {
+		return
+	}
+
+	c.mergeHeader(other)
+
+	c.mergeFiles(other.Downloads)
+	if len(other.FileErrors) > 0 {
+		c.FileErrors = append(c.FileErrors, other.FileErrors...)
+	}
+	updateFirstFileDate(c)
+	if c.FileCountByVersion != nil || c.DownloadsByVersion != nil {
+		c.ComputeVersionStats()
+	}
+
+	if c.RecentRelease == nil {
+		c.RecentRelease = cloneFile(other.RecentRelease)
+	}
+	if c.RecentBeta == nil {
+		c.RecentBeta = cloneFile(other.RecentBeta)
+	}
+	if c.RecentAlpha == nil {
+		c.RecentAlpha = cloneFile(other.RecentAlpha)
+	}
+	if c.LatestDownloadURL == nil {
+		c.LatestDownloadURL = cloneURL(other.LatestDownloadURL)
+	}
+	if c.DescriptionHTML == "" && c.DescriptionText == "" {
+		c.DescriptionHTML = other.DescriptionHTML
+		c.DescriptionText = other.DescriptionText
+	}
+	if len(c.DescriptionMedia.Videos) == 0 && len(c.DescriptionMedia.Images) == 0 {
+		c.DescriptionMedia = DescriptionMedia{
+			Videos: cloneURLs(other.DescriptionMedia.Videos),
+			Images: cloneURLs(other.DescriptionMedia.Images),
+		}
+	}
+	if len(c.AvailableGameVersions) == 0 {
+		c.AvailableGameVersions = cloneStrings(other.AvailableGameVersions)
+	}
+	if c.TotalFilePages == 0 && c.TotalFiles == 0 {
+		c.TotalFilePages = other.TotalFilePages
+		c.TotalFiles = other.TotalFiles
+	}
+	if len(c.Screenshots) == 0 {
+		c.Screenshots = cloneImages(other.Screenshots)
+	}
+	if len(c.Dependencies) == 0 {
+		c.Dependencies = cloneDependencies(other.Dependencies)
+	}
+	if len(c.Dependents) == 0 {
+		c.Dependents = cloneDependencies(other.Dependents)
+	}
+	if len(c.IncludedProjects) == 0 {
+		c.IncludedProjects = cloneProjectSummaries(other.IncludedProjects)
+	}
+	if len(c.Comments) == 0 {
+		c.Comments = cloneComments(other.Comments)
+	}
+}
+
+// mergeHeader copies the header & overview values of other that are missing in c.
+func (c *CurseForge) mergeHeader(other *CurseForge) {
+	mergeURL(&c.OverviewURL, other.OverviewURL)
+	mergeURL(&c.FilesURL, other.FilesURL)
+	mergeURL(&c.ImagesURL, other.ImagesURL)
+	mergeURL(&c.DependenciesURL, other.DependenciesURL)
+	mergeURL(&c.DependentsURL, other.DependentsURL)
+
+	mergeURL(&c.CurseURL, other.CurseURL)
+	mergeURL(&c.ReportProjectURL, other.ReportProjectURL)
+	mergeURL(&c.IssuesURL, other.IssuesURL)
+	mergeURL(&c.WikiURL, other.WikiURL)
+	mergeURL(&c.SourceURL, other.SourceURL)
+
+	mergeURL(&c.CanonicalURL, other.CanonicalURL)
+	if c.ProjectID == 0 {
+		c.ProjectID = other.ProjectID
+	}
+	mergeString(&c.Title, other.Title)
+	mergeURL(&c.ProjectURL, other.ProjectURL)
+	mergeURL(&c.DontationURL, other.DontationURL)
+	mergeString(&c.DonationProvider, other.DonationProvider)
+	mergeURL(&c.ImageURL, other.ImageURL)
+	mergeURL(&c.ImageThumbnailURL, other.ImageThumbnailURL)
+	if len(c.ImageURLVariants) == 0 {
+		c.ImageURLVariants = cloneURLMap(other.ImageURLVariants)
+	}
+	mergeString(&c.RootGameCategory, other.RootGameCategory)
+	mergeURL(&c.RootGameCategoryURL, other.RootGameCategoryURL)
+	mergeString(&c.License, other.License)
+	mergeURL(&c.LicenseURL, other.LicenseURL)
+	mergeString(&c.LicenseSPDX, other.LicenseSPDX)
+	mergeString(&c.Game, other.Game)
+	mergeURL(&c.GameURL, other.GameURL)
+	if c.GameType == GameTypeUnknown {
+		c.GameType = other.GameType
+	}
+
+	if c.TotalDownloads == 0 {
+		c.TotalDownloads = other.TotalDownloads
+	}
+	if c.Rating == 0 && c.RatingCount == 0 {
+		c.Rating = other.Rating
+		c.RatingCount = other.RatingCount
+	}
+	if c.CommentCount == 0 {
+		c.CommentCount = other.CommentCount
+	}
+	if c.Followers == 0 {
+		c.Followers = other.Followers
+	}
+	if c.Created.IsZero() {
+		c.Created = other.Created
+	}
+	if c.Updated.IsZero() {
+		c.Updated = other.Updated
+	}
+
+	if len(c.Authors) == 0 {
+		c.Authors = cloneAuthors(other.Authors)
+	}
+	if len(c.Categories) == 0 {
+		c.Categories = cloneCategories(other.Categories)
+	}
+	mergeString(&c.Summary, other.Summary)
+	c.IsModpack = c.IsModpack || other.IsModpack
+	if c.Fetch == nil {
+		c.Fetch = cloneFetchMeta(other.Fetch)
+	}
+}
+
+// mergeURL sets *dst to a copy of src if *dst is nil.
+func mergeURL(dst **url.URL, src *url.URL) {
+	if *dst == nil {
+		*dst = cloneURL(src)
+	}
+}
+
+// mergeString sets *dst to src if *dst is empty.
+func mergeString(dst *string, src string) {
+	if *dst == "" {
+		*dst = src
+	}
+}
+
+// mergeFiles adds files to Downloads, replacing files with the same URL
+// if the added file has a game version and the existing one has not.
+func (c *CurseForge) mergeFiles(files []File) {
+	known := make(map[string]int, len(c.Downloads))
+	for idx, file := range c.Downloads {
+		if file.URL != nil {
+			known[file.URL.String()] = idx
+		}
+	}
+
+	for idx := range files {
+		file := &files[idx]
+		if file.URL == nil {
+			c.Downloads = append(c.Downloads, *cloneFile(file))
+			continue
+		}
+		key := file.URL.String()
+		existing, ok := known[key]
+		if !ok {
+			known[key] = len(c.Downloads)
+			c.Downloads = append(c.Downloads, *cloneFile(file))
+			continue
+		}
+		if c.Downloads[existing].GameVersion == "" && file.GameVersion != "" {
+			c.Downloads[existing] = *cloneFile(file)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCurseForgeMerge(t *testing.T) {
+	fileURL := func(id string) *url.URL {
+		u, err := url.Parse("https://wow.curseforge.com/projects/pawn/files/" + id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// Overview with the recent files, which lack the game version
+	overview := &CurseForge{
+		Title:           "Pawn",
+		DescriptionText: "Pawn calculates scores for items.",
+		Downloads: []File{
+			{Name: "Pawn-2.2.1-beta1.zip", URL: fileURL("2445001")},
+			{Name: "Pawn-2.2.0.zip", URL: fileURL("2444300")},
+		},
+	}
+	files := &CurseForge{
+		Title:          "Pawn (files page)",
+		TotalFilePages: 1,
+		Downloads: []File{
+			{Name: "Pawn-2.2.1-beta1.zip", URL: fileURL("2445001"), GameVersion: "7.3.0"},
+			{Name: "Pawn-2.2.0.zip", URL: fileURL("2444300")},
+			{Name: "Pawn-2.1.0.zip", URL: fileURL("2398100"), GameVersion: "7.2.5"},
+			{Name: "no URL"},
+		},
+	}
+
+	overview.Merge(files)
+
+	expected := []struct{ Name, GameVersion string }{
+		{"Pawn-2.2.1-beta1.zip", "7.3.0"},
+		{"Pawn-2.2.0.zip", ""},
+		{"Pawn-2.1.0.zip", "7.2.5"},
+		{"no URL", ""},
+	}
+	if len(overview.Downloads) != len(expected) {
+		t.Fatalf("Expected %d files, got %d", len(expected), len(overview.Downloads))
+	}
+	for idx, file := range overview.Downloads {
+		if file.Name != expected[idx].Name || file.GameVersion != expected[idx].GameVersion {
+			t.Errorf("Expected file '%s' (%s), got '%s' (%s)", expected[idx].Name, expected[idx].GameVersion, file.Name, file.GameVersion)
+		}
+	}
+
+	// Header values are kept, missing section values are copied
+	if overview.Title != "Pawn" || overview.DescriptionText != "Pawn calculates scores for items." {
+		t.Errorf("Expected the values of the overview to be kept, got '%s' / '%s'", overview.Title, overview.DescriptionText)
+	}
+	if overview.TotalFilePages != 1 {
+		t.Errorf("Expected %d file pages, got %d", 1, overview.TotalFilePages)
+	}
+
+	// Merging again only adds the file without URL again
+	overview.Merge(files)
+	if len(overview.Downloads) != len(expected)+1 {
+		t.Errorf("Expected %d files, got %d", len(expected)+1, len(overview.Downloads))
+	}
+
+	// Nothing is shared
+	overview.Downloads[2].URL.Path = "/modified"
+	if files.Downloads[2].URL.Path != "/projects/pawn/files/2398100" {
+		t.Errorf("Expected the merged files to be copies, got '%s'", files.Downloads[2].URL)
+	}
+}
+
+func TestCurseForgeMergeHeader(t *testing.T) {
+	created := time.Date(2008, 11, 20, 0, 0, 0, 0, time.UTC)
+	licenseURL, err := url.Parse("https://wow.curseforge.com/projects/pawn/license")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileURL, err := url.Parse("https://wow.curseforge.com/projects/pawn/files/2444300")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newOverview := func() *CurseForge {
+		return &CurseForge{
+			ProjectID:      19012,
+			Title:          "Pawn",
+			Authors:        []Author{{Name: "Vger", Role: "Owner", NormalizedRole: AuthorRoleOwner}},
+			Categories:     []Category{{Name: "Tooltip"}},
+			Created:        created,
+			Updated:        created,
+			TotalDownloads: 4567890,
+			License:        "All Rights Reserved",
+			LicenseURL:     licenseURL,
+			Rating:         4.5,
+			RatingCount:    120,
+			CommentCount:   342,
+			Followers:      1200,
+		}
+	}
+	newFiles := func() *CurseForge {
+		return &CurseForge{
+			Title:          "Pawn",
+			TotalFilePages: 1,
+			Downloads:      []File{{Name: "Pawn-2.2.0.zip", URL: fileURL}},
+		}
+	}
+
+	// Both directions give the same values
+	filesFirst := newFiles()
+	filesFirst.Merge(newOverview())
+	overviewFirst := newOverview()
+	overviewFirst.Merge(newFiles())
+
+	for name, results := range map[string]*CurseForge{"files first": filesFirst, "overview first": overviewFirst} {
+		if results.ProjectID != 19012 || len(results.Authors) != 1 || len(results.Categories) != 1 {
+			t.Errorf("%s: expected project ID, authors & categories, got %d / %v / %v", name, results.ProjectID, results.Authors, results.Categories)
+		}
+		if !results.Created.Equal(created) || !results.Updated.Equal(created) {
+			t.Errorf("%s: expected created & updated %v, got %v / %v", name, created, results.Created, results.Updated)
+		}
+		if results.TotalDownloads != 4567890 || results.Rating != 4.5 || results.RatingCount != 120 {
+			t.Errorf("%s: expected downloads & rating, got %d / %g / %d", name, results.TotalDownloads, results.Rating, results.RatingCount)
+		}
+		if results.License != "All Rights Reserved" || urlString(results.LicenseURL) != licenseURL.String() {
+			t.Errorf("%s: expected license, got '%s' / %v", name, results.License, results.LicenseURL)
+		}
+		if results.CommentCount != 342 || results.Followers != 1200 {
+			t.Errorf("%s: expected comments & followers, got %d / %d", name, results.CommentCount, results.Followers)
+		}
+		if results.TotalFilePages != 1 || len(results.Downloads) != 1 {
+			t.Errorf("%s: expected the files, got %d pages / %d files", name, results.TotalFilePages, len(results.Downloads))
+		}
+	}
+
+	// Nothing is shared
+	filesFirst.LicenseURL.Path = "/modified"
+	filesFirst.Authors[0].Name = "modified"
+	if licenseURL.Path != "/projects/pawn/license" {
+		t.Errorf("Expected the merged URL to be a copy, got '%s'", licenseURL)
+	}
+	if overviewFirst.Authors[0].Name != "Vger" {
+		t.Errorf("Expected the merged authors to be copies, got '%s'", overviewFirst.Authors[0].Name)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"html"
+	"strings"
+)
+
+// NormalizeString unescapes HTML entities and normalizes the whitespace in s,
+// as done by the parsers unless Fetcher.RawText is set.
+func NormalizeString(s string) string {
+	if strings.ContainsRune(s, '&') {
+		s = html.UnescapeString(s)
+	}
+	// strings.Fields also splits at non-breaking spaces (U+00A0)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func normalizeAuthors(authors []Author) {
+	for idx := range authors {
+		authors[idx].Name = NormalizeString(authors[idx].Name)
+		authors[idx].Role = NormalizeString(authors[idx].Role)
+	}
+}
+
+func normalizeCategories(categories []Category) {
+	for idx := range categories {
+		categories[idx].Name = NormalizeString(categories[idx].Name)
+	}
+}
+
+func normalizeDependencies(dependencies []Dependency) {
+	for idx := range dependencies {
+		dependencies[idx].Name = NormalizeString(dependencies[idx].Name)
+	}
+}
+
+func normalizeFiles(files []File) {
+	for idx := range files {
+		normalizeFile(&files[idx])
+	}
+}
+
+// normalizeFile does nothing if file is nil, e.g. for a missing recent file.
+func normalizeFile(file *File) {
+	if file == nil {
+		return
+	}
+	file.Name = NormalizeString(file.Name)
+	file.ReleaseType = NormalizeString(file.ReleaseType)
+	file.GameVersion = NormalizeString(file.GameVersion)
+	file.SizeInfo = NormalizeString(file.SizeInfo)
+	normalizeFiles(file.AdditionalFiles)
+}
+
+// normalizeComments skips the bodies, as collapsing the whitespace would remove their line breaks.
+func normalizeComments(comments []Comment) {
+	for idx := range comments {
+		comments[idx].Author.Name = NormalizeString(comments[idx].Author.Name)
+	}
+}
+
+// normalizeText runs the cleanup pass over all text values of c.
+func (c *Curse) normalizeText() {
+	c.Title = NormalizeString(c.Title)
+	c.License = NormalizeString(c.License)
+	c.Game = NormalizeString(c.Game)
+	c.AvgDownloadsTimeframe = NormalizeString(c.AvgDownloadsTimeframe)
+
+	normalizeAuthors(c.Authors)
+	normalizeCategories(c.Categories)
+	normalizeFiles(c.Downloads)
+}
+
+// normalizeText runs the cleanup pass over all text values of c.
+func (c *CurseForge) normalizeText() {
+	c.Title = NormalizeString(c.Title)
+	c.Summary = NormalizeString(c.Summary)
+	c.License = NormalizeString(c.License)
+	c.Game = NormalizeString(c.Game)
+	c.RootGameCategory = NormalizeString(c.RootGameCategory)
+
+	normalizeAuthors(c.Authors)
+	normalizeCategories(c.Categories)
+	normalizeFiles(c.Downloads)
+	// Copies of the recent files, not pointing into Downloads
+	normalizeFile(c.RecentRelease)
+	normalizeFile(c.RecentBeta)
+	normalizeFile(c.RecentAlpha)
+	normalizeDependencies(c.Dependencies)
+	normalizeDependencies(c.Dependents)
+	normalizeComments(c.Comments)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"gopkg.in/xmlpath.v2"
+)
+
+func TestNormalizeString(t *testing.T) {
+	tests := map[string]string{
+		"Tom&#39;s Mod":          "Tom's Mod",
+		"Bags &amp; Inventory":   "Bags & Inventory",
+		"  Some Title \n":        "Some Title",
+		"Multiple   \t spaces":   "Multiple spaces",
+		"Already clean":          "Already clean",
+		"":                       "",
+		"Fish &amp;&nbsp;Chips ": "Fish & Chips",
+		"TAAM-1.12.1-0.7.0.jar":  "TAAM-1.12.1-0.7.0.jar",
+	}
+	for input, expected := range tests {
+		if actual := NormalizeString(input); actual != expected {
+			t.Errorf("NormalizeString(%q): expected %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestCurseForgeNormalizeText(t *testing.T) {
+	c := &CurseForge{
+		Title:      "Tom&#39;s  Mod",
+		Authors:    []Author{{Name: "Tom ", Role: " Owner"}},
+		Categories: []Category{{Name: "Bags &amp; Inventory"}},
+		Downloads:  []File{{Name: "Tom&#39;s Mod 1.0"}},
+		// The recent files are separate copies
+		RecentRelease: &File{Name: " Tom&#39;s Mod 1.1"},
+		Comments:      []Comment{{Author: Author{Name: " Tom"}, Body: "First line\nSecond  line"}},
+	}
+	c.normalizeText()
+
+	if c.Title != "Tom's Mod" {
+		t.Errorf("Unexpected 'Title': %q", c.Title)
+	}
+	if c.Authors[0].Name != "Tom" || c.Authors[0].Role != "Owner" {
+		t.Errorf("Unexpected 'Author': %q, %q", c.Authors[0].Name, c.Authors[0].Role)
+	}
+	if c.Categories[0].Name != "Bags & Inventory" {
+		t.Errorf("Unexpected 'Category/Name': %q", c.Categories[0].Name)
+	}
+	if c.Downloads[0].Name != "Tom's Mod 1.0" {
+		t.Errorf("Unexpected 'Download/Name': %q", c.Downloads[0].Name)
+	}
+	if c.RecentRelease.Name != "Tom's Mod 1.1" {
+		t.Errorf("Unexpected 'RecentRelease/Name': %q", c.RecentRelease.Name)
+	}
+	if c.Comments[0].Author.Name != "Tom" || c.Comments[0].Body != "First line\nSecond  line" {
+		t.Errorf("Unexpected 'Comment': %q, %q", c.Comments[0].Author.Name, c.Comments[0].Body)
+	}
+}
+
+func TestFetcherRawText(t *testing.T) {
+	page := `<ul class="listing"><li class="project-list-item">
+<div class="name-wrapper overflow-tip"><a href="/projects/toms-mod">Tom&#39;s   Mod</a></div>
+<p class="e-download-count">1,234 Downloads</p>
+<p class="e-update-date"><abbr data-epoch="1503439200"></abbr></p>
+</li></ul>`
+	root, err := xmlpath.ParseHTML(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	documentURL, err := url.Parse("https://minecraft.curseforge.com/mc-mods")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		fetcher  *Fetcher
+		expected string
+	}{
+		{NewFetcher(nil), "Tom's Mod"},
+		{&Fetcher{RawText: true}, "Tom's   Mod"},
+	} {
+		projects, err := parseCFProjectSummaries(newPageLookup(v.fetcher, documentURL), root, "//li[@class='project-list-item']")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(projects) != 1 || projects[0].Title != v.expected {
+			t.Errorf("Expected title %q with RawText %t, got %+v", v.expected, v.fetcher.RawText, projects)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Project is implemented by the results of both parsers, Curse and CurseForge,
+// so the result of ParseAny can be used without a type switch.
+// The methods are prefixed, as the structs already have fields with the plain names.
+type Project interface {
+	// ProjectTitle returns the title of the project.
+	ProjectTitle() string
+	// ProjectAuthors returns the authors of the project.
+	ProjectAuthors() []Author
+	// ProjectFiles returns the files that were parsed, which may only be the most recent ones.
+	ProjectFiles() []File
+}
+
+// ProjectTitle returns Title.
+func (c *Curse) ProjectTitle() string { return c.Title }
+
+// ProjectAuthors returns Authors.
+func (c *Curse) ProjectAuthors() []Author { return c.Authors }
+
+// ProjectFiles returns Downloads.
+func (c *Curse) ProjectFiles() []File { return c.Downloads }
+
+// ProjectTitle returns Title.
+func (c *CurseForge) ProjectTitle() string { return c.Title }
+
+// ProjectAuthors returns Authors.
+func (c *CurseForge) ProjectAuthors() []Author { return c.Authors }
+
+// ProjectFiles returns Downloads.
+func (c *CurseForge) ProjectFiles() []File { return c.Downloads }
+
+// ParseAny fetches and parses a project page, picking the parser by the host of projectURL:
+// curseforge.com URLs are fetched using FetchCurseForge, mods.curse.com URLs using ParseCurse.
+// The result is a *CurseForge or a *Curse respectively.
+//
+// CurseForge projects are fetched with a single request to the overview page,
+// files are parsed from its "Recent Files" (CFOptionOverviewRecentFiles).
+// Use FetchCurseForge directly to select other sections.
+//
+// All requests are sent using DefaultFetcher, see Fetcher.ParseAny.
+func ParseAny(projectURL string) (Project, error) {
+	return DefaultFetcher.ParseAny(projectURL)
+}
+
+// ParseAny fetches and parses a project page from either site, see ParseAny() for details.
+func (f *Fetcher) ParseAny(projectURL string) (Project, error) {
+	u, err := ParseURL(projectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Explicit nil checks, a nil pointer would result in a non-nil Project
+	if isCurseForgeHost(u) {
+		results, err := f.FetchCurseForge(u, CFSectionOverview, CFOptionOverviewRecentFiles)
+		if results == nil {
+			return nil, err
+		}
+		return results, err
+	}
+	if !isCurseHost(u) {
+		return nil, fmt.Errorf("unsupported host '%s' in URL '%s'", u.Host, u.String())
+	}
+
+	resp, err := f.FetchPage(u.String())
+	if err != nil {
+		return nil, err
+	}
+	err = checkPageStatus(u.String(), resp)
+	if err != nil {
+		return nil, err
+	}
+	results, err := ParseCurse(u.String(), resp)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// isCurseHost returns true if u points to curse.com, e.g. mods.curse.com.
+func isCurseHost(u *url.URL) bool {
+	if u == nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "curse.com" || strings.HasSuffix(host, ".curse.com")
+}
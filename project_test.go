@@ -0,0 +1,70 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"net/http"
+	"testing"
+)
+
+var _ Project = (*Curse)(nil)
+var _ Project = (*CurseForge)(nil)
+
+func TestProjectAccessors(t *testing.T) {
+	cf := &CurseForge{Title: "Taam", Authors: []Author{{Name: "founderio"}}, Downloads: []File{{Name: "taam-1.0.jar"}}}
+	curse := &Curse{Title: "Taam", Authors: cf.Authors, Downloads: cf.Downloads}
+
+	for _, project := range []Project{cf, curse} {
+		if project.ProjectTitle() != "Taam" {
+			t.Errorf("Expected title 'Taam', got '%s'", project.ProjectTitle())
+		}
+		if len(project.ProjectAuthors()) != 1 || project.ProjectAuthors()[0].Name != "founderio" {
+			t.Errorf("Expected author 'founderio', got %v", project.ProjectAuthors())
+		}
+		if len(project.ProjectFiles()) != 1 || project.ProjectFiles()[0].Name != "taam-1.0.jar" {
+			t.Errorf("Expected file 'taam-1.0.jar', got %v", project.ProjectFiles())
+		}
+	}
+}
+
+func TestParseAnyUnsupportedHost(t *testing.T) {
+	transport := &countingTransport{}
+	fetcher := NewFetcher(&http.Client{Transport: transport})
+
+	project, err := fetcher.ParseAny("https://www.example.com/projects/taam")
+	if err == nil || project != nil {
+		t.Errorf("Expected an error for an unsupported host, got %v", project)
+	}
+	if transport.count != 0 {
+		t.Errorf("Expected no requests for an unsupported host, got %d", transport.count)
+	}
+}
+
+func TestIsCurseHost(t *testing.T) {
+	testValues := map[string]bool{
+		"https://mods.curse.com/addons/wow/pawn":         true,
+		"https://curse.com/":                             true,
+		"https://www.curseforge.com/minecraft/mc-mods/x": false,
+		"https://notcurse.com/":                          false,
+	}
+	for rawURL, expected := range testValues {
+		u, _ := ParseURL(rawURL)
+		if isCurseHost(u) != expected {
+			t.Errorf("Expected %t for '%s'", expected, rawURL)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// updateFixtures enables TestRecordFixtures, e.g. go test -run TestRecordFixtures -update
+var updateFixtures = flag.Bool("update", false, "record the live pages of the fixtures to testdata/recorded")
+
+// recordDir is where TestRecordFixtures saves the pages. It is separate from the fixtures used by the tests,
+// which are trimmed to the relevant markup and must not be overwritten.
+var recordDir = filepath.Join("testdata", "recorded")
+
+// recordedFixture is a live page a fixture in testdata was saved from.
+type recordedFixture struct {
+	URL string
+	// The status the page is expected to be sent with
+	Status int
+}
+
+// recordedFixtures maps the fixtures in testdata to the live pages they were saved from.
+// The remaining fixtures are constructed by hand, e.g. for API responses.
+var recordedFixtures = map[string]recordedFixture{
+	"curse_taam.html":                      {"https://mods.curse.com/mc-mods/minecraft/238424-taam", http.StatusOK},
+	"curseforge_overview_pawn.html":        {"https://wow.curseforge.com/projects/pawn", http.StatusOK},
+	"curseforge_files_taam.html":           {"https://minecraft.curseforge.com/projects/taam/files", http.StatusOK},
+	"curseforge_file_taam.html":            {"https://minecraft.curseforge.com/projects/taam/files/2444195", http.StatusOK},
+	"curseforge_file_taam_additional.html": {"https://minecraft.curseforge.com/projects/taam/files/2398011", http.StatusOK},
+	"curseforge_images_taam.html":          {"https://minecraft.curseforge.com/projects/taam/images", http.StatusOK},
+	"curseforge_dependencies_taam.html":    {"https://minecraft.curseforge.com/projects/taam/relations/dependencies", http.StatusOK},
+	"curseforge_dependents_taam.html":      {"https://minecraft.curseforge.com/projects/taam/relations/dependents", http.StatusOK},
+	"curseforge_comments_taam.html":        {"https://minecraft.curseforge.com/projects/taam/comments", http.StatusOK},
+	"curseforge_search_taam.html":          {"https://minecraft.curseforge.com/search?search=taam", http.StatusOK},
+	"curseforge_home_minecraft.html":       {"https://minecraft.curseforge.com/", http.StatusOK},
+	"curseforge_browse_mods.html":          {"https://minecraft.curseforge.com/mc-mods?filter-sort=popularity", http.StatusOK},
+	"curseforge_not_found.html":            {"https://minecraft.curseforge.com/projects/does-not-exist", http.StatusNotFound},
+}
+
+// TestRecordFixtures fetches the live pages in recordedFixtures and saves them to recordDir.
+// Only run by maintainers to refresh the fixtures. The saved pages are complete, while the fixtures in testdata
+// are trimmed to the relevant markup, so compare them and carry the changes over by hand.
+//
+// Pages sent with an unexpected status (e.g. a 503 page or the consent interstitial)
+// or redirected elsewhere (e.g. from the legacy hosts to the redesigned site) are not saved.
+func TestRecordFixtures(t *testing.T) {
+	if !*updateFixtures {
+		t.Skip("Records the fixtures, pass -update to run")
+	}
+
+	err := os.MkdirAll(recordDir, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, fixture := range recordedFixtures {
+		resp, err := DefaultFetcher.FetchPage(fixture.URL)
+		if err != nil {
+			t.Errorf("Error fetching '%s' for %s: %s", fixture.URL, name, err.Error())
+			continue
+		}
+		raw, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Errorf("Error reading '%s' for %s: %s", fixture.URL, name, err.Error())
+			continue
+		}
+		if resp.StatusCode != fixture.Status {
+			t.Errorf("Expected status %d for '%s' (%s), got %s", fixture.Status, fixture.URL, name, resp.Status)
+			continue
+		}
+		if finalURL := resp.Request.URL.String(); finalURL != fixture.URL {
+			t.Errorf("'%s' (%s) was redirected to '%s'", fixture.URL, name, finalURL)
+			continue
+		}
+		if isConsentPage(raw) {
+			t.Errorf("Got the consent interstitial for '%s' (%s)", fixture.URL, name)
+			continue
+		}
+
+		err = ioutil.WriteFile(filepath.Join(recordDir, name), raw, 0644)
+		if err != nil {
+			t.Errorf("Error writing %s: %s", name, err.Error())
+		}
+	}
+}
+
+func TestRecordedFixturesExist(t *testing.T) {
+	for name := range recordedFixtures {
+		_, err := os.Stat(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("Recorded fixture %s not found: %s", name, err.Error())
+		}
+	}
+}
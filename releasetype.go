@@ -0,0 +1,74 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"strings"
+)
+
+// ReleaseType is the release phase of a file.
+// The values are ordered by stability, so ReleaseTypeBeta >= ReleaseTypeAlpha etc.
+type ReleaseType uint8
+
+const (
+	// ReleaseTypeUnknown is used for release types that could not be recognized.
+	ReleaseTypeUnknown ReleaseType = iota
+	// ReleaseTypeAlpha marks alpha files.
+	ReleaseTypeAlpha
+	// ReleaseTypeBeta marks beta files.
+	ReleaseTypeBeta
+	// ReleaseTypeRelease marks stable releases.
+	ReleaseTypeRelease
+)
+
+// ParseReleaseType maps a release type as printed on the page ("Release", "Beta", "Alpha")
+// to a ReleaseType. Anything unrecognized is mapped to ReleaseTypeUnknown.
+func ParseReleaseType(releaseType string) ReleaseType {
+	switch strings.ToLower(strings.TrimSpace(releaseType)) {
+	case "release":
+		return ReleaseTypeRelease
+	case "beta":
+		return ReleaseTypeBeta
+	case "alpha":
+		return ReleaseTypeAlpha
+	}
+	return ReleaseTypeUnknown
+}
+
+// String returns the English title of the release type, as printed on the page.
+func (r ReleaseType) String() string {
+	switch r {
+	case ReleaseTypeRelease:
+		return "Release"
+	case ReleaseTypeBeta:
+		return "Beta"
+	case ReleaseTypeAlpha:
+		return "Alpha"
+	}
+	return "Unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler, so the release type is written to JSON by its title, see String.
+func (r ReleaseType) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, see ParseReleaseType.
+func (r *ReleaseType) UnmarshalText(text []byte) error {
+	*r = ParseReleaseType(string(text))
+	return nil
+}
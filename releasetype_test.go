@@ -0,0 +1,47 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"testing"
+)
+
+func TestParseReleaseType(t *testing.T) {
+	testValues := map[string]ReleaseType{
+		"Release":  ReleaseTypeRelease,
+		"beta":     ReleaseTypeBeta,
+		" Alpha ":  ReleaseTypeAlpha,
+		"Snapshot": ReleaseTypeUnknown,
+		"":         ReleaseTypeUnknown,
+	}
+	for releaseType, expected := range testValues {
+		if parsed := ParseReleaseType(releaseType); parsed != expected {
+			t.Errorf("Expected %d for '%s', got %d", expected, releaseType, parsed)
+		}
+	}
+}
+
+func TestReleaseTypeString(t *testing.T) {
+	for _, releaseType := range []string{"Release", "Beta", "Alpha"} {
+		if s := ParseReleaseType(releaseType).String(); s != releaseType {
+			t.Errorf("Expected '%s', got '%s'", releaseType, s)
+		}
+	}
+	if s := ReleaseTypeUnknown.String(); s != "Unknown" {
+		t.Errorf("Expected '%s', got '%s'", "Unknown", s)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+// Reset clears c for parsing into it again, e.g. in a polling loop.
+// All values are zeroed, except that the slices are truncated to length 0 and keep their capacity,
+// so the parsers append to the already allocated arrays. Maps and pointers are set to nil.
+//
+// Values handed out before the Reset (e.g. c.Downloads) share the arrays and are overwritten
+// by the next parse. Clone the results first if they are kept.
+func (c *CurseForge) Reset() {
+	if c == nil {
+		return
+	}
+	reused := CurseForge{
+		Authors:               c.Authors[:0],
+		Categories:            c.Categories[:0],
+		Screenshots:           c.Screenshots[:0],
+		Downloads:             c.Downloads[:0],
+		AvailableGameVersions: c.AvailableGameVersions[:0],
+		FileErrors:            c.FileErrors[:0],
+		Dependencies:          c.Dependencies[:0],
+		Dependents:            c.Dependents[:0],
+		IncludedProjects:      c.IncludedProjects[:0],
+		Comments:              c.Comments[:0],
+	}
+	reused.DescriptionMedia.Videos = c.DescriptionMedia.Videos[:0]
+	reused.DescriptionMedia.Images = c.DescriptionMedia.Images[:0]
+	*c = reused
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2017 Oliver Kahrmann
+
+  Licensed under the Apache License, Version 2.0 (the "License");
+  you may not use this file except in compliance with the License.
+  You may obtain a copy of the License at
+
+  http://www.apache.org/licenses/LICENSE-2.0
+
+  Unless required by applicable law or agreed to in writing, software
+  distributed under the License is distributed on an "AS IS" BASIS,
+  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express
+  or implied. See the License for the specific language governing
+  permissions and limitations under the License.
+*/
+
+package curse
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCurseForgeReset(t *testing.T) {
+	results := new(CurseForge)
+	parse := func() {
+		f, err := os.Open("testdata/curseforge_overview_pawn.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		documentURL, err := ParseURL("https://wow.curseforge.com/projects/pawn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = results.ParseCurseForgeReader(documentURL, f, true, CFSectionOverview, CFOptionFilesNoPagination)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parse()
+	expected := results.Clone()
+	authors := results.Authors
+
+	results.Reset()
+	if results.Title != "" || results.ProjectURL != nil || results.TotalDownloads != 0 || !results.Created.IsZero() {
+		t.Errorf("Expected the scalar values to be cleared, got %+v", results)
+	}
+	if len(results.Authors) != 0 || cap(results.Authors) != cap(authors) {
+		t.Errorf("Expected the authors to be truncated keeping the capacity %d, got len %d cap %d", cap(authors), len(results.Authors), cap(results.Authors))
+	}
+
+	// Parsing again gives the same results, reusing the arrays
+	parse()
+	if !reflect.DeepEqual(expected.Authors, results.Authors) || expected.Title != results.Title {
+		t.Errorf("Re-parsed results differ from the first parse")
+	}
+	if len(authors) > 0 && &authors[0] != &results.Authors[0] {
+		t.Error("Expected the authors array to be reused")
+	}
+
+	var nilResults *CurseForge
+	nilResults.Reset()
+}
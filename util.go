@@ -19,36 +19,79 @@ package curse
 import (
 	"errors"
 	"fmt"
-	"net/http"
+	"math"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"gopkg.in/xmlpath.v2"
 )
 
-var client *http.Client = &http.Client{}
+// Instance for internal use.
+var pathCache = NewXpathCache()
 
-// FetchPage performs a simple http get using a custom user agent.
-func FetchPage(url string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// ExtractString resolves the given xpath relative to context and returns the trimmed string value.
+// Use this to get fields not exposed by the parsers, e.g. from a document loaded with FetchDocument().
+// The compiled xpath is cached internally. Returns an error if the xpath is invalid.
+func ExtractString(context *xmlpath.Node, path string) (string, bool, error) {
+	p, err := pathCache.Compile(path)
 	if err != nil {
-		return nil, fmt.Errorf("Error creating http request: %s", err.Error())
+		return "", false, err
 	}
-	req.Header.Set("User-Agent", "Go-http-client/1.1 (compatible; curse-parser)")
+	s, ok := p.String(context)
+	return strings.TrimSpace(s), ok, nil
+}
 
-	return client.Do(req)
+// ExtractURL resolves the given xpath relative to context and parses the value to an URL.
+// If base is not nil, the URL is resolved using base.
+// The compiled xpath is cached internally. Returns an error if the xpath is invalid.
+func ExtractURL(context *xmlpath.Node, path string, base *url.URL) (*url.URL, error) {
+	s, ok, err := ExtractString(context, path)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("node not found")
+	}
+	if base == nil {
+		return ParseURL(s)
+	}
+	return ParseURLWithBase(s, base)
 }
 
-// Instance for internal use.
-var pathCache = NewXpathCache()
+// ExtractNode resolves the given xpath relative to context and returns the first node found.
+// The compiled xpath is cached internally. Returns an error if the xpath is invalid.
+func ExtractNode(context *xmlpath.Node, path string) (*xmlpath.Node, bool, error) {
+	iter, err := ExtractIter(context, path)
+	if err != nil {
+		return nil, false, err
+	}
+	if !iter.Next() {
+		return nil, false, nil
+	}
+	return iter.Node(), true, nil
+}
+
+// ExtractIter resolves the given xpath relative to context and returns an iterator over all nodes found.
+// The compiled xpath is cached internally. Returns an error if the xpath is invalid.
+func ExtractIter(context *xmlpath.Node, path string) (*xmlpath.Iter, error) {
+	p, err := pathCache.Compile(path)
+	if err != nil {
+		return nil, err
+	}
+	return p.Iter(context), nil
+}
 
 // XpathCache is a wrapper for the xmlpath package.
 // The wrapper functions cache the compiled XPaths instead of recompiling every time.
 // The cached instances are kept in this struct. Create a new instance with NewXpathCache().
 // An internal instance of this will be used for all parsing operations.
+// It is safe for concurrent use.
 type XpathCache struct {
+	mutex sync.RWMutex
 	paths map[string]*xmlpath.Path
 }
 
@@ -61,19 +104,37 @@ func NewXpathCache() *XpathCache {
 
 // GetCompiledPath returns a compiled xpath.
 // If the given xpath is already in the cache, the cached instance is returned.
-// Otherwise it is compiled (using xmlpath.MustCompile()) and put into cache.
-// Panics on compile errors.
+// Otherwise it is compiled and put into cache.
+// Panics on compile errors, use Compile for xpaths that are not known to be valid (e.g. user input).
 func (cache *XpathCache) GetCompiledPath(path string) *xmlpath.Path {
-	p, ok := cache.paths[path]
-	if !ok {
-		p = xmlpath.MustCompile(path)
-		cache.AddToCache(path, p)
+	p, err := cache.Compile(path)
+	if err != nil {
+		panic(err)
 	}
 	return p
 }
 
+// Compile returns a compiled xpath, like GetCompiledPath, but returns compile errors instead of panicking.
+// Invalid xpaths are not cached.
+func (cache *XpathCache) Compile(path string) (*xmlpath.Path, error) {
+	cache.mutex.RLock()
+	p, ok := cache.paths[path]
+	cache.mutex.RUnlock()
+	if ok {
+		return p, nil
+	}
+	p, err := xmlpath.Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error compiling xpath '%s': %s", path, err.Error())
+	}
+	cache.AddToCache(path, p)
+	return p, nil
+}
+
 // Clear the cache of compiled XPaths.
 func (cache *XpathCache) Clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
 	cache.paths = make(map[string]*xmlpath.Path)
 }
 
@@ -81,6 +142,8 @@ func (cache *XpathCache) Clear() {
 // WARNING: With this, you can thoroughly confuse the cache. This function cannot validate if the compiled version
 // is actually matching the uncompiled one!
 func (cache *XpathCache) AddToCache(uncompiled string, compiled *xmlpath.Path) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
 	cache.paths[uncompiled] = compiled
 }
 
@@ -114,6 +177,31 @@ func (cache *XpathCache) Node(context *xmlpath.Node, path string) (*xmlpath.Node
 	return iter.Node(), true
 }
 
+// StringAny is like String, but tries each of the given xpaths in order.
+// The value of the first path that matches is returned.
+// Used for fields with several known layout variants.
+func (cache *XpathCache) StringAny(context *xmlpath.Node, paths ...string) (string, bool) {
+	for _, path := range paths {
+		s, ok := cache.String(context, path)
+		if ok {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// NodeAny is like Node, but tries each of the given xpaths in order.
+// The first node of the first path that matches is returned.
+func (cache *XpathCache) NodeAny(context *xmlpath.Node, paths ...string) (*xmlpath.Node, bool) {
+	for _, path := range paths {
+		node, ok := cache.Node(context, path)
+		if ok {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
 // URL is a wrapper around path.String(context).
 // The given xpath is automatically compiled or pulled from cache.
 // The returned value is parsed to an URL.
@@ -125,12 +213,28 @@ func (cache *XpathCache) URL(context *xmlpath.Node, path string) (*url.URL, erro
 	return ParseURL(urlString)
 }
 
-// ParseURL attempts to parse the given string into a URL.
-// Adds the https url scheme if no scheme is missing
+// firstField returns the first whitespace separated field of s, e.g. "123" of "123 Downloads".
+// Returns false if s is empty or only contains whitespace.
+func firstField(s string) (string, bool) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
+
+// ParseURL attempts to parse the given string into a URL. Surrounding whitespace is trimmed.
+// Adds the https url scheme if the scheme is missing
 // (link urls may be specified in schemeless format "//www.curseforge.com/...")
+// The parsers resolve all links using ParseURLWithBase instead, so relative and
+// protocol-relative links are resolved against the page they are found on.
 func ParseURL(urlString string) (*url.URL, error) {
+	// Inline data is kept as-is
+	if dataURL, ok := parseDataURL(urlString); ok {
+		return dataURL, nil
+	}
 	// Parse to url
-	url, err := url.Parse(urlString)
+	url, err := url.Parse(strings.TrimSpace(urlString))
 	if err != nil {
 		return url, err
 	}
@@ -141,6 +245,57 @@ func ParseURL(urlString string) (*url.URL, error) {
 	return url, nil
 }
 
+// lastNumericPathSegment returns the last path segment of the URL that is a number.
+// e.g. https://minecraft.curseforge.com/projects/taam/files/2444195/download -> 2444195
+// Returns false if there is no such segment.
+func lastNumericPathSegment(u *url.URL) (uint64, bool) {
+	if u == nil {
+		return 0, false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		id, err := strconv.ParseUint(segments[i], 10, 64)
+		if err == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// ParseProjectID returns the numeric project ID contained in a project URL.
+// Supported are the /projects/NNN pattern, e.g. https://www.curseforge.com/projects/19373,
+// and the legacy curse.com links, e.g. https://mods.curse.com/mc-mods/minecraft/238424-taam.
+// Returns false if the URL does not contain the ID, e.g. for slug URLs like https://minecraft.curseforge.com/projects/taam.
+func ParseProjectID(u *url.URL) (uint64, bool) {
+	if u == nil {
+		return 0, false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] != "projects" {
+			continue
+		}
+		id, err := strconv.ParseUint(segments[i+1], 10, 64)
+		if err == nil {
+			return id, true
+		}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "curse.com" || strings.HasSuffix(host, ".curse.com") {
+		// The last segment is "<id>-<slug>"
+		last := segments[len(segments)-1]
+		if idx := strings.IndexByte(last, '-'); idx >= 0 {
+			last = last[:idx]
+		}
+		id, err := strconv.ParseUint(last, 10, 64)
+		if err == nil {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
 // URLWithBase is a wrapper around path.String(context).
 // The given xpath is automatically compiled or pulled from cache.
 // The returned value is parsed to an URL and resolved using 'base'.
@@ -173,6 +328,33 @@ func (cache *XpathCache) URLWithBaseURL(context *xmlpath.Node, path string, base
 	if !ok {
 		return nil, errors.New("node not found")
 	}
+	return ParseURLWithBase(urlString, base)
+}
+
+// URLAny is like URLWithBaseURL, but tries each of the given xpaths in order.
+// The value of the first path that matches is parsed & resolved using 'base'.
+func (cache *XpathCache) URLAny(context *xmlpath.Node, base *url.URL, paths ...string) (*url.URL, error) {
+	urlString, ok := cache.StringAny(context, paths...)
+	if !ok {
+		return nil, errors.New("node not found")
+	}
+	return ParseURLWithBase(urlString, base)
+}
+
+// anyPaths joins candidate xpaths for debug output & parse errors.
+func anyPaths(paths ...string) string {
+	return strings.Join(paths, " | ")
+}
+
+// ParseURLWithBase attempts to parse the given string into a URL and resolves it using 'base'.
+// Protocol-relative URLs ("//media.forgecdn.net/...") inherit the scheme of 'base'.
+// Adds the https url scheme if the scheme is still missing after resolving.
+// data: URIs (inline images) are returned as-is, see IsDataURL.
+func ParseURLWithBase(urlString string, base *url.URL) (*url.URL, error) {
+	// Inline data is kept as-is
+	if dataURL, ok := parseDataURL(urlString); ok {
+		return dataURL, nil
+	}
 	// Parse to url
 	parsedURL, err := url.Parse(strings.TrimSpace(urlString))
 	if err != nil {
@@ -187,6 +369,23 @@ func (cache *XpathCache) URLWithBaseURL(context *xmlpath.Node, path string, base
 	return parsedURL, nil
 }
 
+// IsDataURL returns true if u is a data: URI, i.e. an image inlined into the page.
+// Such URLs do not point to a host and contain the data itself.
+func IsDataURL(u *url.URL) bool {
+	return u != nil && u.Scheme == "data"
+}
+
+// parseDataURL returns a URL for a data: URI, keeping the data unparsed.
+// (The data may contain characters that are not valid in URLs, e.g. stray percent signs.)
+// Returns false if urlString is not a data: URI.
+func parseDataURL(urlString string) (*url.URL, bool) {
+	urlString = strings.TrimSpace(urlString)
+	if len(urlString) < 5 || !strings.EqualFold(urlString[:5], "data:") {
+		return nil, false
+	}
+	return &url.URL{Scheme: "data", Opaque: urlString[5:]}, true
+}
+
 // UInt is a wrapper around path.String(context).
 // The given xpath is automatically compiled or pulled from cache.
 // The returned value is parsed to an uint64, base 10. Commas (decimal separator) are stripped before parsing.
@@ -213,6 +412,138 @@ func ParseUInt(parseString string) (uint64, error) {
 	return strconv.ParseUint(str, 10, 64)
 }
 
+// UIntLocale is a wrapper around path.String(context).
+// The given xpath is automatically compiled or pulled from cache.
+// The returned value is parsed to an uint64 using ParseUIntLocale.
+func (cache *XpathCache) UIntLocale(context *xmlpath.Node, path string, sep rune) (uint64, error) {
+	parseString, ok := cache.String(context, path)
+	if !ok {
+		return 0, errors.New("node not found")
+	}
+	return ParseUIntLocale(parseString, sep)
+}
+
+// ParseUIntLocale attempts to parse the given string to an uint64, using sep as thousands separator.
+// "-" is treated as 0. All occurrences of sep and whitespace (including non-breaking spaces,
+// which some locales use as separator) are removed from the input string.
+func ParseUIntLocale(parseString string, sep rune) (uint64, error) {
+	str := strings.TrimSpace(parseString)
+	// Download counts of '0' are represented as '-'
+	if str == "-" {
+		return 0, nil
+	}
+	str = strings.Map(func(r rune) rune {
+		if r == sep || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, str)
+	return strconv.ParseUint(str, 10, 64)
+}
+
+// countSuffixes maps the (lowercase) suffixes of abbreviated counts to their factor, e.g. "1.2M".
+var countSuffixes = map[byte]float64{
+	'k': 1e3,
+	'm': 1e6,
+	'b': 1e9,
+}
+
+// Count is a wrapper around path.String(context).
+// The given xpath is automatically compiled or pulled from cache.
+// The returned value is parsed to an uint64 using ParseCount.
+func (cache *XpathCache) Count(context *xmlpath.Node, path string) (uint64, error) {
+	parseString, ok := cache.String(context, path)
+	if !ok {
+		return 0, errors.New("node not found")
+	}
+	return ParseCount(parseString)
+}
+
+// CountLocale is a wrapper around path.String(context).
+// The given xpath is automatically compiled or pulled from cache.
+// The returned value is parsed to an uint64 using ParseCountLocale.
+func (cache *XpathCache) CountLocale(context *xmlpath.Node, path string, sep rune) (uint64, error) {
+	parseString, ok := cache.String(context, path)
+	if !ok {
+		return 0, errors.New("node not found")
+	}
+	return ParseCountLocale(parseString, sep)
+}
+
+// ParseCount attempts to parse a count (e.g. downloads) to an uint64,
+// using ParseCountLocale with DefaultThousandsSeparator.
+func ParseCount(parseString string) (uint64, error) {
+	return ParseCountLocale(parseString, DefaultThousandsSeparator)
+}
+
+// ParseCountLocale attempts to parse a count (e.g. downloads) to an uint64, using sep as thousands separator.
+// Abbreviated counts with a k, M or B suffix ("340k", "1.2M") are multiplied accordingly,
+// so the result is an approximation. The decimal separator is '.', or ',' if sep is '.'.
+// Full numbers are parsed exactly using ParseUIntLocale, "-" is treated as 0.
+func ParseCountLocale(parseString string, sep rune) (uint64, error) {
+	str := strings.TrimSpace(parseString)
+	if str == "" {
+		return 0, errors.New("empty count")
+	}
+	factor, ok := countSuffixes[byte(unicode.ToLower(rune(str[len(str)-1])))]
+	if !ok {
+		return ParseUIntLocale(str, sep)
+	}
+
+	mantissa := strings.TrimSpace(str[:len(str)-1])
+	if sep == '.' {
+		mantissa = strings.Replace(mantissa, ",", ".", 1)
+	}
+	value, err := strconv.ParseFloat(mantissa, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value < 0 || math.IsInf(value, 0) || math.IsNaN(value) {
+		return 0, fmt.Errorf("invalid count '%s'", parseString)
+	}
+	return uint64(math.Round(value * factor)), nil
+}
+
+// fileSizeUnits maps the (lowercase) units used for file sizes to their factor.
+// CurseForge calculates KB/MB/GB using powers of 1024, same as KiB/MiB/GiB.
+var fileSizeUnits = map[string]float64{
+	"b":     1,
+	"byte":  1,
+	"bytes": 1,
+	"kb":    1 << 10,
+	"kib":   1 << 10,
+	"mb":    1 << 20,
+	"mib":   1 << 20,
+	"gb":    1 << 30,
+	"gib":   1 << 30,
+}
+
+// ParseFileSize attempts to parse a file size as printed on the files page, e.g. "1.24 MB", to a byte count.
+// Supported units are bytes, KB, MB & GB and their binary variants KiB, MiB & GiB. The space before the unit is optional.
+// Commas are removed from the number. (English number format is assumed!)
+func ParseFileSize(sizeInfo string) (uint64, error) {
+	str := strings.Replace(strings.TrimSpace(sizeInfo), ",", "", -1)
+
+	// Split into number & unit
+	idx := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if idx <= 0 {
+		return 0, fmt.Errorf("invalid file size '%s'", sizeInfo)
+	}
+	number, unit := str[:idx], strings.ToLower(strings.TrimSpace(str[idx:]))
+
+	factor, ok := fileSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown file size unit '%s'", unit)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(value*factor + 0.5), nil
+}
+
 // Int is a wrapper around path.String(context).
 // The given xpath is automatically compiled or pulled from cache.
 // The returned value is parsed to an int64, base 10. Commas (decimal separator) are stripped before parsing.
@@ -243,11 +574,12 @@ func ParseInt(parseString string) (int64, error) {
 // The given xpath is automatically compiled or pulled from cache.
 // The returned value is parsed to an int64, base 10, and interpreted as a unix time stamp.
 // Commas (decimal separator) are stripped before parsing.
-// The time.Time returned will be set to UTC. If there is a parsing error, time.Unix(0, 0).UTC() is returned.
+// The time.Time returned will be set to UTC. If the node is missing or cannot be parsed, the zero time is returned,
+// so a missing date (time.Time.IsZero) can be told apart from a genuine time stamp of 0.
 func (cache *XpathCache) UnixTimestamp(context *xmlpath.Node, path string) (time.Time, error) {
 	ts, err := cache.Int(context, path)
 	if err != nil {
-		return time.Unix(0, 0).UTC(), err
+		return time.Time{}, err
 	}
 
 	return time.Unix(ts, 0).UTC(), nil